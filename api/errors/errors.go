@@ -19,12 +19,16 @@ type ErrorResponse struct {
 
 // Common error codes
 const (
-	ErrCodeValidation     = "VALIDATION_ERROR"
-	ErrCodeAuthentication = "AUTHENTICATION_ERROR"
-	ErrCodeAuthorization  = "AUTHORIZATION_ERROR"
-	ErrCodeNotFound       = "NOT_FOUND"
-	ErrCodeInternal       = "INTERNAL_ERROR"
-	ErrCodeDuplicate      = "DUPLICATE_ERROR"
+	ErrCodeValidation       = "VALIDATION_ERROR"
+	ErrCodeAuthentication   = "AUTHENTICATION_ERROR"
+	ErrCodeAuthorization    = "AUTHORIZATION_ERROR"
+	ErrCodeNotFound         = "NOT_FOUND"
+	ErrCodeInternal         = "INTERNAL_ERROR"
+	ErrCodeDuplicate        = "DUPLICATE_ERROR"
+	ErrCodeEmailNotVerified = "EMAIL_NOT_VERIFIED"
+	ErrCodeMFAUnavailable   = "MFA_UNAVAILABLE"
+	ErrCodeTooManyAttempts  = "TOO_MANY_ATTEMPTS"
+	ErrCodeRateLimited      = "RATE_LIMITED"
 )
 
 // NewAPIError creates a new API error
@@ -61,6 +65,14 @@ func AuthenticationError(c *fiber.Ctx, message string) error {
 	))
 }
 
+func AuthorizationError(c *fiber.Ctx, message string) error {
+	return SendError(c, fiber.StatusForbidden, NewAPIError(
+		ErrCodeAuthorization,
+		message,
+		"",
+	))
+}
+
 func NotFoundError(c *fiber.Ctx, message string) error {
 	return SendError(c, fiber.StatusNotFound, NewAPIError(
 		ErrCodeNotFound,
@@ -76,3 +88,11 @@ func InternalError(c *fiber.Ctx, message string) error {
 		"",
 	))
 }
+
+func RateLimitError(c *fiber.Ctx, message string) error {
+	return SendError(c, fiber.StatusTooManyRequests, NewAPIError(
+		ErrCodeRateLimited,
+		message,
+		"",
+	))
+}
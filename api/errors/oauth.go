@@ -0,0 +1,46 @@
+package errors
+
+import (
+	"github.com/gofiber/fiber/v2"
+)
+
+// OAuth 2.0 error codes (RFC 6749 sections 4.1.2.1 and 5.2, RFC 7009 section 2.2.1,
+// RFC 6750 section 3.1, RFC 8628 section 3.5)
+const (
+	OAuthInvalidRequest          = "invalid_request"
+	OAuthInvalidClient           = "invalid_client"
+	OAuthInvalidGrant            = "invalid_grant"
+	OAuthInvalidScope            = "invalid_scope"
+	OAuthUnauthorizedClient      = "unauthorized_client"
+	OAuthUnsupportedGrantType    = "unsupported_grant_type"
+	OAuthUnsupportedResponseType = "unsupported_response_type"
+	OAuthUnsupportedTokenType    = "unsupported_token_type"
+	OAuthAccessDenied            = "access_denied"
+	OAuthInsufficientScope       = "insufficient_scope"
+	OAuthServerError             = "server_error"
+	OAuthAuthorizationPending    = "authorization_pending"
+	OAuthSlowDown                = "slow_down"
+	OAuthExpiredToken            = "expired_token"
+)
+
+// OAuthError represents an OAuth 2.0 error response. The /oauth endpoints answer
+// with this format instead of the standard error envelope, as OAuth clients expect.
+type OAuthError struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+// SendOAuthError sends an OAuth 2.0 error response
+func SendOAuthError(c *fiber.Ctx, status int, code, description string) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.Status(status).JSON(OAuthError{
+		Code:        code,
+		Description: description,
+	})
+}
+
+// InvalidClientError rejects a request whose client authentication failed
+func InvalidClientError(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderWWWAuthenticate, `Basic realm="oauth"`)
+	return SendOAuthError(c, fiber.StatusUnauthorized, OAuthInvalidClient, "Client authentication failed")
+}
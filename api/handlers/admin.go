@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/api/validators"
+	"fiber-api/config"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/manager"
+)
+
+// AdminUnlockUserHandler clears the failed login count and any lockout of a user
+func AdminUnlockUserHandler(queries *generated.Queries, attempts repository.LoginAttemptRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		userID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return errors.ValidationError(c, "Invalid user ID")
+		}
+
+		profile, err := queries.GetUserProfile(ctx, userID)
+		if err != nil {
+			return errors.NotFoundError(c, "User not found")
+		}
+
+		if err := helpers.ClearFailedLogins(ctx, attempts, profile.UserEmail); err != nil {
+			log.Printf("❌ Failed to unlock user %s: %v", profile.UserEmail, err)
+			return errors.InternalError(c, "Failed to unlock account")
+		}
+
+		adminID, _ := c.Locals("user_id").(string)
+		log.Printf("🔒 Account %s unlocked by admin %s", profile.UserEmail, adminID)
+		return c.JSON(presenter.AccountUnlockedResponse())
+	}
+}
+
+// AdminCreateOAuthClientHandler registers an OAuth client and returns its secret once.
+// The registering admin is recorded as the client's owner.
+func AdminCreateOAuthClientHandler(clients repository.OAuthClientRepository, oauth config.OAuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse request body
+		var input models.RegisterOAuthClient
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateRegisterOAuthClient(input, oauth.Scopes)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		adminID, _ := c.Locals("user_id").(string)
+		client := &repository.OAuthClient{
+			Name:         strings.TrimSpace(input.Name),
+			RedirectURIs: input.RedirectURIs,
+			GrantTypes:   input.GrantTypes,
+			Scopes:       input.Scopes,
+			Public:       input.Public,
+		}
+		if ownerID, err := uuid.Parse(adminID); err == nil {
+			client.OwnerID = uuid.NullUUID{UUID: ownerID, Valid: true}
+		}
+
+		// Public clients have no secret to keep
+		var secret string
+		if !client.Public {
+			var err error
+			secret, client.ClientSecretHash, err = helpers.GenerateClientSecret()
+			if err != nil {
+				log.Printf("❌ Failed to generate oauth client secret: %v", err)
+				return errors.InternalError(c, "Failed to register client")
+			}
+		}
+
+		if err := clients.CreateClient(ctx, client); err != nil {
+			log.Printf("❌ Failed to register oauth client %s: %v", client.Name, err)
+			return errors.InternalError(c, "Failed to register client")
+		}
+
+		log.Printf("🔒 OAuth client %s (%s) registered by admin %s", client.ClientID.String(), client.Name, adminID)
+		return c.Status(fiber.StatusCreated).JSON(presenter.OAuthClientCreatedResponse(client, secret))
+	}
+}
+
+// AdminListOAuthClientsHandler lists the registered OAuth clients
+func AdminListOAuthClientsHandler(clients repository.OAuthClientRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		list, err := clients.ListClients(c.Context())
+		if err != nil {
+			log.Printf("❌ Failed to list oauth clients: %v", err)
+			return errors.InternalError(c, "Failed to list clients")
+		}
+		return c.JSON(presenter.OAuthClientListResponse(list))
+	}
+}
+
+// AdminDeleteOAuthClientHandler removes an OAuth client; it can no longer authenticate
+// and its client_credentials tokens stop working
+func AdminDeleteOAuthClientHandler(jwkManager manager.JwkManager, clients repository.OAuthClientRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		clientID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return errors.ValidationError(c, "Invalid client ID")
+		}
+
+		if err := clients.DeleteClient(c.Context(), clientID); err != nil {
+			if err == repository.ErrNotFound {
+				return errors.NotFoundError(c, "Client not found")
+			}
+			log.Printf("❌ Failed to delete oauth client %s: %v", clientID.String(), err)
+			return errors.InternalError(c, "Failed to delete client")
+		}
+		if err := helpers.RevokeClientKeys(jwkManager, clientID); err != nil {
+			log.Printf("❌ Failed to revoke keys of deleted oauth client %s: %v", clientID.String(), err)
+			return errors.InternalError(c, "Failed to revoke client tokens")
+		}
+
+		adminID, _ := c.Locals("user_id").(string)
+		log.Printf("🔒 OAuth client %s deleted by admin %s", clientID.String(), adminID)
+		return c.JSON(presenter.OAuthClientDeletedResponse())
+	}
+}
@@ -1,26 +1,29 @@
 package handlers
 
 import (
-	"database/sql"
 	"fiber-api/api/errors"
 	"fiber-api/api/handlers/helpers"
 	"fiber-api/api/middleware"
 	"fiber-api/api/models"
 	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
 	"fiber-api/api/validators"
+	"fiber-api/config"
+	"fiber-api/pkg/mailer"
 	"log"
+	"math"
+	"strconv"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/sushan531/auth-sqlc/generated"
-	"github.com/sushan531/jwk-auth/core/manager"
-	"github.com/sushan531/jwk-auth/service"
 	"golang.org/x/crypto/bcrypt"
 )
 
-func UserSignUpHandler(queries *generated.Queries) fiber.Handler {
+// UserSignUpHandler registers a public account. The role is decided by the signup
+// policy: the default role, or a self-service role the caller explicitly requested.
+func UserSignUpHandler(queries *generated.Queries, signup config.SignupConfig, tokens repository.OneTimeTokenRepository, mail mailer.Mailer, verification config.EmailVerificationConfig) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		ctx := c.Context()
-
 		// Parse request body
 		var input models.SignUp
 		if err := c.BodyParser(&input); err != nil {
@@ -40,41 +43,79 @@ func UserSignUpHandler(queries *generated.Queries) fiber.Handler {
 			})
 		}
 
-		// Hash password
-		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+		// Apply the signup role policy
+		role, err := signup.SignupRole(input.UserRole)
 		if err != nil {
-			log.Printf("❌ Failed to hash password for %s: %v", input.UserEmail, err)
-			return errors.InternalError(c, "Failed to process password")
+			log.Printf("🔒 Rejected self-service signup for %s requesting role %s", input.UserEmail, input.UserRole)
+			return errors.AuthorizationError(c, "This role cannot be requested at signup")
 		}
 
-		// Prepare user parameters
-		userParams := generated.InsertUserProfileParams{
-			UserEmail: input.UserEmail,
-			Password:  string(hashedPassword),
-			FullName:  input.FullName,
-			UserRole:  sql.NullString{String: input.UserRole, Valid: input.UserRole != ""},
-			Address:   sql.NullString{String: input.Address, Valid: input.Address != ""},
+		return createUserAccount(c, queries, input, role, tokens, mail, verification)
+	}
+}
+
+// AdminCreateUserHandler registers an account with any valid role. Must be mounted behind RequireRole("admin").
+func AdminCreateUserHandler(queries *generated.Queries, signup config.SignupConfig, tokens repository.OneTimeTokenRepository, mail mailer.Mailer, verification config.EmailVerificationConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Parse request body
+		var input models.SignUp
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
 		}
 
-		// Insert new user record
-		user, err := queries.InsertUserProfile(ctx, userParams)
-		if err != nil {
-			log.Printf("❌ Failed to insert user %s: %v", input.UserEmail, err)
-			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+		// Validate input
+		validation := validators.ValidateSignUp(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 				"success": false,
 				"error": fiber.Map{
-					"code":    "DUPLICATE_ERROR",
-					"message": "Email already exists",
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
 				},
 			})
 		}
 
-		// Return success response
-		return c.Status(fiber.StatusCreated).JSON(presenter.SignUpSuccessResponse(user))
+		role := input.UserRole
+		if role == "" {
+			role = signup.DefaultRole
+		}
+
+		adminID, _ := c.Locals("user_id").(string)
+		log.Printf("🔒 Admin %s creating user %s with role %s", adminID, input.UserEmail, role)
+		return createUserAccount(c, queries, input, role, tokens, mail, verification)
+	}
+}
+
+// createUserAccount inserts the profile, emails a verification link and writes the signup response
+func createUserAccount(c *fiber.Ctx, queries *generated.Queries, input models.SignUp, role string, tokens repository.OneTimeTokenRepository, mail mailer.Mailer, verification config.EmailVerificationConfig) error {
+	// Insert new user record
+	user, err := helpers.CreateUserProfile(c.Context(), queries, input, role)
+	if err == helpers.ErrPasswordHashing {
+		log.Printf("❌ Failed to hash password for %s: %v", input.UserEmail, err)
+		return errors.InternalError(c, "Failed to process password")
+	}
+	if err != nil {
+		log.Printf("❌ Failed to insert user %s: %v", input.UserEmail, err)
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"success": false,
+			"error": fiber.Map{
+				"code":    "DUPLICATE_ERROR",
+				"message": "Email already exists",
+			},
+		})
+	}
+
+	// The account exists either way; the user can ask for a new link if this fails
+	if err := helpers.SendEmailVerification(c.Context(), tokens, mail, verification, user.UserProfileID, input.UserEmail); err != nil {
+		log.Printf("❌ Failed to send verification email to %s: %v", input.UserEmail, err)
 	}
+
+	// Return success response
+	return c.Status(fiber.StatusCreated).JSON(presenter.SignUpSuccessResponse(user))
 }
 
-func LoginHandler(queries *generated.Queries, jwkManager manager.JwkManager, tokenService service.TokenService) fiber.Handler {
+func LoginHandler(queries *generated.Queries, issuer *helpers.SessionIssuer, verifications repository.EmailVerificationRepository, verification config.EmailVerificationConfig, tokens repository.OneTimeTokenRepository, mfa repository.MFARepository, mfaConfig config.MFAConfig, attempts repository.LoginAttemptRepository, lockout config.LockoutConfig) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx := c.Context()
 
@@ -97,45 +138,84 @@ func LoginHandler(queries *generated.Queries, jwkManager manager.JwkManager, tok
 			})
 		}
 
-		// Fetch user auth record
+		// Refuse attempts while the email is backing off after failures or locked
+		retryAfter, err := helpers.LoginRetryAfter(ctx, attempts, lockout, input.UserEmail)
+		if err != nil {
+			log.Printf("❌ Failed to check login attempts for %s: %v", input.UserEmail, err)
+			return errors.InternalError(c, "Failed to verify account status")
+		}
+		if retryAfter > 0 {
+			log.Printf("🔒 Login attempt for %s refused, retry allowed in %s", input.UserEmail, retryAfter.Round(time.Second))
+			return tooManyLoginAttempts(c, retryAfter)
+		}
+
+		// Fetch user auth record; unknown emails still cost a bcrypt comparison and count
+		// as failures, so neither timing nor throttling reveals whether the account exists
 		auth, err := queries.GetUserAuth(ctx, input.UserEmail)
 		if err != nil {
+			helpers.CompareDummyPassword(input.Password)
 			log.Printf("❌ Failed to fetch user for email %s: %v", input.UserEmail, err)
-			return errors.AuthenticationError(c, "Invalid email or password")
+			return failedLogin(c, attempts, lockout, input.UserEmail)
 		}
 
 		// Validate password using bcrypt
 		if err := bcrypt.CompareHashAndPassword([]byte(auth.Password), []byte(input.Password)); err != nil {
 			log.Printf("❌ Invalid password attempt for user %s", input.UserEmail)
-			return errors.AuthenticationError(c, "Invalid email or password")
+			return failedLogin(c, attempts, lockout, input.UserEmail)
 		}
 
-		// Get device type from middleware
-		deviceType := middleware.GetDeviceType(c)
-
-		// Generate device fingerprint from User-Agent from request
-		userAgent := c.Get("User-Agent")
-		deviceFingerprint := helpers.GenerateDeviceFingerprint(userAgent)
+		// A correct password resets the failure count
+		if err := helpers.ClearFailedLogins(ctx, attempts, input.UserEmail); err != nil {
+			log.Printf("❌ Failed to reset login attempts for %s: %v", input.UserEmail, err)
+		}
 
-		// Create a new session key with device type
-		keyID, err := jwkManager.CreateSessionKey(auth.UserProfileID.String(), string(deviceType))
-		if err != nil {
-			log.Printf("❌ Failed to create session key for user %s on device %s: %v", input.UserEmail, deviceType, err)
-			return errors.InternalError(c, "Failed to create session key")
+		// Refuse unverified accounts when verification is required
+		if verification.Required {
+			verified, err := verifications.IsEmailVerified(ctx, auth.UserProfileID)
+			if err != nil {
+				log.Printf("❌ Failed to check email verification for user %s: %v", input.UserEmail, err)
+				return errors.InternalError(c, "Failed to verify account status")
+			}
+			if !verified {
+				log.Printf("🔒 Login refused for unverified user %s", input.UserEmail)
+				return errors.SendError(c, fiber.StatusForbidden, errors.NewAPIError(
+					errors.ErrCodeEmailNotVerified,
+					"Email address has not been verified",
+					"",
+				))
+			}
 		}
 
-		// Create JWT claims with device fingerprint
-		claims, err := helpers.CreateJWTClaims(queries, ctx, auth.UserProfileID, deviceFingerprint.Hash)
+		// Users with MFA enabled get a challenge to complete at /login/mfa instead of tokens
+		mfaEnabled, err := helpers.IsMFAEnabled(ctx, mfa, auth.UserProfileID)
 		if err != nil {
-			log.Printf("❌ Failed to create JWT claims for user %s: %v", input.UserEmail, err)
-			return errors.InternalError(c, "Failed to create JWT claims")
+			log.Printf("❌ Failed to check mfa for user %s: %v", input.UserEmail, err)
+			return errors.InternalError(c, "Failed to verify account status")
+		}
+		if mfaEnabled {
+			challenge, err := helpers.CreateMFAChallenge(ctx, tokens, mfaConfig, auth.UserProfileID)
+			if err != nil {
+				log.Printf("❌ Failed to create mfa challenge for user %s: %v", input.UserEmail, err)
+				return errors.InternalError(c, "Failed to start two-factor authentication")
+			}
+			log.Printf("🔒 MFA challenge issued to user %s", input.UserEmail)
+			return c.JSON(presenter.MFAChallengeRequiredResponse(challenge, int(mfaConfig.ChallengeTTL.Seconds())))
 		}
 
-		// Generate token pair
-		tokenPair, err := tokenService.GenerateTokenPairWithKeyID(claims.ToMap(), keyID)
+		// Get device type from middleware
+		deviceType := middleware.GetDeviceType(c)
+
+		// Open a session for this device and issue its token pair
+		tokenPair, err := issuer.IssueSession(ctx, helpers.SessionRequest{
+			UserID:      auth.UserProfileID,
+			DeviceType:  string(deviceType),
+			UserAgent:   middleware.GetUserAgentHeaders(c),
+			IPAddress:   c.IP(),
+			AuthMethods: []string{models.AMRPassword},
+		})
 		if err != nil {
-			log.Printf("❌ Failed to generate tokens for user %s: %v", input.UserEmail, err)
-			return errors.InternalError(c, "Failed to generate tokens")
+			log.Printf("❌ Failed to open session for user %s on device %s: %v", input.UserEmail, deviceType, err)
+			return errors.InternalError(c, "Failed to create session")
 		}
 
 		// Return successful response
@@ -144,7 +224,28 @@ func LoginHandler(queries *generated.Queries, jwkManager manager.JwkManager, tok
 	}
 }
 
-func RefreshTokenHandler(queries *generated.Queries, tokenService service.TokenService) fiber.Handler {
+// failedLogin counts a failed password login and responds with the generic credentials error
+func failedLogin(c *fiber.Ctx, attempts repository.LoginAttemptRepository, lockout config.LockoutConfig, email string) error {
+	locked, err := helpers.RecordFailedLogin(c.Context(), attempts, lockout, email)
+	if err != nil {
+		log.Printf("❌ Failed to record failed login for %s: %v", email, err)
+	} else if locked {
+		log.Printf("🔒 Login for %s locked for %s after repeated failures", email, lockout.Duration)
+	}
+	return errors.AuthenticationError(c, "Invalid email or password")
+}
+
+// tooManyLoginAttempts responds 429 with the seconds until the next allowed attempt
+func tooManyLoginAttempts(c *fiber.Ctx, retryAfter time.Duration) error {
+	c.Set(fiber.HeaderRetryAfter, strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+	return errors.SendError(c, fiber.StatusTooManyRequests, errors.NewAPIError(
+		errors.ErrCodeTooManyAttempts,
+		"Too many failed login attempts. Try again later",
+		"",
+	))
+}
+
+func RefreshTokenHandler(issuer *helpers.SessionIssuer) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		ctx := c.Context()
 		// Parse request body
@@ -156,58 +257,33 @@ func RefreshTokenHandler(queries *generated.Queries, tokenService service.TokenS
 				"error": "Invalid request payload",
 			})
 		}
-		// Verify the refresh token
-		refreshClaims, err := tokenService.VerifyRefreshToken(req.RefreshToken)
-		if err != nil {
+
+		// Rotate the session; OAuth client sessions refresh through /oauth/token instead
+		tokenPair, _, err := issuer.RefreshSession(ctx, helpers.RefreshRequest{
+			RefreshToken: req.RefreshToken,
+			UserAgent:    middleware.GetUserAgentHeaders(c),
+			IPAddress:    c.IP(),
+		})
+		switch {
+		case err == nil:
+			return c.JSON(presenter.SignInSuccessResponse(*tokenPair))
+		case err == helpers.ErrInvalidRefreshToken:
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid or expired refresh token",
 			})
-		}
-		// Parse user_id from claims
-		userID, err := helpers.ExtractUserIdFromMapObj(refreshClaims)
-		if err != nil {
-			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
-				"error": err.Error(),
-			})
-		}
-		// Extract keyID from token
-		keyID, err := tokenService.ExtractKeyIDFromToken(req.RefreshToken)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-				"error": "Invalid refresh token",
-			})
-		}
-		// Extract device fingerprint from refresh token claims
-		storedFingerprint, hasFingerprintClaim := helpers.GetFingerprintFromClaims(refreshClaims)
-		if !hasFingerprintClaim {
+		case err == helpers.ErrMissingFingerprint:
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Invalid refresh token: missing device fingerprint",
 			})
-		}
-
-		// Validate current device fingerprint against stored one
-		currentUserAgent := c.Get("User-Agent")
-		if !helpers.ValidateDeviceFingerprint(currentUserAgent, storedFingerprint) {
-			log.Printf("❌ Device fingerprint mismatch for user %s during token refresh", userID.String())
+		case err == helpers.ErrFingerprintMismatch:
 			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Device fingerprint mismatch.",
 			})
-		}
-
-		// Create new JWT claims with same device fingerprint
-		claims, err := helpers.CreateJWTClaims(queries, ctx, userID, storedFingerprint)
-		if err != nil {
+		default:
+			log.Printf("❌ Failed to refresh tokens: %v", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-				"error": "Failed to create JWT claims for token refresh",
-			})
-		}
-		// Generate refreshed tokens
-		tokenPair, err := tokenService.RefreshTokensWithKeyID(req.RefreshToken, claims.ToMap(), keyID)
-		if err != nil {
-			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 				"error": "Failed to refresh tokens",
 			})
 		}
-		return c.JSON(presenter.SignInSuccessResponse(*tokenPair))
 	}
 }
@@ -0,0 +1,235 @@
+package handlers
+
+import (
+	"database/sql"
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// userCodeAttempts bounds the retries when a generated user code is already taken
+const userCodeAttempts = 3
+
+// DeviceAuthorizationHandler starts the device authorization grant (RFC 8628 section 3.1)
+// for clients that cannot open a browser. The device shows the user code and verification
+// URI, then polls the token endpoint with the device code until the user decides.
+func DeviceAuthorizationHandler(clients repository.OAuthClientRepository, devices repository.OAuthDeviceAuthorizationRepository, cfg config.OAuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		client, ok := authenticateClient(c, clients, true)
+		if !ok {
+			return errors.InvalidClientError(c)
+		}
+		if !client.AllowsGrant(models.GrantTypeDeviceCode) {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthUnauthorizedClient, "The client may not use the device authorization grant")
+		}
+		scopes := helpers.ParseScope(c.FormValue("scope"))
+		if !client.AllowsScopes(scopes) {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidScope, "The requested scope is not allowed for this client")
+		}
+
+		deviceCode, err := helpers.GenerateOpaqueToken()
+		if err != nil {
+			log.Printf("❌ Failed to generate device code: %v", err)
+			return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to start device authorization")
+		}
+		authorization := &repository.OAuthDeviceAuthorization{
+			DeviceCodeHash: helpers.HashToken(deviceCode),
+			ClientID:       client.ClientID,
+			Scope:          strings.Join(scopes, " "),
+			Interval:       cfg.DevicePollInterval,
+			ExpiresAt:      time.Now().Add(cfg.DeviceCodeTTL),
+		}
+
+		// User codes are short, so retry the rare collision with a pending request
+		for attempt := 1; ; attempt++ {
+			if authorization.UserCode, err = helpers.GenerateUserCode(); err == nil {
+				err = devices.CreateDeviceAuthorization(ctx, authorization)
+			}
+			if err != repository.ErrDuplicate || attempt == userCodeAttempts {
+				break
+			}
+		}
+		if err != nil {
+			log.Printf("❌ Failed to store device authorization for client %s: %v", client.ClientID.String(), err)
+			return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to start device authorization")
+		}
+
+		userCode := helpers.FormatUserCode(authorization.UserCode)
+		verificationURIComplete, err := helpers.AuthorizationRedirect(cfg.DeviceVerificationURL, url.Values{"user_code": {userCode}})
+		if err != nil {
+			log.Printf("❌ Invalid OAuth device verification URL %q: %v", cfg.DeviceVerificationURL, err)
+			return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to start device authorization")
+		}
+
+		log.Printf("🚀 Client %s started a device authorization", client.ClientID.String())
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.JSON(presenter.DeviceAuthorizationResponse(deviceCode, userCode, cfg.DeviceVerificationURL, verificationURIComplete, cfg.DeviceCodeTTL, cfg.DevicePollInterval))
+	}
+}
+
+// GetDeviceAuthorizationHandler describes the pending device authorization request of a
+// user code to the verification page, so the user can check which client asks for access
+func GetDeviceAuthorizationHandler(clients repository.OAuthClientRepository, devices repository.OAuthDeviceAuthorizationRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		userCode := helpers.NormalizeUserCode(c.Params("user_code"))
+		authorization, err := devices.GetDeviceAuthorizationByUserCode(ctx, userCode)
+		if err != nil || !authorization.Pending() {
+			return errors.NotFoundError(c, "Device authorization request not found or expired")
+		}
+		client, err := clients.GetClient(ctx, authorization.ClientID)
+		if err != nil {
+			return errors.NotFoundError(c, "Device authorization request not found or expired")
+		}
+
+		return c.JSON(presenter.DeviceAuthorizationDetailsResponse(helpers.FormatUserCode(userCode), authorization, client))
+	}
+}
+
+// DecideDeviceAuthorizationHandler records the logged-in user's approval or refusal of the
+// device authorization request of a user code. The device's session inherits the
+// authentication time and methods of the user's own session.
+func DecideDeviceAuthorizationHandler(sessions repository.SessionRepository, devices repository.OAuthDeviceAuthorizationRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		keyID, ok := c.Locals("key_id").(string)
+		if !ok || keyID == "" {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Only the user's own sessions may approve devices, never a session held by a client
+		session, err := sessions.GetSessionByKeyID(ctx, keyID)
+		if err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		if session.ClientID.Valid {
+			return errors.AuthorizationError(c, "OAuth client sessions cannot authorize devices")
+		}
+
+		// Parse request body
+		var input models.AuthorizationDecision
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		userCode := helpers.NormalizeUserCode(c.Params("user_code"))
+		authorization, err := devices.GetDeviceAuthorizationByUserCode(ctx, userCode)
+		if err != nil || !authorization.Pending() {
+			return errors.NotFoundError(c, "Device authorization request not found or expired")
+		}
+
+		if input.Approve {
+			authorization.UserID = uuid.NullUUID{UUID: userUuidID, Valid: true}
+			authorization.AuthTime = sql.NullTime{Time: session.AuthTime, Valid: true}
+			authorization.AuthMethods = session.AuthMethods
+			err = devices.ApproveDeviceAuthorization(ctx, authorization)
+		} else {
+			err = devices.DenyDeviceAuthorization(ctx, userCode)
+		}
+		if err != nil {
+			if err == repository.ErrAlreadyUsed {
+				return errors.NotFoundError(c, "Device authorization request not found or expired")
+			}
+			log.Printf("❌ Failed to record decision on device authorization of client %s: %v", authorization.ClientID.String(), err)
+			return errors.InternalError(c, "Failed to record decision")
+		}
+
+		if input.Approve {
+			log.Printf("🚀 User %s authorized a device of client %s for scope %q", userID, authorization.ClientID.String(), authorization.Scope)
+		} else {
+			log.Printf("🔒 User %s denied a device of client %s", userID, authorization.ClientID.String())
+		}
+		return c.JSON(presenter.DeviceAuthorizationDecisionResponse(input.Approve))
+	}
+}
+
+// exchangeDeviceCode answers a device's poll of the token endpoint (RFC 8628 section 3.4).
+// Until the user decides it returns authorization_pending, or slow_down when the device
+// polls faster than its interval. An approved request opens a session of the user for the
+// client with the cli device type, replacing the user's previous device grant session.
+func exchangeDeviceCode(c *fiber.Ctx, issuer *helpers.SessionIssuer, devices repository.OAuthDeviceAuthorizationRepository, client *repository.OAuthClient, oidc config.OIDCConfig) error {
+	ctx := c.Context()
+
+	deviceCode := c.FormValue("device_code")
+	if deviceCode == "" {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidRequest, "device_code is required")
+	}
+	deviceCodeHash := helpers.HashToken(deviceCode)
+	authorization, slowDown, err := devices.PollDeviceAuthorization(ctx, deviceCodeHash)
+	if err != nil {
+		if err != repository.ErrNotFound {
+			log.Printf("❌ Failed to poll device authorization of client %s: %v", client.ClientID.String(), err)
+			return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+		}
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Invalid device_code")
+	}
+	if authorization.ClientID != client.ClientID {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Invalid device_code")
+	}
+
+	switch {
+	case !time.Now().Before(authorization.ExpiresAt):
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthExpiredToken, "The device_code has expired")
+	case authorization.DeniedAt.Valid:
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthAccessDenied, "The user denied the device authorization")
+	case !authorization.UserID.Valid && slowDown:
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthSlowDown, "Polling too fast; increase the interval by 5 seconds")
+	case !authorization.UserID.Valid:
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthAuthorizationPending, "The user has not yet decided")
+	}
+
+	// Redeem the approval; a concurrent poll that got there first already has the tokens
+	if err := devices.ConsumeDeviceAuthorization(ctx, deviceCodeHash); err != nil {
+		if err == repository.ErrAlreadyUsed {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Invalid device_code")
+		}
+		log.Printf("❌ Failed to redeem device authorization of client %s: %v", client.ClientID.String(), err)
+		return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+	}
+
+	tokenPair, session, err := issuer.OpenSession(ctx, helpers.SessionRequest{
+		UserID:      authorization.UserID.UUID,
+		DeviceType:  string(middleware.DeviceTypeCLI),
+		UserAgent:   middleware.GetUserAgentHeaders(c),
+		IPAddress:   c.IP(),
+		AuthMethods: authorization.AuthMethods,
+		AuthTime:    authorization.AuthTime.Time,
+		ClientID:    client.ClientID,
+		Scope:       authorization.Scope,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to open device session for client %s: %v", client.ClientID.String(), err)
+		return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+	}
+
+	idToken, err := issueIDToken(c, issuer, session, helpers.IDTokenRequest{Issuer: oidc.Issuer, TTL: oidc.IDTokenTTL, KeyRotation: oidc.IDTokenKeyRotation})
+	if err != nil {
+		log.Printf("❌ Failed to issue id token to client %s: %v", client.ClientID.String(), err)
+		return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+	}
+
+	log.Printf("🚀 Client %s obtained device tokens for user %s", client.ClientID.String(), authorization.UserID.UUID.String())
+	return sendTokenResponse(c, tokenPair, idToken, authorization.Scope)
+}
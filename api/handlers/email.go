@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/api/validators"
+	"fiber-api/config"
+	"fiber-api/pkg/mailer"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+)
+
+// VerifyEmailHandler consumes a verification token passed as ?token= (GET, emailed link)
+// or in the JSON body (POST) and marks the user's email as verified
+func VerifyEmailHandler(tokens repository.OneTimeTokenRepository, verifications repository.EmailVerificationRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse token from query string or request body
+		var input models.VerifyEmail
+		if c.Method() == fiber.MethodGet {
+			if err := c.QueryParser(&input); err != nil {
+				return errors.ValidationError(c, "Invalid request payload")
+			}
+		} else if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateVerifyEmail(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		// Consume the verification token
+		token, err := tokens.ConsumeToken(ctx, helpers.HashToken(input.Token), repository.TokenPurposeEmailVerification)
+		if err != nil {
+			if err != repository.ErrNotFound {
+				log.Printf("❌ Failed to consume verification token: %v", err)
+			}
+			return errors.ValidationError(c, "Invalid or expired verification token")
+		}
+
+		if err := verifications.MarkEmailVerified(ctx, token.UserID); err != nil {
+			log.Printf("❌ Failed to verify email for user %s: %v", token.UserID.String(), err)
+			return errors.InternalError(c, "Failed to verify email")
+		}
+
+		log.Printf("🚀 Email verified for user %s", token.UserID.String())
+		return c.JSON(presenter.EmailVerifiedResponse())
+	}
+}
+
+// ResendVerificationHandler emails a new verification link, at most once per cooldown.
+// It always responds with 200 so it does not reveal whether the account exists or is verified.
+func ResendVerificationHandler(queries *generated.Queries, tokens repository.OneTimeTokenRepository, verifications repository.EmailVerificationRepository, mail mailer.Mailer, cfg config.EmailVerificationConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse request body
+		var input models.ResendVerification
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateResendVerification(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		response := presenter.VerificationResentResponse()
+
+		auth, err := queries.GetUserAuth(ctx, input.UserEmail)
+		if err != nil {
+			return c.JSON(response)
+		}
+
+		verified, err := verifications.IsEmailVerified(ctx, auth.UserProfileID)
+		if err != nil {
+			log.Printf("❌ Failed to check email verification for user %s: %v", input.UserEmail, err)
+			return errors.InternalError(c, "Failed to resend verification email")
+		}
+		if verified {
+			return c.JSON(response)
+		}
+
+		// Throttle resends per user
+		lastSent, err := tokens.GetLatestTokenCreatedAt(ctx, auth.UserProfileID, repository.TokenPurposeEmailVerification)
+		if err == nil && time.Since(lastSent) < cfg.ResendCooldown {
+			log.Printf("🔒 Verification resend throttled for user %s", input.UserEmail)
+			return c.JSON(response)
+		}
+
+		if err := helpers.SendEmailVerification(ctx, tokens, mail, cfg, auth.UserProfileID, input.UserEmail); err != nil {
+			log.Printf("❌ Failed to resend verification email to %s: %v", input.UserEmail, err)
+			return errors.InternalError(c, "Failed to resend verification email")
+		}
+
+		return c.JSON(response)
+	}
+}
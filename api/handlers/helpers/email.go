@@ -0,0 +1,50 @@
+package helpers
+
+import (
+	"context"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"fiber-api/pkg/mailer"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SendEmailVerification replaces any outstanding verification token of the user
+// with a new one and emails the verification link in the background
+func SendEmailVerification(ctx context.Context, tokens repository.OneTimeTokenRepository, mail mailer.Mailer, cfg config.EmailVerificationConfig, userID uuid.UUID, email string) error {
+	if err := tokens.DeleteUserTokens(ctx, userID, repository.TokenPurposeEmailVerification); err != nil {
+		return err
+	}
+
+	verificationToken, err := GenerateOpaqueToken()
+	if err != nil {
+		return err
+	}
+	if err := tokens.CreateToken(ctx, &repository.OneTimeToken{
+		TokenHash: HashToken(verificationToken),
+		UserID:    userID,
+		Purpose:   repository.TokenPurposeEmailVerification,
+		ExpiresAt: time.Now().Add(cfg.TokenTTL),
+	}); err != nil {
+		return err
+	}
+
+	msg := mailer.Message{
+		To:      email,
+		Subject: "Verify your email address",
+		Body: fmt.Sprintf(
+			"Please confirm your email address: %s?token=%s\n\nThis link expires in %s.",
+			cfg.VerifyURL, url.QueryEscape(verificationToken), cfg.TokenTTL,
+		),
+	}
+	go func() {
+		if err := mail.Send(context.Background(), msg); err != nil {
+			log.Printf("❌ Failed to send verification email to %s: %v", msg.To, err)
+		}
+	}()
+	return nil
+}
@@ -0,0 +1,279 @@
+package helpers
+
+import (
+	"context"
+	"database/sql"
+	"fiber-api/api/repository"
+	"fiber-api/pkg/fingerprint"
+	"fiber-api/pkg/useragent"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sushan531/auth-sqlc/generated"
+	jwkrepository "github.com/sushan531/jwk-auth/core/repository"
+)
+
+// firefox is the User-Agent of the device sessions are opened on in tests
+var firefox = useragent.Headers{UserAgent: "Mozilla/5.0 (X11; Linux x86_64; rv:120.0) Gecko/20100101 Firefox/120.0"}
+
+// newSessionIssuer creates a session issuer whose profiles, sessions, refresh tokens and
+// keys are kept in memory
+func newSessionIssuer(t *testing.T) (*SessionIssuer, *memoryProfiles) {
+	t.Helper()
+	keysets := &memoryKeysets{keysets: map[uuid.UUID]*jwkrepository.UserKeyset{}}
+	jwkManager, tokens := newTokenServiceWith(t, keysets)
+	userAgents, err := useragent.New(16)
+	if err != nil {
+		t.Fatalf("useragent.New: %v", err)
+	}
+	profiles := &memoryProfiles{profiles: map[uuid.UUID]generated.GetUserProfileRow{}}
+	return &SessionIssuer{
+		Queries:       profiles,
+		JWKManager:    jwkManager,
+		Keysets:       keysets,
+		TokenService:  tokens,
+		Sessions:      &memorySessions{sessions: map[uuid.UUID]*repository.Session{}},
+		RefreshTokens: &memoryRefreshTokens{tokens: map[string]*repository.RefreshToken{}},
+		Fingerprints:  fingerprint.New(userAgents, fingerprint.Config{}),
+	}, profiles
+}
+
+// memoryProfiles keeps user profiles in memory
+type memoryProfiles struct {
+	mu       sync.Mutex
+	profiles map[uuid.UUID]generated.GetUserProfileRow
+}
+
+func (m *memoryProfiles) InsertUserProfile(ctx context.Context, arg generated.InsertUserProfileParams) (generated.Auth, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := uuid.New()
+	m.profiles[id] = generated.GetUserProfileRow{UserProfileID: id, UserEmail: arg.UserEmail, FullName: arg.FullName, UserRole: arg.UserRole}
+	return generated.Auth{UserProfileID: id, UserEmail: arg.UserEmail}, nil
+}
+
+func (m *memoryProfiles) GetUserProfile(ctx context.Context, id uuid.UUID) (generated.GetUserProfileRow, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	profile, ok := m.profiles[id]
+	if !ok {
+		return generated.GetUserProfileRow{}, sql.ErrNoRows
+	}
+	return profile, nil
+}
+
+// setRole changes a stored role, as an admin would
+func (m *memoryProfiles) setRole(id uuid.UUID, role string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	profile := m.profiles[id]
+	profile.UserRole = sql.NullString{String: role, Valid: true}
+	m.profiles[id] = profile
+}
+
+// memorySessions keeps session records in memory
+type memorySessions struct {
+	mu       sync.Mutex
+	sessions map[uuid.UUID]*repository.Session
+}
+
+func (m *memorySessions) CreateSession(ctx context.Context, session *repository.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session.SessionID = uuid.New()
+	session.CreatedAt = time.Now()
+	if session.AuthTime.IsZero() {
+		session.AuthTime = session.CreatedAt
+	}
+	copied := *session
+	m.sessions[session.SessionID] = &copied
+	return nil
+}
+
+func (m *memorySessions) GetSession(ctx context.Context, sessionID uuid.UUID) (*repository.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	session, ok := m.sessions[sessionID]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *session
+	return &copied, nil
+}
+
+func (m *memorySessions) GetSessionByKeyID(ctx context.Context, keyID string) (*repository.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, session := range m.sessions {
+		if session.KeyID == keyID {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (m *memorySessions) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*repository.Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var sessions []*repository.Session
+	for _, session := range m.sessions {
+		if session.UserID == userID {
+			copied := *session
+			sessions = append(sessions, &copied)
+		}
+	}
+	return sessions, nil
+}
+
+func (m *memorySessions) RotateSessionKey(ctx context.Context, oldKeyID string, newKeyID string, ipAddress string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, session := range m.sessions {
+		if session.KeyID == oldKeyID {
+			session.KeyID = newKeyID
+			session.IPAddress = ipAddress
+			session.LastRefreshedAt = sql.NullTime{Time: time.Now(), Valid: true}
+			return nil
+		}
+	}
+	return repository.ErrNotFound
+}
+
+func (m *memorySessions) UpdateSessionFingerprint(ctx context.Context, session *repository.Session) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stored, ok := m.sessions[session.SessionID]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	stored.FingerprintAlgorithm = session.FingerprintAlgorithm
+	stored.Platform, stored.Browser, stored.Version = session.Platform, session.Browser, session.Version
+	return nil
+}
+
+func (m *memorySessions) DeleteSession(ctx context.Context, sessionID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.sessions, sessionID)
+	return nil
+}
+
+func (m *memorySessions) DeleteSessionByKeyID(ctx context.Context, keyID string) error {
+	return m.deleteWhere(func(session *repository.Session) bool { return session.KeyID == keyID })
+}
+
+func (m *memorySessions) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	return m.deleteWhere(func(session *repository.Session) bool { return session.UserID == userID })
+}
+
+func (m *memorySessions) DeleteUserDeviceSessions(ctx context.Context, userID uuid.UUID, deviceType string) error {
+	return m.deleteWhere(func(session *repository.Session) bool {
+		return session.UserID == userID && session.DeviceType == deviceType
+	})
+}
+
+func (m *memorySessions) deleteWhere(match func(session *repository.Session) bool) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for id, session := range m.sessions {
+		if match(session) {
+			delete(m.sessions, id)
+		}
+	}
+	return nil
+}
+
+// memoryRefreshTokens keeps refresh token records in memory
+type memoryRefreshTokens struct {
+	mu     sync.Mutex
+	tokens map[string]*repository.RefreshToken
+}
+
+func (m *memoryRefreshTokens) CreateRefreshToken(ctx context.Context, token *repository.RefreshToken) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token.IssuedAt = time.Now()
+	copied := *token
+	m.tokens[token.TokenHash] = &copied
+	return nil
+}
+
+func (m *memoryRefreshTokens) GetRefreshToken(ctx context.Context, tokenHash string) (*repository.RefreshToken, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, ok := m.tokens[tokenHash]
+	if !ok {
+		return nil, repository.ErrNotFound
+	}
+	copied := *token
+	return &copied, nil
+}
+
+func (m *memoryRefreshTokens) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	token, ok := m.tokens[tokenHash]
+	if !ok {
+		return repository.ErrNotFound
+	}
+	if token.UsedAt.Valid {
+		return repository.ErrAlreadyUsed
+	}
+	token.UsedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	return nil
+}
+
+// memoryKeysets keeps session keysets in memory
+type memoryKeysets struct {
+	mu      sync.Mutex
+	keysets map[uuid.UUID]*jwkrepository.UserKeyset
+}
+
+func (m *memoryKeysets) SaveUserKeyset(userID uuid.UUID, keyData string, encryptionKey string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.keysets[userID] = &jwkrepository.UserKeyset{UserID: userID, KeyData: keyData, EncryptionKey: encryptionKey}
+	return nil
+}
+
+func (m *memoryKeysets) GetUserKeyset(userID uuid.UUID) (*jwkrepository.UserKeyset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	keyset, ok := m.keysets[userID]
+	if !ok {
+		return nil, fmt.Errorf("no keyset found for user %s", userID.String())
+	}
+	copied := *keyset
+	return &copied, nil
+}
+
+func (m *memoryKeysets) DeleteUserKeyset(userID uuid.UUID) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.keysets, userID)
+	return nil
+}
+
+func (m *memoryKeysets) GetAllUserKeysets() ([]*jwkrepository.UserKeyset, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	var keysets []*jwkrepository.UserKeyset
+	for _, keyset := range m.keysets {
+		copied := *keyset
+		keysets = append(keysets, &copied)
+	}
+	return keysets, nil
+}
+
+// RegisterOwner is a no-op: every owner's keyset is kept in the same map
+func (m *memoryKeysets) RegisterOwner(ctx context.Context, ownerID uuid.UUID) error {
+	return nil
+}
+
+func (m *memoryKeysets) DeleteOwner(ctx context.Context, ownerID uuid.UUID) error {
+	return m.DeleteUserKeyset(ownerID)
+}
@@ -3,24 +3,50 @@ package helpers
 import (
 	"context"
 	"fiber-api/api/models"
+	"fiber-api/pkg/fingerprint"
 	"fmt"
+	"strings"
 
 	"github.com/google/uuid"
 	"github.com/sushan531/auth-sqlc/generated"
 )
 
-func CreateJWTClaims(queries *generated.Queries, context context.Context, userId uuid.UUID, deviceFingerprint string) (*models.JWTClaims, error) {
+// ProfileReader reads stored user profiles; *generated.Queries implements it
+type ProfileReader interface {
+	GetUserProfile(ctx context.Context, id uuid.UUID) (generated.GetUserProfileRow, error)
+}
+
+// CreateJWTClaims builds token claims from the stored user profile.
+// The role is read on every call, so role changes apply on the next login or refresh.
+func CreateJWTClaims(queries ProfileReader, context context.Context, userId uuid.UUID, deviceFingerprint *fingerprint.Fingerprint) (*models.JWTClaims, error) {
 	profile, err := queries.GetUserProfile(context, userId)
 	if err != nil {
 		return nil, err
 	}
-	claims := &models.JWTClaims{
-		UserID:            profile.UserProfileID.String(),
-		UserEmail:         profile.UserEmail,
-		Role:              "admin",
-		DeviceFingerprint: deviceFingerprint,
+	return profileClaims(profile, deviceFingerprint), nil
+}
+
+// profileClaims creates the JWT claims of a profile; the role is always the stored one
+func profileClaims(profile generated.GetUserProfileRow, deviceFingerprint *fingerprint.Fingerprint) *models.JWTClaims {
+	return &models.JWTClaims{
+		UserID:               profile.UserProfileID.String(),
+		UserEmail:            profile.UserEmail,
+		Role:                 ResolveRole(profile.UserRole.String),
+		DeviceFingerprint:    deviceFingerprint.Hash,
+		FingerprintAlgorithm: int(deviceFingerprint.Algorithm),
+		DevicePlatform:       deviceFingerprint.Platform,
+		DeviceBrowser:        deviceFingerprint.Browser,
+		DeviceVersion:        deviceFingerprint.Version,
+	}
+}
+
+// ResolveRole normalizes a stored role, falling back to the default role when it is empty
+func ResolveRole(storedRole string) string {
+	role := strings.ToLower(strings.TrimSpace(storedRole))
+	if role == "" {
+		return models.DefaultRole
 	}
-	return claims, nil
+	return role
 }
 
 // extractUserIDFromClaims safely extracts and parses user_id from token claims
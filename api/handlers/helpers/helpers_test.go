@@ -0,0 +1,117 @@
+package helpers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fiber-api/api/models"
+	appconfig "fiber-api/config"
+	"fiber-api/pkg/fingerprint"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/config"
+	"github.com/sushan531/jwk-auth/core/manager"
+	jwkrepository "github.com/sushan531/jwk-auth/core/repository"
+	"github.com/sushan531/jwk-auth/service"
+)
+
+// newTokenService creates the token service with keys kept in memory
+func newTokenService(t *testing.T) (manager.JwkManager, service.TokenService) {
+	t.Helper()
+	return newTokenServiceWith(t, &memoryKeysets{keysets: map[uuid.UUID]*jwkrepository.UserKeyset{}})
+}
+
+// newTokenServiceWith creates the token service with keys kept in keysets
+func newTokenServiceWith(t *testing.T, keysets *memoryKeysets) (manager.JwkManager, service.TokenService) {
+	t.Helper()
+	cfg := &config.Config{JWT: config.JWTConfig{
+		AccessTokenDuration:  15 * time.Minute,
+		RefreshTokenDuration: 7 * 24 * time.Hour,
+		RSAKeySize:           2048,
+	}}
+	jwkManager := manager.NewJwkManager(keysets, cfg)
+	return jwkManager, service.NewTokenService(NewJwtManager(jwkManager), jwkManager, cfg)
+}
+
+// TestSignupNeverIssuesAdminTokens follows public signups from the signup role policy
+// through the stored profile to the claims of their first and refreshed token pairs
+func TestSignupNeverIssuesAdminTokens(t *testing.T) {
+	t.Setenv("SIGNUP_DEFAULT_ROLE", "")
+	t.Setenv("SIGNUP_SELF_SERVICE_ROLES", "")
+	signup := appconfig.LoadAppConfig().Signup
+	ctx := context.Background()
+
+	for _, requested := range []string{models.RoleAdmin, "Admin", " ADMIN", models.RoleModerator} {
+		if role, err := signup.SignupRole(requested); !errors.Is(err, appconfig.ErrRoleNotSelfService) {
+			t.Errorf("signup requesting role %q = %q, %v; want ErrRoleNotSelfService", requested, role, err)
+		}
+	}
+
+	role, err := signup.SignupRole("")
+	if err != nil {
+		t.Fatalf("SignupRole: %v", err)
+	}
+	issuer, profiles := newSessionIssuer(t)
+	user, err := CreateUserProfile(ctx, profiles, models.SignUp{UserEmail: "user@example.com", Password: "securepassword123", FullName: "User"}, role)
+	if err != nil {
+		t.Fatalf("CreateUserProfile: %v", err)
+	}
+
+	pair, _, err := issuer.OpenSession(ctx, SessionRequest{UserID: user.UserProfileID, DeviceType: "web", UserAgent: firefox})
+	if err != nil {
+		t.Fatalf("OpenSession: %v", err)
+	}
+	assertRole(t, "login", issuer.TokenService, pair, models.RoleUser)
+
+	refreshed, _, err := issuer.RefreshSession(ctx, RefreshRequest{RefreshToken: pair.RefreshToken, UserAgent: firefox})
+	if err != nil {
+		t.Fatalf("RefreshSession: %v", err)
+	}
+	assertRole(t, "refresh", issuer.TokenService, refreshed, models.RoleUser)
+
+	// A refresh reads the role stored now, not the one the session was opened with
+	profiles.setRole(user.UserProfileID, models.RoleModerator)
+	refreshed, _, err = issuer.RefreshSession(ctx, RefreshRequest{RefreshToken: refreshed.RefreshToken, UserAgent: firefox})
+	if err != nil {
+		t.Fatalf("RefreshSession after role change: %v", err)
+	}
+	assertRole(t, "refresh after role change", issuer.TokenService, refreshed, models.RoleModerator)
+}
+
+// TestProfileClaimsRole checks the role of profiles stored without one or with odd casing
+func TestProfileClaimsRole(t *testing.T) {
+	deviceFingerprint := &fingerprint.Fingerprint{Algorithm: fingerprint.CurrentAlgorithm, Hash: "hash"}
+	for stored, want := range map[sql.NullString]string{
+		{}:                                      models.RoleUser,
+		{String: "", Valid: true}:               models.RoleUser,
+		{String: " User ", Valid: true}:         models.RoleUser,
+		{String: "moderator", Valid: true}:      models.RoleModerator,
+		{String: models.RoleAdmin, Valid: true}: models.RoleAdmin,
+	} {
+		claims := profileClaims(generated.GetUserProfileRow{UserProfileID: uuid.New(), UserRole: stored}, deviceFingerprint)
+		if claims.Role != want {
+			t.Errorf("stored role %+v: claims role = %q, want %q", stored, claims.Role, want)
+		}
+	}
+}
+
+func assertRole(t *testing.T, step string, tokens service.TokenService, pair *service.TokenPair, want string) {
+	t.Helper()
+	accessClaims, err := tokens.VerifyToken(pair.AccessToken)
+	if err != nil {
+		t.Fatalf("%s: VerifyToken: %v", step, err)
+	}
+	if role := accessClaims["role"]; role != want {
+		t.Errorf("%s: access token role = %v, want %q", step, role, want)
+	}
+	refreshClaims, err := tokens.VerifyRefreshToken(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("%s: VerifyRefreshToken: %v", step, err)
+	}
+	if role, ok := refreshClaims["role"]; ok && role != want {
+		t.Errorf("%s: refresh token role = %v, want none or %q", step, role, want)
+	}
+}
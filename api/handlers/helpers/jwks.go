@@ -0,0 +1,161 @@
+package helpers
+
+import (
+	"crypto/rsa"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/sushan531/jwk-auth/core/manager"
+	jwkrepository "github.com/sushan531/jwk-auth/core/repository"
+)
+
+// jwksMinRebuildInterval limits how often key changes rebuild the published set, so a
+// burst of logins does not reload every keyset once per login
+const jwksMinRebuildInterval = time.Second
+
+// JWKSCache keeps the published JWK set in memory. Building it loads and decrypts every
+// user's keyset, so it is rebuilt only after a session key changed, at most once per
+// jwksMinRebuildInterval, or once ttl has passed. Keys changed by other instances show
+// up within ttl. It is safe for concurrent use.
+type JWKSCache struct {
+	keysets jwkrepository.UserAuthRepository
+	ttl     time.Duration
+
+	mu      sync.Mutex
+	set     jwk.Set
+	builtAt time.Time
+	stale   bool
+}
+
+// NewJWKSCache creates a cache of the public keys of the given keysets
+func NewJWKSCache(keysets jwkrepository.UserAuthRepository, ttl time.Duration) *JWKSCache {
+	return &JWKSCache{keysets: keysets, ttl: ttl}
+}
+
+// Get returns the published JWK set, rebuilding it when it is out of date. Concurrent
+// callers wait for a single rebuild.
+func (c *JWKSCache) Get() (jwk.Set, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.set != nil {
+		age := time.Since(c.builtAt)
+		if age < c.ttl && (!c.stale || age < jwksMinRebuildInterval) {
+			return c.set, nil
+		}
+	}
+
+	set, err := PublicJWKS(c.keysets)
+	if err != nil {
+		return nil, err
+	}
+	c.set = set
+	c.builtAt = time.Now()
+	c.stale = false
+	return set, nil
+}
+
+// Invalidate marks the set out of date after a session key was created or deleted
+func (c *JWKSCache) Invalidate() {
+	c.mu.Lock()
+	c.stale = true
+	c.mu.Unlock()
+}
+
+// keyChangeNotifier is a JWK manager that reports created and deleted session keys
+type keyChangeNotifier struct {
+	manager.JwkManager
+	onChange func()
+}
+
+// NotifyKeyChanges wraps a JWK manager to call onChange after every created or deleted
+// session key
+func NotifyKeyChanges(jwkManager manager.JwkManager, onChange func()) manager.JwkManager {
+	return &keyChangeNotifier{JwkManager: jwkManager, onChange: onChange}
+}
+
+// CreateSessionKey creates a session key and reports the change
+func (n *keyChangeNotifier) CreateSessionKey(userID string, deviceType string) (string, error) {
+	keyID, err := n.JwkManager.CreateSessionKey(userID, deviceType)
+	if err == nil {
+		n.onChange()
+	}
+	return keyID, err
+}
+
+// DeleteSessionKey deletes a session key and reports the change
+func (n *keyChangeNotifier) DeleteSessionKey(userID string, keyID string) error {
+	err := n.JwkManager.DeleteSessionKey(userID, keyID)
+	if err == nil {
+		n.onChange()
+	}
+	return err
+}
+
+// PublicJWKS returns the public half of every session key as a JWK set.
+// Keys are published under the kid that tokens signed with them carry in their
+// kid JOSE header, a hash of the key ID, so the set does not reveal key owners.
+func PublicJWKS(keysets jwkrepository.UserAuthRepository) (jwk.Set, error) {
+	encrypted, err := keysets.GetAllUserKeysets()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load keysets: %w", err)
+	}
+
+	encryption := manager.NewEncryptionManager()
+	set := jwk.NewSet()
+	for _, keyset := range encrypted {
+		if keyset.KeyData == "" {
+			continue
+		}
+		data, err := encryption.Decrypt(keyset.KeyData, keyset.EncryptionKey)
+		if err != nil {
+			continue // Skip keysets that can't be decrypted
+		}
+		userKeys, err := (&jwkrepository.UserKeyset{KeyData: string(data)}).GetJWKS()
+		if err != nil {
+			continue // Skip invalid JWKS
+		}
+
+		for i := 0; i < userKeys.Len(); i++ {
+			key, _ := userKeys.Key(i)
+			keyID, ok := key.KeyID()
+			if !ok {
+				continue
+			}
+			publicKey, err := publicSigningKey(key, PublishedKeyID(keyID))
+			if err != nil {
+				continue // Skip keys that can't be exported
+			}
+			if err := set.AddKey(publicKey); err != nil {
+				return nil, fmt.Errorf("failed to add key %s: %w", keyID, err)
+			}
+		}
+	}
+	return set, nil
+}
+
+// publicSigningKey converts a stored private session key into its public RS256 signing JWK.
+// The stored "use" holds the device type, so it is replaced with "sig".
+func publicSigningKey(key jwk.Key, keyID string) (jwk.Key, error) {
+	var privateKey rsa.PrivateKey
+	if err := jwk.Export(key, &privateKey); err != nil {
+		return nil, err
+	}
+	publicKey, err := jwk.Import(&privateKey.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if err := publicKey.Set(jwk.KeyIDKey, keyID); err != nil {
+		return nil, err
+	}
+	if err := publicKey.Set(jwk.AlgorithmKey, jwa.RS256()); err != nil {
+		return nil, err
+	}
+	if err := publicKey.Set(jwk.KeyUsageKey, jwk.ForSignature); err != nil {
+		return nil, err
+	}
+	return publicKey, nil
+}
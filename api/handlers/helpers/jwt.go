@@ -0,0 +1,91 @@
+package helpers
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jws"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+	"github.com/sushan531/jwk-auth/core/manager"
+)
+
+// Token lifetimes, as jwk-auth signs them
+const (
+	defaultTokenTTL = 24 * time.Hour
+	accessTokenTTL  = 15 * time.Minute
+	refreshTokenTTL = 7 * 24 * time.Hour
+)
+
+// PublishedKeyID returns the kid a session key is published under in the JWKS and named
+// by in the JOSE header of tokens. Session key IDs contain the user ID, device type and
+// login time, so only a hash of them is made public.
+func PublishedKeyID(keyID string) string {
+	sum := sha256.Sum256([]byte(keyID))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// headerKeyIDSigner signs tokens like jwk-auth, with the session key ID in the kid claim,
+// and also names the key's published ID in the kid JOSE header, so standard JWT libraries
+// pick the verification key from the JWKS. Verification is left to jwk-auth.
+type headerKeyIDSigner struct {
+	manager.JwtManager
+	jwkManager manager.JwkManager
+}
+
+// NewJwtManager creates the JWT manager tokens are signed and verified with
+func NewJwtManager(jwkManager manager.JwkManager) manager.JwtManager {
+	return &headerKeyIDSigner{JwtManager: manager.NewJwtManager(jwkManager), jwkManager: jwkManager}
+}
+
+// GenerateTokenWithKeyID signs a token valid for a day
+func (s *headerKeyIDSigner) GenerateTokenWithKeyID(claims map[string]interface{}, keyID string) (string, error) {
+	return s.sign(claims, keyID, defaultTokenTTL)
+}
+
+// GenerateAccessTokenWithKeyID signs an access token
+func (s *headerKeyIDSigner) GenerateAccessTokenWithKeyID(claims map[string]interface{}, keyID string) (string, error) {
+	return s.sign(claims, keyID, accessTokenTTL)
+}
+
+// GenerateRefreshTokenWithKeyID signs a refresh token
+func (s *headerKeyIDSigner) GenerateRefreshTokenWithKeyID(claims map[string]interface{}, keyID string) (string, error) {
+	return s.sign(claims, keyID, refreshTokenTTL)
+}
+
+func (s *headerKeyIDSigner) sign(claims map[string]interface{}, keyID string, ttl time.Duration) (string, error) {
+	now := time.Now()
+	token := jwt.New()
+	for name, value := range claims {
+		if err := token.Set(name, value); err != nil {
+			return "", fmt.Errorf("failed to set claim %s: %w", name, err)
+		}
+	}
+	if err := token.Set(jwt.IssuedAtKey, now.Unix()); err != nil {
+		return "", fmt.Errorf("failed to set iat: %w", err)
+	}
+	if err := token.Set(jwt.ExpirationKey, now.Add(ttl).Unix()); err != nil {
+		return "", fmt.Errorf("failed to set exp: %w", err)
+	}
+	// jwk-auth finds the verification key by the kid claim
+	if err := token.Set("kid", keyID); err != nil {
+		return "", fmt.Errorf("failed to set key id in token: %w", err)
+	}
+
+	privateKey, err := s.jwkManager.GetPrivateKeyByID(keyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get signing key: %w", err)
+	}
+	headers := jws.NewHeaders()
+	if err := headers.Set(jws.KeyIDKey, PublishedKeyID(keyID)); err != nil {
+		return "", fmt.Errorf("failed to set key id header: %w", err)
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256(), privateKey, jws.WithProtectedHeaders(headers)))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign token: %w", err)
+	}
+	return string(signed), nil
+}
@@ -0,0 +1,61 @@
+package helpers
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v3/jws"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+	jwkrepository "github.com/sushan531/jwk-auth/core/repository"
+)
+
+// TestTokensVerifyAgainstPublishedJWKS checks that a standard verifier finds the key of a
+// token in the JWKS by its kid header, and that published kids do not reveal the key owner
+func TestTokensVerifyAgainstPublishedJWKS(t *testing.T) {
+	keysets := &memoryKeysets{keysets: map[uuid.UUID]*jwkrepository.UserKeyset{}}
+	jwkManager, tokens := newTokenServiceWith(t, keysets)
+	userID := uuid.New().String()
+	keyID, err := jwkManager.CreateSessionKey(userID, "web")
+	if err != nil {
+		t.Fatalf("CreateSessionKey: %v", err)
+	}
+	pair, err := tokens.GenerateTokenPairWithKeyID(map[string]interface{}{"user_id": userID}, keyID)
+	if err != nil {
+		t.Fatalf("GenerateTokenPairWithKeyID: %v", err)
+	}
+
+	set, err := PublicJWKS(keysets)
+	if err != nil {
+		t.Fatalf("PublicJWKS: %v", err)
+	}
+	if set.Len() != 1 {
+		t.Fatalf("JWKS has %d keys, want 1", set.Len())
+	}
+	key, _ := set.Key(0)
+	kid, _ := key.KeyID()
+	if kid != PublishedKeyID(keyID) || strings.Contains(kid, userID) || strings.Contains(kid, "web") {
+		t.Errorf("published kid = %q, want an opaque hash of the key ID", kid)
+	}
+
+	for name, token := range map[string]string{"access": pair.AccessToken, "refresh": pair.RefreshToken} {
+		message, err := jws.Parse([]byte(token))
+		if err != nil {
+			t.Fatalf("%s token: jws.Parse: %v", name, err)
+		}
+		if header, _ := message.Signatures()[0].ProtectedHeaders().KeyID(); header != kid {
+			t.Errorf("%s token kid header = %q, want %q", name, header, kid)
+		}
+		if _, err := jwt.Parse([]byte(token), jwt.WithKeySet(set, jws.WithInferAlgorithmFromKey(true))); err != nil {
+			t.Errorf("%s token does not verify against the JWKS: %v", name, err)
+		}
+	}
+
+	// jwk-auth still verifies by the kid claim
+	if _, err := tokens.VerifyToken(pair.AccessToken); err != nil {
+		t.Errorf("VerifyToken: %v", err)
+	}
+	if _, err := tokens.VerifyRefreshToken(pair.RefreshToken); err != nil {
+		t.Errorf("VerifyRefreshToken: %v", err)
+	}
+}
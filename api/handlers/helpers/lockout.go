@@ -0,0 +1,86 @@
+package helpers
+
+import (
+	"context"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+// dummyPasswordHash is compared against when the email is unknown, so a failed login
+// takes as long whether or not the account exists
+var (
+	dummyPasswordHash     []byte
+	dummyPasswordHashOnce sync.Once
+)
+
+// LoginAttemptKey normalizes an email into the key failed logins are counted under
+func LoginAttemptKey(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
+
+// LoginRetryAfter returns how long the email must wait before its next login attempt,
+// or zero if an attempt is allowed now
+func LoginRetryAfter(ctx context.Context, attempts repository.LoginAttemptRepository, cfg config.LockoutConfig, email string) (time.Duration, error) {
+	attempt, err := attempts.GetLoginAttempt(ctx, LoginAttemptKey(email))
+	if err == repository.ErrNotFound {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, err
+	}
+	return loginWait(attempt, cfg, time.Now()), nil
+}
+
+// loginWait applies the lockout and exponential backoff rules to a failure record
+func loginWait(attempt *repository.LoginAttempt, cfg config.LockoutConfig, now time.Time) time.Duration {
+	if attempt.LockedUntil.Valid {
+		// An expired lock allows a fresh start; the next failure restarts the count
+		return positive(attempt.LockedUntil.Time.Sub(now))
+	}
+	if attempt.FailedCount <= 0 || cfg.BaseDelay <= 0 {
+		return 0
+	}
+
+	delay := cfg.BaseDelay
+	for i := 1; i < attempt.FailedCount && (cfg.MaxDelay <= 0 || delay < cfg.MaxDelay); i++ {
+		delay *= 2
+	}
+	if cfg.MaxDelay > 0 && delay > cfg.MaxDelay {
+		delay = cfg.MaxDelay
+	}
+	return positive(attempt.LastFailedAt.Add(delay).Sub(now))
+}
+
+func positive(d time.Duration) time.Duration {
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// RecordFailedLogin counts a failed login for the email and reports whether it is now locked
+func RecordFailedLogin(ctx context.Context, attempts repository.LoginAttemptRepository, cfg config.LockoutConfig, email string) (bool, error) {
+	attempt, err := attempts.RecordFailedLogin(ctx, LoginAttemptKey(email), cfg.MaxAttempts, cfg.Duration)
+	if err != nil {
+		return false, err
+	}
+	return attempt.LockedUntil.Valid, nil
+}
+
+// ClearFailedLogins resets the failure count and lock of the email
+func ClearFailedLogins(ctx context.Context, attempts repository.LoginAttemptRepository, email string) error {
+	return attempts.ClearLoginAttempts(ctx, LoginAttemptKey(email))
+}
+
+// CompareDummyPassword spends the time of a bcrypt comparison for an unknown account
+func CompareDummyPassword(password string) {
+	dummyPasswordHashOnce.Do(func() {
+		dummyPasswordHash, _ = bcrypt.GenerateFromPassword([]byte("dummy-password-for-timing"), bcrypt.DefaultCost)
+	})
+	_ = bcrypt.CompareHashAndPassword(dummyPasswordHash, []byte(password))
+}
@@ -0,0 +1,143 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base32"
+	"errors"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"fiber-api/pkg/totp"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sushan531/jwk-auth/core/manager"
+)
+
+// RecoveryCodeCount is the number of recovery codes issued when MFA is enabled
+const RecoveryCodeCount = 10
+
+// TOTPSkew is the number of 30 second steps a code may drift either way
+const TOTPSkew = 1
+
+// ErrInvalidMFACode is returned when a TOTP or recovery code is wrong, expired or replayed
+var ErrInvalidMFACode = errors.New("invalid mfa code")
+
+var recoveryCodeEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// EncryptMFASecret encrypts a TOTP secret for storage with the configured key
+func EncryptMFASecret(cfg config.MFAConfig, secret string) (string, error) {
+	return manager.NewEncryptionManager().Encrypt([]byte(secret), cfg.EncryptionKey)
+}
+
+// DecryptMFASecret decrypts a stored TOTP secret with the configured key
+func DecryptMFASecret(cfg config.MFAConfig, encryptedSecret string) (string, error) {
+	secret, err := manager.NewEncryptionManager().Decrypt(encryptedSecret, cfg.EncryptionKey)
+	if err != nil {
+		return "", err
+	}
+	return string(secret), nil
+}
+
+// GenerateRecoveryCodes returns new recovery codes formatted as xxxxx-xxxxx
+// together with the hashes to store
+func GenerateRecoveryCodes() ([]string, []string, error) {
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		buf := make([]byte, 7)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, nil, fmt.Errorf("failed to generate recovery code: %w", err)
+		}
+		raw := strings.ToLower(recoveryCodeEncoding.EncodeToString(buf))[:10]
+		codes = append(codes, raw[:5]+"-"+raw[5:])
+		hashes = append(hashes, HashToken(raw))
+	}
+	return codes, hashes, nil
+}
+
+// normalizeRecoveryCode strips the separator and case a user may type a recovery code with
+func normalizeRecoveryCode(code string) string {
+	return strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), "-", ""))
+}
+
+// VerifyTOTPCode checks a code against the user's secret and records its time step,
+// so each code is accepted at most once
+func VerifyTOTPCode(ctx context.Context, mfa repository.MFARepository, cfg config.MFAConfig, enrollment *repository.MFA, code string) error {
+	secret, err := DecryptMFASecret(cfg, enrollment.EncryptedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to decrypt mfa secret: %w", err)
+	}
+
+	step, ok := totp.ValidateAfter(secret, code, time.Now(), TOTPSkew, enrollment.LastUsedStep)
+	if !ok {
+		return ErrInvalidMFACode
+	}
+	if err := mfa.UpdateLastUsedStep(ctx, enrollment.UserID, step); err != nil {
+		if errors.Is(err, repository.ErrAlreadyUsed) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+	return nil
+}
+
+// VerifyMFACode accepts either a current TOTP code or an unused recovery code
+// of a user with MFA enabled; a recovery code is consumed
+func VerifyMFACode(ctx context.Context, mfa repository.MFARepository, cfg config.MFAConfig, userID uuid.UUID, code string) error {
+	enrollment, err := mfa.GetMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+	if !enrollment.Enabled() {
+		return ErrInvalidMFACode
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totp.Digits {
+		return VerifyTOTPCode(ctx, mfa, cfg, enrollment, code)
+	}
+
+	if err := mfa.ConsumeRecoveryCode(ctx, userID, HashToken(normalizeRecoveryCode(code))); err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return ErrInvalidMFACode
+		}
+		return err
+	}
+	return nil
+}
+
+// IsMFAEnabled reports whether the user has confirmed a TOTP enrollment
+func IsMFAEnabled(ctx context.Context, mfa repository.MFARepository, userID uuid.UUID) (bool, error) {
+	enrollment, err := mfa.GetMFA(ctx, userID)
+	if err != nil {
+		if errors.Is(err, repository.ErrNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+	return enrollment.Enabled(), nil
+}
+
+// CreateMFAChallenge issues the short-lived token a login exchanges, together with
+// a second factor, for a token pair
+func CreateMFAChallenge(ctx context.Context, tokens repository.OneTimeTokenRepository, cfg config.MFAConfig, userID uuid.UUID) (string, error) {
+	challenge, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", err
+	}
+	if err := tokens.CreateToken(ctx, &repository.OneTimeToken{
+		TokenHash: HashToken(challenge),
+		UserID:    userID,
+		Purpose:   repository.TokenPurposeMFAChallenge,
+		ExpiresAt: time.Now().Add(cfg.ChallengeTTL),
+	}); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
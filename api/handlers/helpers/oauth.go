@@ -0,0 +1,204 @@
+package helpers
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"fiber-api/api/repository"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+	"github.com/sushan531/jwk-auth/core/manager"
+	"github.com/sushan531/jwk-auth/service"
+)
+
+// Token type hints accepted by the introspection and revocation endpoints
+const (
+	TokenTypeHintAccessToken  = "access_token"
+	TokenTypeHintRefreshToken = "refresh_token"
+)
+
+// GenerateClientSecret returns a new client secret and the hash to store for it
+func GenerateClientSecret() (string, string, error) {
+	secret, err := GenerateOpaqueToken()
+	if err != nil {
+		return "", "", err
+	}
+	return secret, HashToken(secret), nil
+}
+
+// VerifyClientSecret reports whether secret is the secret of client, in constant time.
+// Public clients have no secret and never match.
+func VerifyClientSecret(client *repository.OAuthClient, secret string) bool {
+	if client.ClientSecretHash == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(secret)), []byte(client.ClientSecretHash)) == 1
+}
+
+// VerifyCodeChallenge checks a PKCE code verifier against the S256 challenge sent
+// with the authorization request (RFC 7636 section 4.6)
+func VerifyCodeChallenge(verifier string, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	computed := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(computed), []byte(challenge)) == 1
+}
+
+// ParseScope splits a space-separated scope string, dropping duplicates
+func ParseScope(scope string) []string {
+	var scopes []string
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(scopes, s) {
+			scopes = append(scopes, s)
+		}
+	}
+	return scopes
+}
+
+// AuthorizationRedirect appends params to a registered redirect URI, keeping its own query
+func AuthorizationRedirect(redirectURI string, params url.Values) (string, error) {
+	target, err := url.Parse(redirectURI)
+	if err != nil {
+		return "", fmt.Errorf("invalid redirect URI: %w", err)
+	}
+	query := target.Query()
+	for key, values := range params {
+		for _, value := range values {
+			query.Add(key, value)
+		}
+	}
+	target.RawQuery = query.Encode()
+	return target.String(), nil
+}
+
+// userCodeAlphabet holds the characters of device user codes: consonants only, so codes
+// spell no words and survive case-insensitive entry (RFC 8628 section 6.1)
+const userCodeAlphabet = "BCDFGHJKLMNPQRSTVWXZ"
+
+// userCodeLength gives 20^8, about 2^34, possible user codes
+const userCodeLength = 8
+
+// GenerateUserCode returns a random user code for the device authorization grant
+func GenerateUserCode() (string, error) {
+	code := make([]byte, 0, userCodeLength)
+	buf := make([]byte, 1)
+	for len(code) < userCodeLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate user code: %w", err)
+		}
+		// Reject bytes past the largest multiple of the alphabet size to avoid bias
+		if int(buf[0]) >= 256-256%len(userCodeAlphabet) {
+			continue
+		}
+		code = append(code, userCodeAlphabet[int(buf[0])%len(userCodeAlphabet)])
+	}
+	return string(code), nil
+}
+
+// NormalizeUserCode converts a user code as typed by the user to its stored form,
+// ignoring case, dashes and spaces
+func NormalizeUserCode(input string) string {
+	var code strings.Builder
+	for _, r := range strings.ToUpper(input) {
+		if strings.ContainsRune(userCodeAlphabet, r) {
+			code.WriteRune(r)
+		}
+	}
+	return code.String()
+}
+
+// FormatUserCode splits a user code into two halves, e.g. "BDFG-HJKL", for display
+func FormatUserCode(code string) string {
+	half := len(code) / 2
+	return code[:half] + "-" + code[half:]
+}
+
+// OAuthDeviceType is the session device type of a client acting for a user. Each client
+// gets its own, so authorizing a client never replaces the user's own device sessions.
+// Key IDs are split on "-", so the client ID is used without dashes.
+func OAuthDeviceType(clientID uuid.UUID) string {
+	return "oauth" + strings.ReplaceAll(clientID.String(), "-", "")
+}
+
+// ClientCredentialsDeviceType is the device type of the session key a client's own
+// client_credentials tokens are signed with
+func ClientCredentialsDeviceType(clientID uuid.UUID) string {
+	return "client" + strings.ReplaceAll(clientID.String(), "-", "")
+}
+
+// IssueClientToken issues a client_credentials access token; the token carries no user
+// claims. All tokens of a client share its key, so a new token leaves the earlier ones
+// valid until they expire, and revoking any of them revokes them all.
+func (s *SessionIssuer) IssueClientToken(ctx context.Context, client *repository.OAuthClient, scope string) (*service.TokenPair, error) {
+	keyID, err := s.clientKey(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	// An empty device fingerprint keeps the token out of the first-party API routes
+	claims := map[string]interface{}{
+		"sub":                client.ClientID.String(),
+		"client_id":          client.ClientID.String(),
+		"scope":              scope,
+		"device_fingerprint": "",
+	}
+	tokenPair, err := s.TokenService.GenerateTokenPairWithKeyID(claims, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate client token: %w", err)
+	}
+
+	// The client_credentials grant has no refresh token
+	tokenPair.RefreshToken = ""
+	return tokenPair, nil
+}
+
+// RevokeClientKeys deletes the keys of a client's client_credentials tokens, which
+// invalidates every such token of the client
+func RevokeClientKeys(jwkManager manager.JwkManager, clientID uuid.UUID) error {
+	keyIDs, err := jwkManager.GetSessionKeys(clientID.String())
+	if err != nil {
+		return fmt.Errorf("failed to fetch client keys: %w", err)
+	}
+	for _, keyID := range keyIDs {
+		if err := DeleteSessionKey(jwkManager, clientID, keyID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// clientKey returns the session key a client's client_credentials tokens are signed
+// with, creating it when the client has none. The key is kept under the client's own ID,
+// so no user's logout or password reset revokes it. Creating a key replaces the previous
+// key of its device type, so an existing key is reused.
+func (s *SessionIssuer) clientKey(ctx context.Context, client *repository.OAuthClient) (string, error) {
+	if err := s.Keysets.RegisterOwner(ctx, client.ClientID); err != nil {
+		return "", err
+	}
+	ownerID := client.ClientID.String()
+	deviceType := ClientCredentialsDeviceType(client.ClientID)
+
+	keyIDs, err := s.JWKManager.GetSessionKeys(ownerID)
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch client keys: %w", err)
+	}
+	for _, keyID := range keyIDs {
+		if strings.HasPrefix(keyID, deviceType+"-") {
+			return keyID, nil
+		}
+	}
+
+	keyID, err := s.JWKManager.CreateSessionKey(ownerID, deviceType)
+	if err != nil {
+		return "", fmt.Errorf("failed to create client key: %w", err)
+	}
+	return keyID, nil
+}
@@ -0,0 +1,166 @@
+package helpers
+
+import (
+	"context"
+	"fiber-api/api/models"
+	"fiber-api/api/repository"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jws"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// IssuerKeyOwner owns the keys ID tokens are signed with. They are kept in
+// signing_keysets apart from every session key, so no login, refresh or logout
+// replaces them.
+var IssuerKeyOwner = uuid.MustParse("00000000-0000-0000-0000-000000000001")
+
+// issuerKeyPrefix starts the device type of an ID token signing key, which is followed
+// by the key's creation time in Unix seconds
+const issuerKeyPrefix = "idtoken"
+
+// IDTokenRequest describes an ID token to issue for a session opened through the
+// authorization server
+type IDTokenRequest struct {
+	Issuer string
+	TTL    time.Duration
+	// KeyRotation is how long a signing key signs before a new one takes over
+	KeyRotation time.Duration
+	// Nonce is echoed from the authorization request; it is left out on refresh
+	Nonce string
+}
+
+// LoadUserInfo reads the OpenID Connect claims of a user from the profile GetProfileHandler
+// serves, releasing only the claims the granted scopes allow
+func LoadUserInfo(ctx context.Context, queries ProfileReader, verifications repository.EmailVerificationRepository, userID uuid.UUID, scopes []string) (*models.UserInfo, error) {
+	profile, err := queries.GetUserProfile(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &models.UserInfo{Sub: profile.UserProfileID.String()}
+	if slices.Contains(scopes, models.ScopeProfile) {
+		info.Name = profile.FullName
+	}
+	if slices.Contains(scopes, models.ScopeEmail) {
+		verified, err := verifications.IsEmailVerified(ctx, userID)
+		if err != nil {
+			return nil, err
+		}
+		info.Email = profile.UserEmail
+		info.EmailVerified = &verified
+	}
+	return info, nil
+}
+
+// IssueIDToken issues an OpenID Connect ID token for a client session. It is signed with
+// the issuer's current key, not the session's, so it stays verifiable after the session
+// is refreshed or logged out, and names that key in the JOSE header.
+func (s *SessionIssuer) IssueIDToken(ctx context.Context, session *repository.Session, req IDTokenRequest) (string, error) {
+	info, err := LoadUserInfo(ctx, s.Queries, s.Verifications, session.UserID, strings.Fields(session.Scope))
+	if err != nil {
+		return "", fmt.Errorf("failed to load user info: %w", err)
+	}
+
+	now := time.Now()
+	claims := info.ToMap()
+	claims[jwt.IssuerKey] = req.Issuer
+	claims[jwt.AudienceKey] = []string{session.ClientID.UUID.String()}
+	claims[jwt.IssuedAtKey] = now.Unix()
+	claims[jwt.ExpirationKey] = now.Add(req.TTL).Unix()
+	claims["auth_time"] = session.AuthTime.Unix()
+	if req.Nonce != "" {
+		claims["nonce"] = req.Nonce
+	}
+	if len(session.AuthMethods) > 0 {
+		claims["amr"] = session.AuthMethods
+	}
+
+	token := jwt.New()
+	for name, value := range claims {
+		if err := token.Set(name, value); err != nil {
+			return "", fmt.Errorf("failed to set claim %s: %w", name, err)
+		}
+	}
+
+	keyID, err := s.issuerKey(ctx, now, req)
+	if err != nil {
+		return "", err
+	}
+	privateKey, err := s.JWKManager.GetPrivateKeyByID(keyID)
+	if err != nil {
+		return "", fmt.Errorf("failed to get signing key: %w", err)
+	}
+	headers := jws.NewHeaders()
+	if err := headers.Set(jws.KeyIDKey, PublishedKeyID(keyID)); err != nil {
+		return "", fmt.Errorf("failed to set key id header: %w", err)
+	}
+
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256(), privateKey, jws.WithProtectedHeaders(headers)))
+	if err != nil {
+		return "", fmt.Errorf("failed to sign id token: %w", err)
+	}
+	return string(signed), nil
+}
+
+// issuerKey returns the key ID tokens are signed with at now, creating one when the
+// current key is older than req.KeyRotation. Retired keys stay published until the last
+// ID token they signed has expired, and are deleted after.
+func (s *SessionIssuer) issuerKey(ctx context.Context, now time.Time, req IDTokenRequest) (string, error) {
+	s.issuerKeyMu.Lock()
+	defer s.issuerKeyMu.Unlock()
+
+	if err := s.Keysets.RegisterOwner(ctx, IssuerKeyOwner); err != nil {
+		return "", err
+	}
+	keyIDs, err := s.JWKManager.GetSessionKeys(IssuerKeyOwner.String())
+	if err != nil {
+		return "", fmt.Errorf("failed to fetch issuer keys: %w", err)
+	}
+
+	current, currentCreated := "", time.Time{}
+	for _, keyID := range keyIDs {
+		created, ok := issuerKeyCreatedAt(keyID)
+		if !ok {
+			continue
+		}
+		age := now.Sub(created)
+		switch {
+		case age >= req.KeyRotation+req.TTL:
+			if err := DeleteSessionKey(s.JWKManager, IssuerKeyOwner, keyID); err != nil {
+				return "", err
+			}
+		case age < req.KeyRotation && created.After(currentCreated):
+			current, currentCreated = keyID, created
+		}
+	}
+	if current != "" {
+		return current, nil
+	}
+
+	keyID, err := s.JWKManager.CreateSessionKey(IssuerKeyOwner.String(), issuerKeyPrefix+strconv.FormatInt(now.Unix(), 10))
+	if err != nil {
+		return "", fmt.Errorf("failed to create issuer key: %w", err)
+	}
+	return keyID, nil
+}
+
+// issuerKeyCreatedAt reads the creation time of an ID token signing key from its key ID
+func issuerKeyCreatedAt(keyID string) (time.Time, bool) {
+	rest, ok := strings.CutPrefix(keyID, issuerKeyPrefix)
+	if !ok {
+		return time.Time{}, false
+	}
+	seconds, _, _ := strings.Cut(rest, "-")
+	unix, err := strconv.ParseInt(seconds, 10, 64)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return time.Unix(unix, 0), true
+}
@@ -0,0 +1,79 @@
+package helpers
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	jwkrepository "github.com/sushan531/jwk-auth/core/repository"
+)
+
+// TestIssuerKeyRotation follows the ID token signing key through a rotation: the key is
+// reused until it is due, and the retired key stays published until its ID tokens expire
+func TestIssuerKeyRotation(t *testing.T) {
+	keysets := &memoryKeysets{keysets: map[uuid.UUID]*jwkrepository.UserKeyset{}}
+	jwkManager, _ := newTokenServiceWith(t, keysets)
+	issuer := &SessionIssuer{JWKManager: jwkManager, Keysets: keysets}
+	req := IDTokenRequest{TTL: time.Hour, KeyRotation: 24 * time.Hour}
+	ctx := context.Background()
+	start := time.Now()
+
+	issuerKeyAt := func(at time.Duration) string {
+		t.Helper()
+		keyID, err := issuer.issuerKey(ctx, start.Add(at), req)
+		if err != nil {
+			t.Fatalf("issuerKey at +%s: %v", at, err)
+		}
+		return keyID
+	}
+	published := func() []string {
+		t.Helper()
+		set, err := PublicJWKS(keysets)
+		if err != nil {
+			t.Fatalf("PublicJWKS: %v", err)
+		}
+		var kids []string
+		for i := 0; i < set.Len(); i++ {
+			key, _ := set.Key(i)
+			kid, _ := key.KeyID()
+			kids = append(kids, kid)
+		}
+		return kids
+	}
+
+	first := issuerKeyAt(0)
+	if again := issuerKeyAt(23 * time.Hour); again != first {
+		t.Errorf("key before rotation = %q, want %q", again, first)
+	}
+
+	// A user's sessions come and go without touching the issuer key
+	userID := uuid.New()
+	sessionKey, err := jwkManager.CreateSessionKey(userID.String(), "web")
+	if err != nil {
+		t.Fatalf("CreateSessionKey: %v", err)
+	}
+	if err := DeleteSessionKey(jwkManager, userID, sessionKey); err != nil {
+		t.Fatalf("DeleteSessionKey: %v", err)
+	}
+	if !slices.Contains(published(), PublishedKeyID(first)) {
+		t.Errorf("issuer key is not published after a logout")
+	}
+
+	second := issuerKeyAt(24 * time.Hour)
+	if second == first {
+		t.Fatalf("key was not rotated after %s", req.KeyRotation)
+	}
+	if kids := published(); !slices.Contains(kids, PublishedKeyID(first)) || !slices.Contains(kids, PublishedKeyID(second)) {
+		t.Errorf("published kids = %v, want the retired and the current key", kids)
+	}
+
+	// Once the retired key's last ID tokens have expired it is deleted
+	if again := issuerKeyAt(25 * time.Hour); again != second {
+		t.Errorf("key after rotation = %q, want %q", again, second)
+	}
+	if kids := published(); slices.Contains(kids, PublishedKeyID(first)) || !slices.Contains(kids, PublishedKeyID(second)) {
+		t.Errorf("published kids = %v, want only the current key", kids)
+	}
+}
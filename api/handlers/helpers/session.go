@@ -0,0 +1,355 @@
+package helpers
+
+import (
+	"context"
+	"errors"
+	"fiber-api/api/models"
+	"fiber-api/api/repository"
+	"fiber-api/pkg/fingerprint"
+	"fiber-api/pkg/useragent"
+	"fmt"
+	"log"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/sushan531/jwk-auth/core/manager"
+	"github.com/sushan531/jwk-auth/service"
+)
+
+// RevokeAllSessions deletes every session key of a user and their session records,
+// except the session backed by exceptKeyID when it is not empty.
+// Returns the number of revoked session keys.
+func RevokeAllSessions(ctx context.Context, jwkManager manager.JwkManager, sessions repository.SessionRepository, userID uuid.UUID, exceptKeyID string) (int, error) {
+	keyIDs, err := jwkManager.GetSessionKeys(userID.String())
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch session keys: %w", err)
+	}
+
+	revoked := 0
+	for _, keyID := range keyIDs {
+		if keyID == exceptKeyID {
+			continue
+		}
+		if err := jwkManager.DeleteSessionKey(userID.String(), keyID); err != nil {
+			return revoked, fmt.Errorf("failed to revoke session key %s: %w", keyID, err)
+		}
+		if err := sessions.DeleteSessionByKeyID(ctx, keyID); err != nil {
+			return revoked, err
+		}
+		revoked++
+	}
+
+	// Drop records whose keys were already gone
+	if exceptKeyID == "" {
+		if err := sessions.DeleteUserSessions(ctx, userID); err != nil {
+			return revoked, err
+		}
+	}
+	return revoked, nil
+}
+
+// DeleteSessionKey deletes a session key of a user. A key that is already gone, e.g.
+// replaced by a newer login on the same device type, counts as deleted.
+func DeleteSessionKey(jwkManager manager.JwkManager, userID uuid.UUID, keyID string) error {
+	err := jwkManager.DeleteSessionKey(userID.String(), keyID)
+	if err == nil {
+		return nil
+	}
+	keyIDs, listErr := jwkManager.GetSessionKeys(userID.String())
+	if listErr == nil && !slices.Contains(keyIDs, keyID) {
+		return nil
+	}
+	return fmt.Errorf("failed to revoke session key %s: %w", keyID, err)
+}
+
+// SessionIssuer opens sessions after a successful authentication: it creates the
+// device session key, records the session and issues the first token pair of its family
+type SessionIssuer struct {
+	Queries       ProfileReader
+	JWKManager    manager.JwkManager
+	Keysets       repository.KeysetRepository
+	TokenService  service.TokenService
+	Sessions      repository.SessionRepository
+	RefreshTokens repository.RefreshTokenRepository
+	Verifications repository.EmailVerificationRepository
+	Fingerprints  *fingerprint.Generator
+
+	// issuerKeyMu serializes rotations of the ID token signing key
+	issuerKeyMu sync.Mutex
+}
+
+// SessionRequest describes the authenticated user and the device a session is opened for
+type SessionRequest struct {
+	UserID     uuid.UUID
+	DeviceType string
+	UserAgent  useragent.Headers
+	IPAddress  string
+	// AuthMethods lists how the user authenticated (RFC 8176). AuthTime defaults to now;
+	// it is set when the session continues an earlier authentication.
+	AuthMethods []string
+	AuthTime    time.Time
+	// ClientID and Scope are set when an OAuth client opens the session on the user's behalf
+	ClientID uuid.UUID
+	Scope    string
+}
+
+// RefreshRequest describes a refresh token presented to rotate a session's tokens
+type RefreshRequest struct {
+	RefreshToken string
+	// ClientID must be the OAuth client the session was opened for, or uuid.Nil for first-party sessions
+	ClientID  uuid.UUID
+	UserAgent useragent.Headers
+	IPAddress string
+}
+
+var (
+	// ErrInvalidRefreshToken is returned for unknown, expired, reused or foreign refresh tokens
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+
+	// ErrMissingFingerprint is returned for refresh tokens without a device fingerprint
+	ErrMissingFingerprint = errors.New("refresh token has no device fingerprint")
+
+	// ErrFingerprintMismatch is returned when the refreshing device is not the one the session was opened on
+	ErrFingerprintMismatch = errors.New("device fingerprint mismatch")
+
+	// ErrDeviceNotAllowed is returned when a session exists but was opened on a device type that may not act
+	ErrDeviceNotAllowed = errors.New("session device type not allowed")
+)
+
+// LoadDeviceSession returns the user's own session behind keyID. The session must have been
+// opened by the user on one of deviceTypes, not by an OAuth client; otherwise it is returned
+// with ErrDeviceNotAllowed.
+func LoadDeviceSession(ctx context.Context, sessions repository.SessionRepository, userID uuid.UUID, keyID string, deviceTypes ...string) (*repository.Session, error) {
+	session, err := sessions.GetSessionByKeyID(ctx, keyID)
+	if err != nil {
+		return nil, err
+	}
+	if session.UserID != userID {
+		return nil, repository.ErrNotFound
+	}
+	if session.ClientID.Valid || !slices.Contains(deviceTypes, session.DeviceType) {
+		return session, ErrDeviceNotAllowed
+	}
+	return session, nil
+}
+
+// IssueSession opens a new session and returns its token pair.
+// A session replaces any previous session of the same user and device type.
+func (s *SessionIssuer) IssueSession(ctx context.Context, req SessionRequest) (*service.TokenPair, error) {
+	tokenPair, _, err := s.OpenSession(ctx, req)
+	return tokenPair, err
+}
+
+// OpenSession opens a new session like IssueSession and also returns its record
+func (s *SessionIssuer) OpenSession(ctx context.Context, req SessionRequest) (*service.TokenPair, *repository.Session, error) {
+	// Generate device fingerprint from the User-Agent and Client Hints of the request
+	deviceFingerprint := s.Fingerprints.Generate(req.UserAgent)
+
+	// Create a new session key with device type
+	keyID, err := s.JWKManager.CreateSessionKey(req.UserID.String(), req.DeviceType)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create session key: %w", err)
+	}
+
+	// Record the session, replacing any previous record for this device type
+	if err := s.Sessions.DeleteUserDeviceSessions(ctx, req.UserID, req.DeviceType); err != nil {
+		return nil, nil, err
+	}
+	session := &repository.Session{
+		UserID:               req.UserID,
+		KeyID:                keyID,
+		DeviceType:           req.DeviceType,
+		FingerprintAlgorithm: int(deviceFingerprint.Algorithm),
+		Platform:             deviceFingerprint.Platform,
+		Browser:              deviceFingerprint.Browser,
+		Version:              deviceFingerprint.Version,
+		IPAddress:            req.IPAddress,
+		ClientID:             uuid.NullUUID{UUID: req.ClientID, Valid: req.ClientID != uuid.Nil},
+		Scope:                req.Scope,
+		AuthTime:             req.AuthTime,
+		AuthMethods:          req.AuthMethods,
+	}
+	if err := s.Sessions.CreateSession(ctx, session); err != nil {
+		return nil, nil, err
+	}
+
+	// Create JWT claims with device fingerprint
+	claims, err := sessionClaims(ctx, s.Queries, session, deviceFingerprint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create JWT claims: %w", err)
+	}
+
+	// Generate token pair
+	tokenPair, err := s.TokenService.GenerateTokenPairWithKeyID(claims.ToMap(), keyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to generate tokens: %w", err)
+	}
+
+	// Record the refresh token as the first of this session's token family
+	if err := s.RefreshTokens.CreateRefreshToken(ctx, &repository.RefreshToken{
+		TokenHash: HashToken(tokenPair.RefreshToken),
+		SessionID: session.SessionID,
+		UserID:    req.UserID,
+		KeyID:     keyID,
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return tokenPair, session, nil
+}
+
+// RefreshSession consumes a refresh token and rotates its session to a new key and token pair.
+// Presenting a refresh token that was already used revokes its whole session.
+func (s *SessionIssuer) RefreshSession(ctx context.Context, req RefreshRequest) (*service.TokenPair, *repository.Session, error) {
+	// Look up the refresh token record before verifying the signature:
+	// a rotated token's key is already gone, but its reuse must still be detected
+	tokenHash := HashToken(req.RefreshToken)
+	tokenRecord, err := s.RefreshTokens.GetRefreshToken(ctx, tokenHash)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if tokenRecord.UsedAt.Valid {
+		s.revokeTokenFamily(ctx, tokenRecord, req.IPAddress)
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// Sessions opened for an OAuth client only refresh through that client
+	session, err := s.Sessions.GetSession(ctx, tokenRecord.SessionID)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	if session.ClientID.UUID != req.ClientID {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// Verify the refresh token
+	refreshClaims, err := s.TokenService.VerifyRefreshToken(req.RefreshToken)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	userID, err := ExtractUserIdFromMapObj(refreshClaims)
+	if err != nil || userID != tokenRecord.UserID {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+	keyID, err := s.TokenService.ExtractKeyIDFromToken(req.RefreshToken)
+	if err != nil {
+		return nil, nil, ErrInvalidRefreshToken
+	}
+
+	// Validate current device fingerprint against the stored one. Refresh tokens carry only
+	// the hash, so the algorithm and the parts come from the session's record.
+	storedFingerprint, hasFingerprintClaim := fingerprint.FromClaims(refreshClaims)
+	if !hasFingerprintClaim {
+		return nil, nil, ErrMissingFingerprint
+	}
+	if _, hasAlgorithm := refreshClaims[fingerprint.ClaimAlgorithm]; !hasAlgorithm && session.FingerprintAlgorithm != 0 {
+		storedFingerprint.Algorithm = fingerprint.Algorithm(session.FingerprintAlgorithm)
+	}
+	if storedFingerprint.Platform == "" {
+		storedFingerprint.Platform = session.Platform
+		storedFingerprint.Browser = session.Browser
+		storedFingerprint.Version = session.Version
+	}
+	switch s.Fingerprints.Compare(req.UserAgent, storedFingerprint) {
+	case fingerprint.Mismatch:
+		log.Printf("❌ Device fingerprint mismatch for user %s during token refresh", userID.String())
+		return nil, nil, ErrFingerprintMismatch
+	case fingerprint.Upgraded:
+		log.Printf("🔒 User %s refreshed from %s updated to version %s; re-issuing the device fingerprint", userID.String(), storedFingerprint.Browser, s.Fingerprints.Generate(req.UserAgent).Version)
+	}
+
+	// Consume the refresh token; losing this race to a concurrent request is reuse too
+	if err := s.RefreshTokens.MarkRefreshTokenUsed(ctx, tokenHash); err != nil {
+		if err == repository.ErrAlreadyUsed {
+			s.revokeTokenFamily(ctx, tokenRecord, req.IPAddress)
+			return nil, nil, ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	// Create new JWT claims for the same device, moving the fingerprint to the current
+	// algorithm and browser version
+	deviceFingerprint := s.Fingerprints.Generate(req.UserAgent)
+	claims, err := sessionClaims(ctx, s.Queries, session, deviceFingerprint)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create JWT claims: %w", err)
+	}
+	tokenPair, err := s.TokenService.RefreshTokensWithKeyID(req.RefreshToken, claims.ToMap(), keyID)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to refresh tokens: %w", err)
+	}
+
+	// Move the session record to the newly issued session key
+	newKeyID, err := s.TokenService.ExtractKeyIDFromToken(tokenPair.AccessToken)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read refreshed key ID: %w", err)
+	}
+	if err := s.Sessions.RotateSessionKey(ctx, keyID, newKeyID, req.IPAddress); err != nil {
+		return nil, nil, fmt.Errorf("failed to update session record: %w", err)
+	}
+	session.KeyID = newKeyID
+	if int(deviceFingerprint.Algorithm) != session.FingerprintAlgorithm || deviceFingerprint.Platform != session.Platform ||
+		deviceFingerprint.Browser != session.Browser || deviceFingerprint.Version != session.Version {
+		session.FingerprintAlgorithm = int(deviceFingerprint.Algorithm)
+		session.Platform = deviceFingerprint.Platform
+		session.Browser = deviceFingerprint.Browser
+		session.Version = deviceFingerprint.Version
+		if err := s.Sessions.UpdateSessionFingerprint(ctx, session); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	// Record the new refresh token in the same token family
+	if err := s.RefreshTokens.CreateRefreshToken(ctx, &repository.RefreshToken{
+		TokenHash: HashToken(tokenPair.RefreshToken),
+		SessionID: tokenRecord.SessionID,
+		UserID:    userID,
+		KeyID:     newKeyID,
+	}); err != nil {
+		return nil, nil, err
+	}
+	return tokenPair, session, nil
+}
+
+// RevokeSession deletes a session's key and record, invalidating its access token
+// and its whole refresh token family. The record is kept when the key cannot be deleted.
+func (s *SessionIssuer) RevokeSession(ctx context.Context, session *repository.Session) error {
+	if err := DeleteSessionKey(s.JWKManager, session.UserID, session.KeyID); err != nil {
+		return err
+	}
+	return s.Sessions.DeleteSession(ctx, session.SessionID)
+}
+
+// revokeTokenFamily revokes the session a reused refresh token belongs to.
+// Both the legitimate client and whoever replayed the token must log in again.
+func (s *SessionIssuer) revokeTokenFamily(ctx context.Context, token *repository.RefreshToken, ipAddress string) {
+	log.Printf("🔒 Security event: refresh token reuse detected for user %s, session %s from IP %s; revoking token family",
+		token.UserID.String(), token.SessionID.String(), ipAddress)
+
+	session, err := s.Sessions.GetSession(ctx, token.SessionID)
+	if err != nil {
+		log.Printf("❌ Failed to load session %s for revocation: %v", token.SessionID.String(), err)
+		return
+	}
+	if err := s.RevokeSession(ctx, session); err != nil {
+		log.Printf("❌ Failed to delete session %s: %v", session.SessionID.String(), err)
+	}
+}
+
+// sessionClaims creates the JWT claims of a session, including the OAuth client and
+// scope of sessions opened through the authorization server. Client tokens leave out
+// the user's role, which only grants access to first-party routes.
+func sessionClaims(ctx context.Context, queries ProfileReader, session *repository.Session, deviceFingerprint *fingerprint.Fingerprint) (*models.JWTClaims, error) {
+	claims, err := CreateJWTClaims(queries, ctx, session.UserID, deviceFingerprint)
+	if err != nil {
+		return nil, err
+	}
+	if session.ClientID.Valid {
+		claims.Role = ""
+		claims.ClientID = session.ClientID.UUID.String()
+		claims.Scope = session.Scope
+	}
+	return claims, nil
+}
@@ -0,0 +1,176 @@
+package helpers
+
+import (
+	"context"
+	"crypto/subtle"
+	"database/sql"
+	"errors"
+	"fiber-api/api/models"
+	"fiber-api/api/repository"
+	"fiber-api/pkg/oidc"
+	"fmt"
+	"log"
+
+	"github.com/google/uuid"
+	"github.com/sushan531/auth-sqlc/generated"
+)
+
+var (
+	// ErrSocialEmailMissing is returned when a new provider account has no email to link or register
+	ErrSocialEmailMissing = errors.New("provider account has no email address")
+
+	// ErrSocialEmailUnverified is returned when a new provider account claims an email the
+	// provider has not verified, whether to link an existing profile or to create one
+	ErrSocialEmailUnverified = errors.New("provider email address is not verified")
+
+	// ErrSocialProfileUnverified is returned when a new provider account's email belongs to
+	// a profile whose owner never verified it, so whoever registered it may not own it
+	ErrSocialProfileUnverified = errors.New("existing profile email address is not verified")
+)
+
+// SocialBindingCookie names the cookie binding a social login to the browser that started it
+const SocialBindingCookie = "social_login_binding"
+
+// maxSocialBindingLength bounds the binding cookie value reused from a request
+const maxSocialBindingLength = 128
+
+// SocialBinding returns the browser binding to start a social login with. A browser
+// that already has one keeps it, so logins started in two tabs can both finish.
+func SocialBinding(existing string) (string, error) {
+	if existing != "" && len(existing) <= maxSocialBindingLength {
+		return existing, nil
+	}
+	return oidc.NewRandom()
+}
+
+// SocialBindingMatches reports whether a request's binding cookie belongs to the browser
+// that started a social login
+func SocialBindingMatches(state *repository.SocialLoginState, binding string) bool {
+	if state.BindingHash == "" || binding == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(HashToken(binding)), []byte(state.BindingHash)) == 1
+}
+
+// ResolveSocialAccount returns the profile a provider account logs in to. A linked identity
+// wins; otherwise the provider must have verified the email, and the account is linked to
+// the profile with that email, or a new profile with the given role is created for it.
+// A profile is only linked once its own email is verified: otherwise anyone could have
+// registered the address beforehand and would keep their password and sessions.
+func ResolveSocialAccount(ctx context.Context, queries *generated.Queries, logins repository.SocialLoginRepository, verifications repository.EmailVerificationRepository, provider string, claims *oidc.Claims, role string) (uuid.UUID, error) {
+	return resolveSocialAccount(ctx, queryProfiles{queries: queries}, logins, verifications, provider, claims, role)
+}
+
+// socialProfiles finds and registers the profiles provider accounts resolve to
+type socialProfiles interface {
+	// FindByEmail returns the ID of the profile with the email, or sql.ErrNoRows
+	FindByEmail(ctx context.Context, email string) (uuid.UUID, error)
+	// Create registers a profile for a provider account
+	Create(ctx context.Context, claims *oidc.Claims, role string) (uuid.UUID, error)
+}
+
+// queryProfiles implements socialProfiles with the auth and profile queries
+type queryProfiles struct {
+	queries *generated.Queries
+}
+
+func (p queryProfiles) FindByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	auth, err := p.queries.GetUserAuth(ctx, email)
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return auth.UserProfileID, nil
+}
+
+func (p queryProfiles) Create(ctx context.Context, claims *oidc.Claims, role string) (uuid.UUID, error) {
+	return createSocialProfile(ctx, p.queries, claims, role)
+}
+
+// resolveSocialAccount implements ResolveSocialAccount
+func resolveSocialAccount(ctx context.Context, profiles socialProfiles, logins repository.SocialLoginRepository, verifications repository.EmailVerificationRepository, provider string, claims *oidc.Claims, role string) (uuid.UUID, error) {
+	identity, err := logins.GetIdentity(ctx, provider, claims.Subject)
+	if err == nil {
+		if err := logins.TouchIdentity(ctx, provider, claims.Subject); err != nil {
+			log.Printf("❌ Failed to record %s login of user %s: %v", provider, identity.UserID.String(), err)
+		}
+		return identity.UserID, nil
+	}
+	if err != repository.ErrNotFound {
+		return uuid.Nil, err
+	}
+
+	if claims.Email == "" {
+		return uuid.Nil, ErrSocialEmailMissing
+	}
+	// Linking hands the profile to whoever controls the provider account, and creating one
+	// takes the address from its owner, so the provider must vouch for the email address
+	if !claims.EmailVerified {
+		return uuid.Nil, ErrSocialEmailUnverified
+	}
+
+	userID, err := profiles.FindByEmail(ctx, claims.Email)
+	switch {
+	case err == nil:
+		verified, err := verifications.IsEmailVerified(ctx, userID)
+		if err != nil {
+			return uuid.Nil, fmt.Errorf("failed to check email verification of user %s: %w", claims.Email, err)
+		}
+		if !verified {
+			return uuid.Nil, ErrSocialProfileUnverified
+		}
+		log.Printf("🔒 Linking %s account %s to existing user %s", provider, claims.Subject, claims.Email)
+	case errors.Is(err, sql.ErrNoRows):
+		userID, err = profiles.Create(ctx, claims, role)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		log.Printf("🚀 Created user %s from %s account %s", claims.Email, provider, claims.Subject)
+		if err := verifications.MarkEmailVerified(ctx, userID); err != nil {
+			log.Printf("❌ Failed to mark email of user %s verified: %v", claims.Email, err)
+		}
+	default:
+		return uuid.Nil, fmt.Errorf("failed to look up user %s: %w", claims.Email, err)
+	}
+
+	err = logins.CreateIdentity(ctx, &repository.UserIdentity{
+		Provider: provider,
+		Subject:  claims.Subject,
+		UserID:   userID,
+		Email:    claims.Email,
+	})
+	if err == repository.ErrDuplicate {
+		// A concurrent login linked the account first
+		identity, err := logins.GetIdentity(ctx, provider, claims.Subject)
+		if err != nil {
+			return uuid.Nil, err
+		}
+		return identity.UserID, nil
+	}
+	if err != nil {
+		return uuid.Nil, err
+	}
+	return userID, nil
+}
+
+// createSocialProfile registers a profile for a provider account. It gets a random
+// password nobody knows; the user can set one through the password reset flow.
+func createSocialProfile(ctx context.Context, queries *generated.Queries, claims *oidc.Claims, role string) (uuid.UUID, error) {
+	password, err := GenerateOpaqueToken()
+	if err != nil {
+		return uuid.Nil, err
+	}
+	fullName := claims.Name
+	if fullName == "" {
+		fullName = claims.Email
+	}
+
+	user, err := CreateUserProfile(ctx, queries, models.SignUp{
+		UserEmail: claims.Email,
+		Password:  password,
+		FullName:  fullName,
+	}, role)
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create user %s: %w", claims.Email, err)
+	}
+	return user.UserProfileID, nil
+}
@@ -0,0 +1,235 @@
+package helpers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fiber-api/api/repository"
+	"fiber-api/pkg/oidc"
+	"fiber-api/pkg/oidc/oidctest"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+// fakeProfiles holds profiles by email
+type fakeProfiles struct {
+	byEmail map[string]uuid.UUID
+	created []string
+	roles   []string
+}
+
+func (f *fakeProfiles) FindByEmail(ctx context.Context, email string) (uuid.UUID, error) {
+	if id, ok := f.byEmail[email]; ok {
+		return id, nil
+	}
+	return uuid.Nil, sql.ErrNoRows
+}
+
+func (f *fakeProfiles) Create(ctx context.Context, claims *oidc.Claims, role string) (uuid.UUID, error) {
+	id := uuid.New()
+	f.byEmail[claims.Email] = id
+	f.created = append(f.created, claims.Email)
+	f.roles = append(f.roles, role)
+	return id, nil
+}
+
+// fakeSocialLogins holds linked identities by provider and subject
+type fakeSocialLogins struct {
+	identities map[string]*repository.UserIdentity
+}
+
+func (f *fakeSocialLogins) CreateState(ctx context.Context, state *repository.SocialLoginState) error {
+	return nil
+}
+
+func (f *fakeSocialLogins) ConsumeState(ctx context.Context, stateHash string) (*repository.SocialLoginState, error) {
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeSocialLogins) GetIdentity(ctx context.Context, provider string, subject string) (*repository.UserIdentity, error) {
+	if identity, ok := f.identities[provider+"/"+subject]; ok {
+		return identity, nil
+	}
+	return nil, repository.ErrNotFound
+}
+
+func (f *fakeSocialLogins) CreateIdentity(ctx context.Context, identity *repository.UserIdentity) error {
+	key := identity.Provider + "/" + identity.Subject
+	if _, exists := f.identities[key]; exists {
+		return repository.ErrDuplicate
+	}
+	f.identities[key] = identity
+	return nil
+}
+
+func (f *fakeSocialLogins) TouchIdentity(ctx context.Context, provider string, subject string) error {
+	return nil
+}
+
+// fakeVerifications records verified users
+type fakeVerifications struct {
+	verified map[uuid.UUID]bool
+}
+
+func (f *fakeVerifications) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	return f.verified[userID], nil
+}
+
+func (f *fakeVerifications) MarkEmailVerified(ctx context.Context, userID uuid.UUID) error {
+	f.verified[userID] = true
+	return nil
+}
+
+// socialFixture signs in to a mock provider and resolves the account against fakes
+type socialFixture struct {
+	mock          *oidctest.Provider
+	provider      *oidc.Provider
+	profiles      *fakeProfiles
+	logins        *fakeSocialLogins
+	verifications *fakeVerifications
+}
+
+func newSocialFixture(t *testing.T) *socialFixture {
+	t.Helper()
+	mock := oidctest.NewProvider(t, "client-123")
+	provider, err := oidc.New(oidc.Config{Name: "mock", Issuer: mock.Issuer(), ClientID: "client-123", RedirectURL: "http://localhost/callback"})
+	if err != nil {
+		t.Fatalf("oidc.New: %v", err)
+	}
+	return &socialFixture{
+		mock:          mock,
+		provider:      provider,
+		profiles:      &fakeProfiles{byEmail: map[string]uuid.UUID{}},
+		logins:        &fakeSocialLogins{identities: map[string]*repository.UserIdentity{}},
+		verifications: &fakeVerifications{verified: map[uuid.UUID]bool{}},
+	}
+}
+
+// login completes a login at the mock provider and resolves its account
+func (f *socialFixture) login(t *testing.T, claims map[string]interface{}) (uuid.UUID, error) {
+	t.Helper()
+	ctx := context.Background()
+	claims["nonce"] = "nonce"
+	rawIDToken, err := f.provider.Exchange(ctx, f.mock.IssueCode(f.mock.IDToken(claims)), "verifier")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	verified, err := f.provider.VerifyIDToken(ctx, rawIDToken, "nonce")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	return resolveSocialAccount(ctx, f.profiles, f.logins, f.verifications, f.provider.Name(), verified, "user")
+}
+
+func TestResolveSocialAccountCreatesProfile(t *testing.T) {
+	f := newSocialFixture(t)
+
+	userID, err := f.login(t, map[string]interface{}{"sub": "alice", "email": "alice@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if len(f.profiles.created) != 1 || f.profiles.byEmail["alice@example.com"] != userID || f.profiles.roles[0] != "user" {
+		t.Fatalf("created %v with roles %v, want alice@example.com as user", f.profiles.created, f.profiles.roles)
+	}
+	if !f.verifications.verified[userID] {
+		t.Error("email verified by the provider was not marked verified")
+	}
+
+	// The next login uses the linked identity
+	again, err := f.login(t, map[string]interface{}{"sub": "alice", "email": "alice@example.com", "email_verified": true})
+	if err != nil || again != userID {
+		t.Fatalf("second login = %s, %v; want %s", again, err, userID)
+	}
+	if len(f.profiles.created) != 1 {
+		t.Errorf("second login created another profile")
+	}
+}
+
+func TestResolveSocialAccountLinksVerifiedEmail(t *testing.T) {
+	f := newSocialFixture(t)
+	existing := uuid.New()
+	f.profiles.byEmail["bob@example.com"] = existing
+	f.verifications.verified[existing] = true
+
+	userID, err := f.login(t, map[string]interface{}{"sub": "bob", "email": "bob@example.com", "email_verified": true})
+	if err != nil {
+		t.Fatalf("resolve: %v", err)
+	}
+	if userID != existing || len(f.profiles.created) != 0 {
+		t.Fatalf("resolved %s, created %v; want the existing profile %s", userID, f.profiles.created, existing)
+	}
+	if identity := f.logins.identities["mock/bob"]; identity == nil || identity.UserID != existing {
+		t.Errorf("identity = %+v, want a link to %s", identity, existing)
+	}
+}
+
+func TestResolveSocialAccountRefusesUnverifiedProfile(t *testing.T) {
+	f := newSocialFixture(t)
+	// Registered with the victim's address by someone who never verified it
+	existing := uuid.New()
+	f.profiles.byEmail["frank@example.com"] = existing
+
+	_, err := f.login(t, map[string]interface{}{"sub": "frank", "email": "frank@example.com", "email_verified": true})
+	if !errors.Is(err, ErrSocialProfileUnverified) {
+		t.Fatalf("error = %v, want ErrSocialProfileUnverified", err)
+	}
+	if len(f.logins.identities) != 0 || f.verifications.verified[existing] {
+		t.Errorf("refused login linked identities %v or verified the profile", f.logins.identities)
+	}
+
+	// Once the profile's owner verifies the address, the provider account links to it
+	f.verifications.verified[existing] = true
+	userID, err := f.login(t, map[string]interface{}{"sub": "frank", "email": "frank@example.com", "email_verified": true})
+	if err != nil || userID != existing {
+		t.Fatalf("login after verification = %s, %v; want %s", userID, err, existing)
+	}
+}
+
+func TestResolveSocialAccountRefusesUnverifiedEmail(t *testing.T) {
+	f := newSocialFixture(t)
+	existing := uuid.New()
+	f.profiles.byEmail["carol@example.com"] = existing
+
+	// Neither linking an existing profile nor creating a new one
+	for _, email := range []string{"carol@example.com", "dave@example.com"} {
+		_, err := f.login(t, map[string]interface{}{"sub": email, "email": email, "email_verified": false})
+		if !errors.Is(err, ErrSocialEmailUnverified) {
+			t.Errorf("%s: error = %v, want ErrSocialEmailUnverified", email, err)
+		}
+	}
+	if len(f.profiles.created) != 0 || len(f.logins.identities) != 0 {
+		t.Errorf("unverified logins created profiles %v and identities %v", f.profiles.created, f.logins.identities)
+	}
+}
+
+func TestResolveSocialAccountRequiresEmail(t *testing.T) {
+	f := newSocialFixture(t)
+	if _, err := f.login(t, map[string]interface{}{"sub": "erin"}); !errors.Is(err, ErrSocialEmailMissing) {
+		t.Fatalf("error = %v, want ErrSocialEmailMissing", err)
+	}
+}
+
+func TestSocialBinding(t *testing.T) {
+	binding, err := SocialBinding("")
+	if err != nil || binding == "" {
+		t.Fatalf("SocialBinding = %q, %v", binding, err)
+	}
+	if kept, _ := SocialBinding(binding); kept != binding {
+		t.Errorf("SocialBinding replaced the browser's existing binding")
+	}
+
+	state := &repository.SocialLoginState{BindingHash: HashToken(binding)}
+	if !SocialBindingMatches(state, binding) {
+		t.Error("the starting browser's binding did not match")
+	}
+	other, _ := SocialBinding("")
+	for _, presented := range []string{"", other} {
+		if SocialBindingMatches(state, presented) {
+			t.Errorf("binding %q of another browser matched", presented)
+		}
+	}
+	if SocialBindingMatches(&repository.SocialLoginState{}, "") {
+		t.Error("a state without a binding matched")
+	}
+}
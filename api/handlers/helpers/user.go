@@ -0,0 +1,58 @@
+package helpers
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fiber-api/api/models"
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/sushan531/auth-sqlc/generated"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ErrPasswordHashing is returned when a password cannot be hashed
+var ErrPasswordHashing = errors.New("failed to hash password")
+
+// ProfileWriter inserts user profiles; *generated.Queries implements it
+type ProfileWriter interface {
+	InsertUserProfile(ctx context.Context, arg generated.InsertUserProfileParams) (generated.Auth, error)
+}
+
+// CreateUserProfile hashes the password and inserts a new profile with the given role
+func CreateUserProfile(ctx context.Context, queries ProfileWriter, input models.SignUp, role string) (generated.Auth, error) {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(input.Password), bcrypt.DefaultCost)
+	if err != nil {
+		return generated.Auth{}, ErrPasswordHashing
+	}
+
+	return queries.InsertUserProfile(ctx, generated.InsertUserProfileParams{
+		UserEmail: input.UserEmail,
+		Password:  string(hashedPassword),
+		FullName:  input.FullName,
+		UserRole:  sql.NullString{String: ResolveRole(role), Valid: true},
+		Address:   sql.NullString{String: input.Address, Valid: input.Address != ""},
+	})
+}
+
+// UpdatePassword hashes and stores a new password for the user
+func UpdatePassword(ctx context.Context, queries *generated.Queries, userID uuid.UUID, password string) error {
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return ErrPasswordHashing
+	}
+
+	// Only the password column is flagged for update
+	_, err = queries.ConditionalUpdateAuth(ctx, generated.ConditionalUpdateAuthParams{
+		Column1:       1,
+		Password:      string(hashedPassword),
+		Column3:       0,
+		Column5:       0,
+		UserProfileID: userID,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to update password for user %s: %w", userID.String(), err)
+	}
+	return nil
+}
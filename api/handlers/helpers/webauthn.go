@@ -0,0 +1,28 @@
+package helpers
+
+import (
+	"context"
+	"fiber-api/api/repository"
+	"fiber-api/pkg/webauthn"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CreateWebAuthnChallenge stores a new ceremony challenge of the given purpose, valid for
+// the relying party timeout, and returns it. Discoverable credential logins use uuid.Nil.
+func CreateWebAuthnChallenge(ctx context.Context, tokens repository.OneTimeTokenRepository, rp *webauthn.RelyingParty, userID uuid.UUID, purpose string) (string, error) {
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		return "", err
+	}
+	if err := tokens.CreateToken(ctx, &repository.OneTimeToken{
+		TokenHash: HashToken(challenge),
+		UserID:    userID,
+		Purpose:   purpose,
+		ExpiresAt: time.Now().Add(rp.Config().Timeout),
+	}); err != nil {
+		return "", err
+	}
+	return challenge, nil
+}
@@ -0,0 +1,288 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/api/validators"
+	"fiber-api/config"
+	"fiber-api/pkg/totp"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/skip2/go-qrcode"
+	"github.com/sushan531/auth-sqlc/generated"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// LoginMFAHandler exchanges the mfa_challenge of a password login plus a TOTP or
+// recovery code for a token pair. A challenge is single-use: after a wrong code
+// the user has to log in with their password again.
+func LoginMFAHandler(issuer *helpers.SessionIssuer, tokens repository.OneTimeTokenRepository, mfa repository.MFARepository, cfg config.MFAConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse request body
+		var input models.LoginMFA
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateLoginMFA(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		// Consume the challenge before checking the code so it cannot be brute forced
+		challenge, err := tokens.ConsumeToken(ctx, helpers.HashToken(input.MFAChallenge), repository.TokenPurposeMFAChallenge)
+		if err == repository.ErrNotFound {
+			return errors.AuthenticationError(c, "Invalid or expired MFA challenge")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to consume mfa challenge: %v", err)
+			return errors.InternalError(c, "Failed to verify authentication code")
+		}
+
+		if err := helpers.VerifyMFACode(ctx, mfa, cfg, challenge.UserID, input.Code); err != nil {
+			if err == helpers.ErrInvalidMFACode {
+				log.Printf("🔒 Invalid mfa code for user %s", challenge.UserID)
+				return errors.AuthenticationError(c, "Invalid authentication code")
+			}
+			log.Printf("❌ Failed to verify mfa code for user %s: %v", challenge.UserID, err)
+			return errors.InternalError(c, "Failed to verify authentication code")
+		}
+
+		// Get device type from middleware
+		deviceType := middleware.GetDeviceType(c)
+
+		// Open a session for this device and issue its token pair
+		tokenPair, err := issuer.IssueSession(ctx, helpers.SessionRequest{
+			UserID:      challenge.UserID,
+			DeviceType:  string(deviceType),
+			UserAgent:   middleware.GetUserAgentHeaders(c),
+			IPAddress:   c.IP(),
+			AuthMethods: []string{models.AMRPassword, models.AMROneTimeCode, models.AMRMultiFactor},
+		})
+		if err != nil {
+			log.Printf("❌ Failed to open session for user %s on device %s: %v", challenge.UserID, deviceType, err)
+			return errors.InternalError(c, "Failed to create session")
+		}
+
+		log.Printf("🚀 User %s completed two-factor login from %s device", challenge.UserID, deviceType)
+		return c.JSON(presenter.SignInSuccessResponse(*tokenPair))
+	}
+}
+
+// EnrollMFAHandler creates a new TOTP secret for the logged-in user and returns it as
+// an otpauth:// URI and QR code. The secret stays pending until confirmed with a code.
+func EnrollMFAHandler(queries *generated.Queries, mfa repository.MFARepository, cfg config.MFAConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		if !cfg.Enabled() {
+			return errors.SendError(c, fiber.StatusServiceUnavailable, errors.NewAPIError(
+				errors.ErrCodeMFAUnavailable,
+				"Two-factor authentication is not configured",
+				"",
+			))
+		}
+
+		// Extract user from JWT claims
+		userEmail, ok := c.Locals("user_email").(string)
+		if !ok {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		auth, err := queries.GetUserAuth(ctx, userEmail)
+		if err != nil {
+			log.Printf("❌ Failed to fetch user %s for mfa enrollment: %v", userEmail, err)
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		secret, err := totp.GenerateSecret()
+		if err != nil {
+			log.Printf("❌ Failed to generate mfa secret for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to start enrollment")
+		}
+		encryptedSecret, err := helpers.EncryptMFASecret(cfg, secret)
+		if err != nil {
+			log.Printf("❌ Failed to encrypt mfa secret for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to start enrollment")
+		}
+
+		// Store the pending secret, replacing an unconfirmed earlier one
+		err = mfa.SavePendingMFA(ctx, auth.UserProfileID, encryptedSecret)
+		if err == repository.ErrAlreadyUsed {
+			return errors.SendError(c, fiber.StatusConflict, errors.NewAPIError(
+				errors.ErrCodeDuplicate,
+				"Two-factor authentication is already enabled",
+				"",
+			))
+		}
+		if err != nil {
+			log.Printf("❌ Failed to store mfa secret for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to start enrollment")
+		}
+
+		uri := totp.URI(cfg.Issuer, userEmail, secret)
+		png, err := qrcode.Encode(uri, qrcode.Medium, 256)
+		if err != nil {
+			log.Printf("❌ Failed to render mfa qr code for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to start enrollment")
+		}
+		qrCode := "data:image/png;base64," + base64.StdEncoding.EncodeToString(png)
+
+		log.Printf("🔒 MFA enrollment started for user %s", userEmail)
+		return c.JSON(presenter.MFAEnrollmentSuccessResponse(secret, uri, qrCode))
+	}
+}
+
+// ConfirmMFAHandler enables a pending TOTP enrollment with a first valid code and
+// returns the user's recovery codes
+func ConfirmMFAHandler(queries *generated.Queries, mfa repository.MFARepository, cfg config.MFAConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user from JWT claims
+		userEmail, ok := c.Locals("user_email").(string)
+		if !ok {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Parse request body
+		var input models.ConfirmMFA
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateConfirmMFA(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		auth, err := queries.GetUserAuth(ctx, userEmail)
+		if err != nil {
+			log.Printf("❌ Failed to fetch user %s for mfa confirmation: %v", userEmail, err)
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		enrollment, err := mfa.GetMFA(ctx, auth.UserProfileID)
+		if err == repository.ErrNotFound || (err == nil && enrollment.Enabled()) {
+			return errors.NotFoundError(c, "No pending two-factor enrollment")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to fetch mfa enrollment for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to confirm enrollment")
+		}
+
+		secret, err := helpers.DecryptMFASecret(cfg, enrollment.EncryptedSecret)
+		if err != nil {
+			log.Printf("❌ Failed to decrypt mfa secret for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to confirm enrollment")
+		}
+		step, valid := totp.Validate(secret, input.Code, time.Now(), helpers.TOTPSkew)
+		if !valid {
+			log.Printf("🔒 Invalid mfa confirmation code for user %s", userEmail)
+			return errors.AuthenticationError(c, "Invalid authentication code")
+		}
+
+		codes, hashes, err := helpers.GenerateRecoveryCodes()
+		if err != nil {
+			log.Printf("❌ Failed to generate recovery codes for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to confirm enrollment")
+		}
+		err = mfa.EnableMFA(ctx, auth.UserProfileID, step, hashes)
+		if err == repository.ErrNotFound {
+			return errors.NotFoundError(c, "No pending two-factor enrollment")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to enable mfa for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to confirm enrollment")
+		}
+
+		log.Printf("🔒 MFA enabled for user %s", userEmail)
+		return c.JSON(presenter.MFAEnabledResponse(codes))
+	}
+}
+
+// DisableMFAHandler turns off TOTP for the logged-in user after re-checking
+// their password and a current TOTP or recovery code
+func DisableMFAHandler(queries *generated.Queries, mfa repository.MFARepository, cfg config.MFAConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user from JWT claims
+		userEmail, ok := c.Locals("user_email").(string)
+		if !ok {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Parse request body
+		var input models.DisableMFA
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateDisableMFA(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		// Re-verify the password
+		auth, err := queries.GetUserAuth(ctx, userEmail)
+		if err != nil {
+			log.Printf("❌ Failed to fetch user %s for mfa disable: %v", userEmail, err)
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(auth.Password), []byte(input.Password)); err != nil {
+			log.Printf("❌ Invalid password on mfa disable for user %s", userEmail)
+			return errors.AuthenticationError(c, "Password is incorrect")
+		}
+
+		// Require the second factor as well
+		if err := helpers.VerifyMFACode(ctx, mfa, cfg, auth.UserProfileID, input.Code); err != nil {
+			if err == helpers.ErrInvalidMFACode {
+				log.Printf("🔒 Invalid mfa code on mfa disable for user %s", userEmail)
+				return errors.AuthenticationError(c, "Invalid authentication code")
+			}
+			log.Printf("❌ Failed to verify mfa code for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to disable two-factor authentication")
+		}
+
+		if err := mfa.DeleteMFA(ctx, auth.UserProfileID); err != nil {
+			log.Printf("❌ Failed to disable mfa for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to disable two-factor authentication")
+		}
+
+		log.Printf("🔒 MFA disabled for user %s", userEmail)
+		return c.JSON(presenter.MFADisabledResponse())
+	}
+}
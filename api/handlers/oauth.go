@@ -0,0 +1,654 @@
+package handlers
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"log"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/manager"
+	"github.com/sushan531/jwk-auth/service"
+)
+
+// IntrospectTokenHandler implements RFC 7662 token introspection for authenticated clients.
+// A token is active while its signature, expiry and session key check out; a refresh
+// token must also not have been rotated yet. Clients only see their own tokens: a token
+// issued to another client or to the first-party API is reported inactive.
+func IntrospectTokenHandler(tokenService service.TokenService, sessions repository.SessionRepository, refreshTokens repository.RefreshTokenRepository, clients repository.OAuthClientRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		client, ok := authenticateClient(c, clients, false)
+		if !ok {
+			return errors.InvalidClientError(c)
+		}
+
+		token := c.FormValue("token")
+		if token == "" {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidRequest, "token is required")
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		claims, tokenType := introspectToken(ctx, tokenService, sessions, refreshTokens, client, token, c.FormValue("token_type_hint"))
+		if claims == nil {
+			return c.JSON(presenter.InactiveTokenResponse())
+		}
+
+		log.Printf("🔒 Client %s introspected an active %s", client.ClientID.String(), tokenType)
+		return c.JSON(presenter.TokenIntrospectionResponse(claims, tokenType))
+	}
+}
+
+// RevokeTokenHandler implements RFC 7009 token revocation for authenticated clients.
+// Revoking either token of a session deletes its session key, which invalidates the
+// access token and the whole refresh token family; revoking a client_credentials token
+// deletes the client's key. Clients can only revoke their own tokens. Unknown tokens
+// and tokens of other clients are not an error and are left alone.
+func RevokeTokenHandler(jwkManager manager.JwkManager, tokenService service.TokenService, sessions repository.SessionRepository, refreshTokens repository.RefreshTokenRepository, clients repository.OAuthClientRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		client, ok := authenticateClient(c, clients, false)
+		if !ok {
+			return errors.InvalidClientError(c)
+		}
+
+		token := c.FormValue("token")
+		if token == "" {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidRequest, "token is required")
+		}
+
+		ownerID, keyID, found := tokenSessionKey(ctx, tokenService, sessions, refreshTokens, client, token, c.FormValue("token_type_hint"))
+		if !found {
+			return c.SendStatus(fiber.StatusOK)
+		}
+
+		if err := helpers.DeleteSessionKey(jwkManager, ownerID, keyID); err != nil {
+			log.Printf("❌ Failed to revoke session key %s of %s: %v", keyID, ownerID.String(), err)
+			return errors.SendOAuthError(c, fiber.StatusServiceUnavailable, errors.OAuthServerError, "Failed to revoke token")
+		}
+		if err := sessions.DeleteSessionByKeyID(ctx, keyID); err != nil {
+			log.Printf("❌ Failed to delete session record for key %s: %v", keyID, err)
+			return errors.SendOAuthError(c, fiber.StatusServiceUnavailable, errors.OAuthServerError, "Failed to revoke token")
+		}
+
+		log.Printf("🔒 Client %s revoked session key %s of %s", client.ClientID.String(), keyID, ownerID.String())
+		return c.SendStatus(fiber.StatusOK)
+	}
+}
+
+// AuthorizeHandler starts the authorization code flow (RFC 6749 section 4.1). It checks the
+// client, the exact redirect URI and the PKCE challenge, stores the request and sends the
+// user to the consent page, which logs the user in and decides on the request.
+func AuthorizeHandler(clients repository.OAuthClientRepository, authorizations repository.OAuthAuthorizationRepository, cfg config.OAuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Until the redirect URI is known to be registered, errors are shown instead of redirected
+		clientID, err := uuid.Parse(c.Query("client_id"))
+		if err != nil {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidRequest, "client_id is missing or invalid")
+		}
+		client, err := clients.GetClient(ctx, clientID)
+		if err != nil {
+			if err != repository.ErrNotFound {
+				log.Printf("❌ Failed to fetch oauth client %s: %v", clientID.String(), err)
+			}
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidRequest, "Unknown client")
+		}
+		redirectURI := c.Query("redirect_uri")
+		if !client.HasRedirectURI(redirectURI) {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidRequest, "redirect_uri does not match a registered redirect URI")
+		}
+
+		state := c.Query("state")
+		if c.Query("response_type") != models.ResponseTypeCode {
+			return redirectAuthorizationError(c, redirectURI, state, errors.OAuthUnsupportedResponseType, "Only the code response type is supported")
+		}
+		if !client.AllowsGrant(models.GrantTypeAuthorizationCode) {
+			return redirectAuthorizationError(c, redirectURI, state, errors.OAuthUnauthorizedClient, "The client may not use the authorization code grant")
+		}
+		codeChallenge := c.Query("code_challenge")
+		if len(codeChallenge) != 43 || c.Query("code_challenge_method") != models.CodeChallengeMethodS256 {
+			return redirectAuthorizationError(c, redirectURI, state, errors.OAuthInvalidRequest, "PKCE with code_challenge_method S256 is required")
+		}
+		scopes := helpers.ParseScope(c.Query("scope"))
+		if !client.AllowsScopes(scopes) {
+			return redirectAuthorizationError(c, redirectURI, state, errors.OAuthInvalidScope, "The requested scope is not allowed for this client")
+		}
+
+		authorization := &repository.OAuthAuthorization{
+			ClientID:      client.ClientID,
+			RedirectURI:   redirectURI,
+			Scope:         strings.Join(scopes, " "),
+			State:         state,
+			Nonce:         c.Query("nonce"),
+			CodeChallenge: codeChallenge,
+			ExpiresAt:     time.Now().Add(cfg.RequestTTL),
+		}
+		if err := authorizations.CreateAuthorization(ctx, authorization); err != nil {
+			log.Printf("❌ Failed to store authorization request for client %s: %v", client.ClientID.String(), err)
+			return redirectAuthorizationError(c, redirectURI, state, errors.OAuthServerError, "Failed to start authorization")
+		}
+
+		consentURL, err := helpers.AuthorizationRedirect(cfg.ConsentURL, url.Values{"request_id": {authorization.RequestID.String()}})
+		if err != nil {
+			log.Printf("❌ Invalid OAuth consent URL %q: %v", cfg.ConsentURL, err)
+			return redirectAuthorizationError(c, redirectURI, state, errors.OAuthServerError, "Failed to start authorization")
+		}
+		return c.Redirect(consentURL, fiber.StatusFound)
+	}
+}
+
+// GetAuthorizationRequestHandler describes a pending authorization request to the consent page
+func GetAuthorizationRequestHandler(clients repository.OAuthClientRepository, authorizations repository.OAuthAuthorizationRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		requestID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return errors.ValidationError(c, "Invalid authorization request ID")
+		}
+
+		authorization, err := authorizations.GetAuthorization(ctx, requestID)
+		if err != nil || !authorization.Pending() {
+			return errors.NotFoundError(c, "Authorization request not found or expired")
+		}
+		client, err := clients.GetClient(ctx, authorization.ClientID)
+		if err != nil {
+			return errors.NotFoundError(c, "Authorization request not found or expired")
+		}
+
+		return c.JSON(presenter.AuthorizationRequestDetailsResponse(authorization, client))
+	}
+}
+
+// DecideAuthorizationHandler records the logged-in user's consent or refusal and returns the
+// client redirect carrying the authorization code, or the access_denied error. The code
+// inherits the authentication time and methods of the user's own session.
+func DecideAuthorizationHandler(sessions repository.SessionRepository, authorizations repository.OAuthAuthorizationRepository, cfg config.OAuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		keyID, ok := c.Locals("key_id").(string)
+		if !ok || keyID == "" {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		requestID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return errors.ValidationError(c, "Invalid authorization request ID")
+		}
+
+		// Only the user's own sessions may consent, never a session held by a client
+		session, err := sessions.GetSessionByKeyID(ctx, keyID)
+		if err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		if session.ClientID.Valid {
+			return errors.AuthorizationError(c, "OAuth client sessions cannot authorize clients")
+		}
+
+		// Parse request body
+		var input models.AuthorizationDecision
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		authorization, err := authorizations.GetAuthorization(ctx, requestID)
+		if err != nil || !authorization.Pending() {
+			return errors.NotFoundError(c, "Authorization request not found or expired")
+		}
+
+		params := url.Values{}
+		if authorization.State != "" {
+			params.Set("state", authorization.State)
+		}
+
+		if !input.Approve {
+			if err := authorizations.DeleteAuthorization(ctx, requestID); err != nil {
+				log.Printf("❌ Failed to delete denied authorization request %s: %v", requestID.String(), err)
+			}
+			params.Set("error", errors.OAuthAccessDenied)
+			redirectTo, err := helpers.AuthorizationRedirect(authorization.RedirectURI, params)
+			if err != nil {
+				log.Printf("❌ Failed to build redirect for authorization request %s: %v", requestID.String(), err)
+				return errors.InternalError(c, "Failed to record decision")
+			}
+			log.Printf("🔒 User %s denied client %s", userID, authorization.ClientID.String())
+			return c.JSON(presenter.AuthorizationDecisionResponse(redirectTo))
+		}
+
+		code, err := helpers.GenerateOpaqueToken()
+		if err != nil {
+			log.Printf("❌ Failed to generate authorization code: %v", err)
+			return errors.InternalError(c, "Failed to record decision")
+		}
+		authorization.UserID = uuid.NullUUID{UUID: userUuidID, Valid: true}
+		authorization.AuthTime = sql.NullTime{Time: session.AuthTime, Valid: true}
+		authorization.AuthMethods = session.AuthMethods
+		authorization.CodeHash = sql.NullString{String: helpers.HashToken(code), Valid: true}
+		if err := authorizations.ApproveAuthorization(ctx, authorization, cfg.CodeTTL); err != nil {
+			if err == repository.ErrAlreadyUsed {
+				return errors.NotFoundError(c, "Authorization request not found or expired")
+			}
+			log.Printf("❌ Failed to approve authorization request %s: %v", requestID.String(), err)
+			return errors.InternalError(c, "Failed to record decision")
+		}
+
+		params.Set("code", code)
+		redirectTo, err := helpers.AuthorizationRedirect(authorization.RedirectURI, params)
+		if err != nil {
+			log.Printf("❌ Failed to build redirect for authorization request %s: %v", requestID.String(), err)
+			return errors.InternalError(c, "Failed to record decision")
+		}
+
+		log.Printf("🚀 User %s authorized client %s for scope %q", userID, authorization.ClientID.String(), authorization.Scope)
+		return c.JSON(presenter.AuthorizationDecisionResponse(redirectTo))
+	}
+}
+
+// TokenHandler implements the token endpoint (RFC 6749 section 3.2) for the
+// authorization_code, refresh_token, client_credentials and device_code grants.
+// Sessions granted the openid scope also get an ID token.
+func TokenHandler(issuer *helpers.SessionIssuer, clients repository.OAuthClientRepository, authorizations repository.OAuthAuthorizationRepository, devices repository.OAuthDeviceAuthorizationRepository, oidc config.OIDCConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		client, ok := authenticateClient(c, clients, true)
+		if !ok {
+			return errors.InvalidClientError(c)
+		}
+
+		grantType := c.FormValue("grant_type")
+		if !slices.Contains(models.GrantTypes, grantType) {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthUnsupportedGrantType, "Unsupported grant_type")
+		}
+		if !client.AllowsGrant(grantType) {
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthUnauthorizedClient, "The client may not use this grant type")
+		}
+
+		switch grantType {
+		case models.GrantTypeAuthorizationCode:
+			return exchangeAuthorizationCode(c, issuer, authorizations, client, oidc)
+		case models.GrantTypeRefreshToken:
+			return refreshClientSession(c, issuer, client, oidc)
+		case models.GrantTypeDeviceCode:
+			return exchangeDeviceCode(c, issuer, devices, client, oidc)
+		default:
+			return issueClientCredentials(c, issuer, client)
+		}
+	}
+}
+
+// exchangeAuthorizationCode redeems an authorization code for a session of the client.
+// A code presented twice revokes the session issued for it (RFC 6749 section 4.1.2).
+func exchangeAuthorizationCode(c *fiber.Ctx, issuer *helpers.SessionIssuer, authorizations repository.OAuthAuthorizationRepository, client *repository.OAuthClient, oidc config.OIDCConfig) error {
+	ctx := c.Context()
+
+	code := c.FormValue("code")
+	if code == "" {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidRequest, "code is required")
+	}
+	codeHash := helpers.HashToken(code)
+	authorization, err := authorizations.GetAuthorizationByCode(ctx, codeHash)
+	if err != nil || authorization.ClientID != client.ClientID || !authorization.UserID.Valid {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Invalid or expired authorization code")
+	}
+	if authorization.UsedAt.Valid {
+		revokeAuthorizationSession(ctx, issuer, authorization, c.IP())
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Invalid or expired authorization code")
+	}
+	if c.FormValue("redirect_uri") != authorization.RedirectURI {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "redirect_uri does not match the authorization request")
+	}
+	if !helpers.VerifyCodeChallenge(c.FormValue("code_verifier"), authorization.CodeChallenge) {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Invalid code_verifier")
+	}
+
+	// Redeem the code; losing this race to a concurrent request is reuse too
+	if err := authorizations.ConsumeAuthorizationCode(ctx, codeHash); err != nil {
+		if err == repository.ErrAlreadyUsed {
+			revokeAuthorizationSession(ctx, issuer, authorization, c.IP())
+			return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Invalid or expired authorization code")
+		}
+		log.Printf("❌ Failed to redeem authorization code of client %s: %v", client.ClientID.String(), err)
+		return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+	}
+
+	tokenPair, session, err := issuer.OpenSession(ctx, helpers.SessionRequest{
+		UserID:      authorization.UserID.UUID,
+		DeviceType:  helpers.OAuthDeviceType(client.ClientID),
+		UserAgent:   middleware.GetUserAgentHeaders(c),
+		IPAddress:   c.IP(),
+		AuthMethods: authorization.AuthMethods,
+		AuthTime:    authorization.AuthTime.Time,
+		ClientID:    client.ClientID,
+		Scope:       authorization.Scope,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to open session for client %s: %v", client.ClientID.String(), err)
+		return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+	}
+	if err := authorizations.SetAuthorizationSession(ctx, authorization.RequestID, session.SessionID); err != nil {
+		log.Printf("❌ Failed to record session of authorization %s: %v", authorization.RequestID.String(), err)
+	}
+
+	idToken, err := issueIDToken(c, issuer, session, helpers.IDTokenRequest{Issuer: oidc.Issuer, TTL: oidc.IDTokenTTL, KeyRotation: oidc.IDTokenKeyRotation, Nonce: authorization.Nonce})
+	if err != nil {
+		log.Printf("❌ Failed to issue id token to client %s: %v", client.ClientID.String(), err)
+		return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+	}
+
+	log.Printf("🚀 Client %s obtained tokens for user %s", client.ClientID.String(), authorization.UserID.UUID.String())
+	return sendTokenResponse(c, tokenPair, idToken, authorization.Scope)
+}
+
+// refreshClientSession rotates the tokens of a session opened for the client. The new
+// ID token keeps the original auth_time but carries no nonce.
+func refreshClientSession(c *fiber.Ctx, issuer *helpers.SessionIssuer, client *repository.OAuthClient, oidc config.OIDCConfig) error {
+	refreshToken := c.FormValue("refresh_token")
+	if refreshToken == "" {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidRequest, "refresh_token is required")
+	}
+
+	tokenPair, session, err := issuer.RefreshSession(c.Context(), helpers.RefreshRequest{
+		RefreshToken: refreshToken,
+		ClientID:     client.ClientID,
+		UserAgent:    middleware.GetUserAgentHeaders(c),
+		IPAddress:    c.IP(),
+	})
+	switch {
+	case err == nil:
+		idToken, err := issueIDToken(c, issuer, session, helpers.IDTokenRequest{Issuer: oidc.Issuer, TTL: oidc.IDTokenTTL, KeyRotation: oidc.IDTokenKeyRotation})
+		if err != nil {
+			log.Printf("❌ Failed to issue id token to client %s: %v", client.ClientID.String(), err)
+			return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+		}
+		return sendTokenResponse(c, tokenPair, idToken, session.Scope)
+	case err == helpers.ErrInvalidRefreshToken, err == helpers.ErrMissingFingerprint:
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Invalid or expired refresh token")
+	case err == helpers.ErrFingerprintMismatch:
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidGrant, "Device fingerprint mismatch")
+	default:
+		log.Printf("❌ Failed to refresh tokens of client %s: %v", client.ClientID.String(), err)
+		return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+	}
+}
+
+// issueClientCredentials issues an access token to the client itself. Without a scope
+// parameter the token gets every scope registered for the client.
+func issueClientCredentials(c *fiber.Ctx, issuer *helpers.SessionIssuer, client *repository.OAuthClient) error {
+	if client.Public {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthUnauthorizedClient, "Public clients cannot use the client_credentials grant")
+	}
+
+	scopes := helpers.ParseScope(c.FormValue("scope"))
+	if len(scopes) == 0 {
+		scopes = client.Scopes
+	}
+	if !client.AllowsScopes(scopes) {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, errors.OAuthInvalidScope, "The requested scope is not allowed for this client")
+	}
+	scope := strings.Join(scopes, " ")
+
+	tokenPair, err := issuer.IssueClientToken(c.Context(), client, scope)
+	if err != nil {
+		log.Printf("❌ Failed to issue client token to client %s: %v", client.ClientID.String(), err)
+		return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to issue tokens")
+	}
+
+	log.Printf("🚀 Client %s obtained a client_credentials token", client.ClientID.String())
+	return sendTokenResponse(c, tokenPair, "", scope)
+}
+
+// revokeAuthorizationSession revokes the session issued for a replayed authorization code
+func revokeAuthorizationSession(ctx context.Context, issuer *helpers.SessionIssuer, authorization *repository.OAuthAuthorization, ipAddress string) {
+	log.Printf("🔒 Security event: authorization code reuse detected for client %s from IP %s; revoking its session",
+		authorization.ClientID.String(), ipAddress)
+	if !authorization.SessionID.Valid {
+		return
+	}
+	session, err := issuer.Sessions.GetSession(ctx, authorization.SessionID.UUID)
+	if err != nil {
+		return
+	}
+	if err := issuer.RevokeSession(ctx, session); err != nil {
+		log.Printf("❌ Failed to delete session %s: %v", session.SessionID.String(), err)
+	}
+}
+
+// redirectAuthorizationError sends an authorization error back to the client's redirect URI
+func redirectAuthorizationError(c *fiber.Ctx, redirectURI string, state string, code string, description string) error {
+	params := url.Values{"error": {code}, "error_description": {description}}
+	if state != "" {
+		params.Set("state", state)
+	}
+	target, err := helpers.AuthorizationRedirect(redirectURI, params)
+	if err != nil {
+		return errors.SendOAuthError(c, fiber.StatusBadRequest, code, description)
+	}
+	return c.Redirect(target, fiber.StatusFound)
+}
+
+// issueIDToken issues an ID token when the session was granted the openid scope,
+// and returns an empty string otherwise
+func issueIDToken(c *fiber.Ctx, issuer *helpers.SessionIssuer, session *repository.Session, req helpers.IDTokenRequest) (string, error) {
+	if !slices.Contains(strings.Fields(session.Scope), models.ScopeOpenID) {
+		return "", nil
+	}
+	return issuer.IssueIDToken(c.Context(), session, req)
+}
+
+// sendTokenResponse sends a successful token endpoint response, which must not be cached
+func sendTokenResponse(c *fiber.Ctx, tokenPair *service.TokenPair, idToken string, scope string) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	c.Set(fiber.HeaderPragma, "no-cache")
+	return c.JSON(presenter.OAuthTokenResponse(*tokenPair, idToken, scope))
+}
+
+// UserInfoHandler implements the OpenID Connect userinfo endpoint. It serves the claims of
+// the token's user that its scopes release, from the same profile as GetProfileHandler.
+func UserInfoHandler(queries *generated.Queries, verifications repository.EmailVerificationRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID and granted scopes from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		claims, _ := c.Locals("claims").(map[string]interface{})
+		scope, _ := claims["scope"].(string)
+		scopes := strings.Fields(scope)
+		if !slices.Contains(scopes, models.ScopeOpenID) {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer error="insufficient_scope", scope="openid"`)
+			return errors.SendOAuthError(c, fiber.StatusForbidden, errors.OAuthInsufficientScope, "The access token was not granted the openid scope")
+		}
+
+		info, err := helpers.LoadUserInfo(ctx, queries, verifications, userUuidID, scopes)
+		if err != nil {
+			log.Printf("❌ Failed to load user info for user %s: %v", userID, err)
+			return errors.SendOAuthError(c, fiber.StatusInternalServerError, errors.OAuthServerError, "Failed to load user info")
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.JSON(info)
+	}
+}
+
+// authenticateClient identifies the calling client from HTTP Basic credentials or,
+// failing that, from client_id and client_secret form parameters. With allowPublic,
+// a public client is identified by its client_id alone.
+func authenticateClient(c *fiber.Ctx, clients repository.OAuthClientRepository, allowPublic bool) (*repository.OAuthClient, bool) {
+	clientID, secret, ok := basicClientCredentials(c.Get(fiber.HeaderAuthorization))
+	if !ok {
+		clientID, secret = c.FormValue("client_id"), c.FormValue("client_secret")
+	}
+	if clientID == "" || (secret == "" && !allowPublic) {
+		return nil, false
+	}
+
+	id, err := uuid.Parse(clientID)
+	if err != nil {
+		return nil, false
+	}
+	client, err := clients.GetClient(c.Context(), id)
+	if err != nil {
+		if err != repository.ErrNotFound {
+			log.Printf("❌ Failed to fetch oauth client %s: %v", clientID, err)
+		}
+		return nil, false
+	}
+	if secret == "" {
+		if !client.Public {
+			return nil, false
+		}
+		return client, true
+	}
+	if !helpers.VerifyClientSecret(client, secret) {
+		log.Printf("🔒 Invalid client secret for oauth client %s from IP %s", clientID, c.IP())
+		return nil, false
+	}
+	return client, true
+}
+
+// basicClientCredentials decodes client_secret_basic credentials, whose ID and secret
+// are form-encoded before base64 encoding (RFC 6749 section 2.3.1)
+func basicClientCredentials(header string) (string, string, bool) {
+	encoded, found := strings.CutPrefix(header, "Basic ")
+	if !found {
+		return "", "", false
+	}
+	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return "", "", false
+	}
+	rawID, rawSecret, found := strings.Cut(string(decoded), ":")
+	if !found {
+		return "", "", false
+	}
+	clientID, err := url.QueryUnescape(rawID)
+	if err != nil {
+		return "", "", false
+	}
+	secret, err := url.QueryUnescape(rawSecret)
+	if err != nil {
+		return "", "", false
+	}
+	return clientID, secret, true
+}
+
+// introspectToken returns the claims and type hint of an active token issued to client,
+// trying the hinted token type first. Returns nil claims for inactive or unknown tokens
+// and for tokens issued to anyone else.
+func introspectToken(ctx context.Context, tokenService service.TokenService, sessions repository.SessionRepository, refreshTokens repository.RefreshTokenRepository, client *repository.OAuthClient, token string, hint string) (map[string]interface{}, string) {
+	asAccess := func() (map[string]interface{}, string) {
+		claims, err := tokenService.VerifyToken(token)
+		if err != nil || !issuedTo(claims, client) {
+			return nil, ""
+		}
+		return claims, helpers.TokenTypeHintAccessToken
+	}
+	asRefresh := func() (map[string]interface{}, string) {
+		record, err := refreshTokens.GetRefreshToken(ctx, helpers.HashToken(token))
+		if err != nil || record.UsedAt.Valid {
+			return nil, ""
+		}
+		session, err := sessions.GetSession(ctx, record.SessionID)
+		if err != nil || !sessionOfClient(session, client) {
+			return nil, ""
+		}
+		claims, err := tokenService.VerifyRefreshToken(token)
+		if err != nil {
+			return nil, ""
+		}
+		return claims, helpers.TokenTypeHintRefreshToken
+	}
+
+	first, second := asAccess, asRefresh
+	if hint == helpers.TokenTypeHintRefreshToken {
+		first, second = asRefresh, asAccess
+	}
+	if claims, tokenType := first(); claims != nil {
+		return claims, tokenType
+	}
+	return second()
+}
+
+// tokenSessionKey finds the owner and current session key of a token issued to client.
+// The owner is the user of a session, or the client itself for client_credentials
+// tokens. A refresh token is matched by its recorded hash, so a rotated refresh token
+// still leads to its session; an access token must carry a valid signature.
+func tokenSessionKey(ctx context.Context, tokenService service.TokenService, sessions repository.SessionRepository, refreshTokens repository.RefreshTokenRepository, client *repository.OAuthClient, token string, hint string) (uuid.UUID, string, bool) {
+	fromRefresh := func() (uuid.UUID, string, bool) {
+		record, err := refreshTokens.GetRefreshToken(ctx, helpers.HashToken(token))
+		if err != nil {
+			return uuid.Nil, "", false
+		}
+		session, err := sessions.GetSession(ctx, record.SessionID)
+		if err != nil || !sessionOfClient(session, client) {
+			return uuid.Nil, "", false
+		}
+		return session.UserID, session.KeyID, true
+	}
+	fromAccess := func() (uuid.UUID, string, bool) {
+		claims, err := tokenService.VerifyToken(token)
+		if err != nil || !issuedTo(claims, client) {
+			return uuid.Nil, "", false
+		}
+		keyID, ok := claims["kid"].(string)
+		if !ok || keyID == "" {
+			return uuid.Nil, "", false
+		}
+		// client_credentials tokens carry no user; their key belongs to the client
+		if _, hasUser := claims["user_id"]; !hasUser {
+			return client.ClientID, keyID, true
+		}
+		userID, err := helpers.ExtractUserIdFromMapObj(claims)
+		if err != nil {
+			return uuid.Nil, "", false
+		}
+		return userID, keyID, true
+	}
+
+	first, second := fromAccess, fromRefresh
+	if hint == helpers.TokenTypeHintRefreshToken {
+		first, second = fromRefresh, fromAccess
+	}
+	if ownerID, keyID, ok := first(); ok {
+		return ownerID, keyID, true
+	}
+	return second()
+}
+
+// issuedTo reports whether verified access token claims name client as their client
+func issuedTo(claims map[string]interface{}, client *repository.OAuthClient) bool {
+	clientID, _ := claims["client_id"].(string)
+	return clientID == client.ClientID.String()
+}
+
+// sessionOfClient reports whether a session was opened for client
+func sessionOfClient(session *repository.Session, client *repository.OAuthClient) bool {
+	return session.ClientID.Valid && session.ClientID.UUID == client.ClientID
+}
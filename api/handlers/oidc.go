@@ -0,0 +1,42 @@
+package handlers
+
+import (
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/presenter"
+	"fiber-api/config"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// JWKSHandler publishes the public keys of all sessions from the server's cache. Access
+// and refresh tokens carry their key ID in the kid claim of the payload, not in the JOSE
+// header, which matches the kid of one of these keys.
+func JWKSHandler(jwks *helpers.JWKSCache, cfg config.OIDCConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		set, err := jwks.Get()
+		if err != nil {
+			log.Printf("❌ Failed to build JWKS: %v", err)
+			return errors.InternalError(c, "Failed to load signing keys")
+		}
+
+		setCacheControl(c, cfg.JWKSMaxAge)
+		return c.JSON(set, "application/jwk-set+json")
+	}
+}
+
+// OpenIDConfigurationHandler serves the OpenID Connect discovery document
+func OpenIDConfigurationHandler(cfg config.OIDCConfig, oauth config.OAuthConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		setCacheControl(c, cfg.DiscoveryMaxAge)
+		return c.JSON(presenter.OpenIDConfigurationResponse(cfg.Issuer, oauth.Scopes))
+	}
+}
+
+// setCacheControl lets clients and shared caches reuse a public response for maxAge
+func setCacheControl(c *fiber.Ctx, maxAge time.Duration) {
+	c.Set(fiber.HeaderCacheControl, fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+}
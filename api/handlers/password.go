@@ -0,0 +1,209 @@
+package handlers
+
+import (
+	"context"
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/api/validators"
+	"fiber-api/config"
+	"fiber-api/pkg/mailer"
+	"fmt"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/manager"
+	"golang.org/x/crypto/bcrypt"
+)
+
+// ForgotPasswordHandler emails a password reset link. It always responds with 200
+// so the response does not reveal whether the email is registered.
+func ForgotPasswordHandler(queries *generated.Queries, tokens repository.OneTimeTokenRepository, mail mailer.Mailer, cfg config.PasswordResetConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse request body
+		var input models.ForgotPassword
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateForgotPassword(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		response := presenter.PasswordResetRequestedResponse()
+
+		// Unknown emails get the same response as known ones
+		auth, err := queries.GetUserAuth(ctx, input.UserEmail)
+		if err != nil {
+			log.Printf("🔒 Password reset requested for unknown email %s", input.UserEmail)
+			return c.JSON(response)
+		}
+
+		// Invalidate outstanding reset tokens before issuing a new one
+		if err := tokens.DeleteUserTokens(ctx, auth.UserProfileID, repository.TokenPurposePasswordReset); err != nil {
+			log.Printf("❌ Failed to clear reset tokens for user %s: %v", input.UserEmail, err)
+			return errors.InternalError(c, "Failed to process password reset")
+		}
+
+		resetToken, err := helpers.GenerateOpaqueToken()
+		if err != nil {
+			log.Printf("❌ Failed to generate reset token for user %s: %v", input.UserEmail, err)
+			return errors.InternalError(c, "Failed to process password reset")
+		}
+		if err := tokens.CreateToken(ctx, &repository.OneTimeToken{
+			TokenHash: helpers.HashToken(resetToken),
+			UserID:    auth.UserProfileID,
+			Purpose:   repository.TokenPurposePasswordReset,
+			ExpiresAt: time.Now().Add(cfg.TokenTTL),
+		}); err != nil {
+			log.Printf("❌ Failed to store reset token for user %s: %v", input.UserEmail, err)
+			return errors.InternalError(c, "Failed to process password reset")
+		}
+
+		// Deliver in the background so response time does not depend on the mail server
+		msg := mailer.Message{
+			To:      input.UserEmail,
+			Subject: "Reset your password",
+			Body: fmt.Sprintf(
+				"We received a request to reset your password.\n\nReset it here: %s?token=%s\n\nThis link expires in %s. If you did not request a reset, you can ignore this email.",
+				cfg.ResetURL, url.QueryEscape(resetToken), cfg.TokenTTL,
+			),
+		}
+		go func() {
+			if err := mail.Send(context.Background(), msg); err != nil {
+				log.Printf("❌ Failed to send password reset email to %s: %v", msg.To, err)
+			}
+		}()
+
+		log.Printf("🔒 Password reset requested for user %s", input.UserEmail)
+		return c.JSON(response)
+	}
+}
+
+// ResetPasswordHandler consumes a reset token, sets the new password and revokes every session of the user
+func ResetPasswordHandler(queries *generated.Queries, jwkManager manager.JwkManager, sessions repository.SessionRepository, tokens repository.OneTimeTokenRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse request body
+		var input models.ResetPassword
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateResetPassword(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		// Consume the reset token
+		token, err := tokens.ConsumeToken(ctx, helpers.HashToken(input.Token), repository.TokenPurposePasswordReset)
+		if err != nil {
+			if err != repository.ErrNotFound {
+				log.Printf("❌ Failed to consume reset token: %v", err)
+			}
+			return errors.ValidationError(c, "Invalid or expired reset token")
+		}
+
+		// Store the new password
+		if err := helpers.UpdatePassword(ctx, queries, token.UserID, input.NewPassword); err != nil {
+			log.Printf("❌ Failed to reset password for user %s: %v", token.UserID.String(), err)
+			return errors.InternalError(c, "Failed to reset password")
+		}
+
+		// Every existing session was opened with the old password
+		revoked, err := helpers.RevokeAllSessions(ctx, jwkManager, sessions, token.UserID, "")
+		if err != nil {
+			log.Printf("❌ Failed to revoke sessions after password reset for user %s: %v", token.UserID.String(), err)
+			return errors.InternalError(c, "Password was reset but existing sessions could not be revoked")
+		}
+
+		log.Printf("🔒 Password reset for user %s, %d sessions revoked", token.UserID.String(), revoked)
+		return c.JSON(presenter.PasswordResetSuccessResponse())
+	}
+}
+
+// ChangePasswordHandler re-verifies the current password, stores the new one and
+// revokes every other session of the user while keeping the current one
+func ChangePasswordHandler(queries *generated.Queries, jwkManager manager.JwkManager, sessions repository.SessionRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user from JWT claims
+		userEmail, ok := c.Locals("user_email").(string)
+		if !ok {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		currentKeyID, _ := c.Locals("key_id").(string)
+
+		// Parse request body
+		var input models.ChangePassword
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateChangePassword(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		// Re-verify the current password
+		auth, err := queries.GetUserAuth(ctx, userEmail)
+		if err != nil {
+			log.Printf("❌ Failed to fetch user %s for password change: %v", userEmail, err)
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(auth.Password), []byte(input.CurrentPassword)); err != nil {
+			log.Printf("❌ Invalid current password on password change for user %s", userEmail)
+			return errors.AuthenticationError(c, "Current password is incorrect")
+		}
+
+		// Store the new password
+		if err := helpers.UpdatePassword(ctx, queries, auth.UserProfileID, input.NewPassword); err != nil {
+			log.Printf("❌ Failed to change password for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Failed to change password")
+		}
+
+		// Sign out every other device
+		revoked, err := helpers.RevokeAllSessions(ctx, jwkManager, sessions, auth.UserProfileID, currentKeyID)
+		if err != nil {
+			log.Printf("❌ Failed to revoke sessions after password change for user %s: %v", userEmail, err)
+			return errors.InternalError(c, "Password was changed but other sessions could not be revoked")
+		}
+
+		log.Printf("🔒 Password changed for user %s, %d other sessions revoked", userEmail, revoked)
+		return c.JSON(presenter.PasswordChangedResponse(revoked))
+	}
+}
@@ -0,0 +1,274 @@
+package handlers
+
+import (
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/api/validators"
+	"fiber-api/config"
+	"fiber-api/pkg/fingerprint"
+	"log"
+	"net/url"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// StartQRLoginHandler starts a cross-device login for a web browser. The browser shows the
+// returned QR payload and polls with its poll token until the user approves in the app.
+func StartQRLoginHandler(logins repository.QRLoginRepository, fingerprints *fingerprint.Generator, cfg config.QRLoginConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		if middleware.GetDeviceType(c) != middleware.DeviceTypeWeb {
+			return errors.ValidationError(c, "QR login is only available in web browsers")
+		}
+
+		pollToken, err := helpers.GenerateOpaqueToken()
+		if err != nil {
+			log.Printf("❌ Failed to generate qr login poll token: %v", err)
+			return errors.InternalError(c, "Failed to start QR login")
+		}
+		scanCode, err := helpers.GenerateOpaqueToken()
+		if err != nil {
+			log.Printf("❌ Failed to generate qr login code: %v", err)
+			return errors.InternalError(c, "Failed to start QR login")
+		}
+
+		// Remember the browser, so only it can collect the session and the app can show it
+		browser := fingerprints.Generate(middleware.GetUserAgentHeaders(c))
+		login := &repository.QRLogin{
+			PollTokenHash:   helpers.HashToken(pollToken),
+			ScanCodeHash:    helpers.HashToken(scanCode),
+			FingerprintHash: browser.Hash,
+			Platform:        browser.Platform,
+			Browser:         browser.Browser,
+			IPAddress:       c.IP(),
+			ExpiresAt:       time.Now().Add(cfg.TTL),
+		}
+		if err := logins.CreateLogin(ctx, login); err != nil {
+			log.Printf("❌ Failed to store qr login: %v", err)
+			return errors.InternalError(c, "Failed to start QR login")
+		}
+
+		qrPayload, err := helpers.AuthorizationRedirect(cfg.ScanURL, url.Values{"code": {scanCode}})
+		if err != nil {
+			log.Printf("❌ Invalid QR login scan URL %q: %v", cfg.ScanURL, err)
+			return errors.InternalError(c, "Failed to start QR login")
+		}
+
+		c.Set(fiber.HeaderCacheControl, "no-store")
+		return c.Status(fiber.StatusCreated).JSON(presenter.QRLoginStartedResponse(login, qrPayload, pollToken, cfg.PollInterval))
+	}
+}
+
+// PollQRLoginHandler answers a browser waiting for its QR login with 202 until the user
+// decides. Once approved, the first poll opens the browser's own web session.
+func PollQRLoginHandler(issuer *helpers.SessionIssuer, logins repository.QRLoginRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse request body
+		var input models.QRLoginPoll
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateQRLoginPoll(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		login, err := logins.GetLoginByPollToken(ctx, helpers.HashToken(input.PollToken))
+		if err != nil {
+			if err != repository.ErrNotFound {
+				log.Printf("❌ Failed to fetch qr login: %v", err)
+				return errors.InternalError(c, "Failed to check QR login")
+			}
+			return errors.NotFoundError(c, "QR login not found or expired")
+		}
+		// QR logins expire within minutes, so they are always fingerprinted with the current algorithm
+		if !issuer.Fingerprints.Matches(middleware.GetUserAgentHeaders(c), login.FingerprintHash, fingerprint.CurrentAlgorithm) {
+			log.Printf("🔒 QR login %s polled from a different browser at %s", login.LoginID.String(), c.IP())
+			return errors.AuthenticationError(c, "QR login was started in another browser")
+		}
+
+		switch {
+		case !time.Now().Before(login.ExpiresAt):
+			return errors.NotFoundError(c, "QR login not found or expired")
+		case login.DeniedAt.Valid:
+			return errors.AuthorizationError(c, "QR login was denied in the mobile app")
+		case !login.UserID.Valid:
+			return c.Status(fiber.StatusAccepted).JSON(presenter.QRLoginPendingResponse(login))
+		}
+
+		// Redeem the approval; a concurrent poll that got there first already has the session
+		if err := logins.ConsumeLogin(ctx, login.LoginID); err != nil {
+			if err == repository.ErrAlreadyUsed {
+				return errors.NotFoundError(c, "QR login not found or expired")
+			}
+			log.Printf("❌ Failed to redeem qr login %s: %v", login.LoginID.String(), err)
+			return errors.InternalError(c, "Failed to create session")
+		}
+
+		// The browser gets a web session of its own, independent of the app's session
+		tokenPair, err := issuer.IssueSession(ctx, helpers.SessionRequest{
+			UserID:      login.UserID.UUID,
+			DeviceType:  string(middleware.DeviceTypeWeb),
+			UserAgent:   middleware.GetUserAgentHeaders(c),
+			IPAddress:   c.IP(),
+			AuthMethods: []string{models.AMRMultiChannel},
+		})
+		if err != nil {
+			log.Printf("❌ Failed to open web session for qr login %s: %v", login.LoginID.String(), err)
+			return errors.InternalError(c, "Failed to create session")
+		}
+
+		log.Printf("🚀 User %s logged in on the web by QR code", login.UserID.UUID.String())
+		return c.JSON(presenter.SignInSuccessResponse(*tokenPair))
+	}
+}
+
+// ScanQRLoginHandler describes the browser behind a scanned QR code to the native app, so
+// the user can check it is their own before approving
+func ScanQRLoginHandler(sessions repository.SessionRepository, logins repository.QRLoginRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		keyID, ok := c.Locals("key_id").(string)
+		if !ok || keyID == "" {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Only a session of the user's own Android or iOS app may approve
+		session, err := helpers.LoadDeviceSession(ctx, sessions, userUuidID, keyID, string(middleware.DeviceTypeAndroid), string(middleware.DeviceTypeIOS))
+		if err == helpers.ErrDeviceNotAllowed {
+			log.Printf("🔒 User %s tried to use a QR login from a %s session", userID, session.DeviceType)
+			return errors.AuthorizationError(c, "QR logins can only be approved in the mobile app")
+		}
+		if err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Parse request body
+		var input models.QRLoginScan
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateQRLoginCode(input.Code)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		login, err := logins.GetLoginByScanCode(ctx, helpers.HashToken(input.Code))
+		if err != nil || !login.Pending() {
+			return errors.NotFoundError(c, "QR login not found or expired")
+		}
+		if err := logins.MarkLoginScanned(ctx, login.LoginID); err != nil {
+			log.Printf("❌ Failed to mark qr login %s scanned: %v", login.LoginID.String(), err)
+		}
+
+		return c.JSON(presenter.QRLoginRequestDetailsResponse(login))
+	}
+}
+
+// DecideQRLoginHandler records the app user's approval or refusal of a scanned QR login
+func DecideQRLoginHandler(sessions repository.SessionRepository, logins repository.QRLoginRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		keyID, ok := c.Locals("key_id").(string)
+		if !ok || keyID == "" {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Only a session of the user's own Android or iOS app may approve
+		session, err := helpers.LoadDeviceSession(ctx, sessions, userUuidID, keyID, string(middleware.DeviceTypeAndroid), string(middleware.DeviceTypeIOS))
+		if err == helpers.ErrDeviceNotAllowed {
+			log.Printf("🔒 User %s tried to use a QR login from a %s session", userID, session.DeviceType)
+			return errors.AuthorizationError(c, "QR logins can only be approved in the mobile app")
+		}
+		if err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Parse request body
+		var input models.QRLoginDecision
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateQRLoginCode(input.Code)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		login, err := logins.GetLoginByScanCode(ctx, helpers.HashToken(input.Code))
+		if err != nil || !login.Pending() {
+			return errors.NotFoundError(c, "QR login not found or expired")
+		}
+
+		if input.Approve {
+			err = logins.ApproveLogin(ctx, login.LoginID, session.UserID)
+		} else {
+			err = logins.DenyLogin(ctx, login.LoginID)
+		}
+		if err != nil {
+			if err == repository.ErrAlreadyUsed {
+				return errors.NotFoundError(c, "QR login not found or expired")
+			}
+			log.Printf("❌ Failed to record decision on qr login %s: %v", login.LoginID.String(), err)
+			return errors.InternalError(c, "Failed to record decision")
+		}
+
+		if input.Approve {
+			log.Printf("🚀 User %s approved QR login %s from %s device", session.UserID.String(), login.LoginID.String(), session.DeviceType)
+		} else {
+			log.Printf("🔒 User %s denied QR login %s", session.UserID.String(), login.LoginID.String())
+		}
+		return c.JSON(presenter.QRLoginDecisionResponse(input.Approve))
+	}
+}
@@ -0,0 +1,241 @@
+package handlers
+
+import (
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/api/validators"
+	"fiber-api/config"
+	"fiber-api/pkg/oidc"
+	"log"
+	"slices"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+)
+
+// ListSocialProvidersHandler lists the providers users can log in with
+func ListSocialProvidersHandler(providers map[string]*oidc.Provider) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		names := make([]string, 0, len(providers))
+		for name := range providers {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		return c.JSON(presenter.SocialProvidersListResponse(names))
+	}
+}
+
+// BeginSocialLoginHandler starts a login at an external OpenID Connect provider. It stores
+// a fresh state, nonce and PKCE verifier and returns the provider URL to send the user to.
+// The state is bound to the browser by an HttpOnly cookie, which finishing the login requires.
+func BeginSocialLoginHandler(providers map[string]*oidc.Provider, logins repository.SocialLoginRepository, cfg config.SocialLoginConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		provider, ok := providers[c.Params("provider")]
+		if !ok {
+			return errors.NotFoundError(c, "Unknown login provider")
+		}
+
+		state, err := oidc.NewRandom()
+		if err != nil {
+			log.Printf("❌ Failed to generate %s login state: %v", provider.Name(), err)
+			return errors.InternalError(c, "Failed to start login")
+		}
+		nonce, err := oidc.NewRandom()
+		if err != nil {
+			log.Printf("❌ Failed to generate %s login nonce: %v", provider.Name(), err)
+			return errors.InternalError(c, "Failed to start login")
+		}
+		verifier, err := oidc.NewRandom()
+		if err != nil {
+			log.Printf("❌ Failed to generate %s code verifier: %v", provider.Name(), err)
+			return errors.InternalError(c, "Failed to start login")
+		}
+		binding, err := helpers.SocialBinding(c.Cookies(helpers.SocialBindingCookie))
+		if err != nil {
+			log.Printf("❌ Failed to generate %s login binding: %v", provider.Name(), err)
+			return errors.InternalError(c, "Failed to start login")
+		}
+
+		authorizationURL, err := provider.AuthCodeURL(ctx, state, nonce, oidc.CodeChallenge(verifier))
+		if err != nil {
+			log.Printf("❌ Failed to build %s authorization URL: %v", provider.Name(), err)
+			return errors.InternalError(c, "Failed to start login")
+		}
+
+		if err := logins.CreateState(ctx, &repository.SocialLoginState{
+			StateHash:    helpers.HashToken(state),
+			Provider:     provider.Name(),
+			Nonce:        nonce,
+			CodeVerifier: verifier,
+			BindingHash:  helpers.HashToken(binding),
+			ExpiresAt:    time.Now().Add(cfg.StateTTL),
+		}); err != nil {
+			log.Printf("❌ Failed to store %s login state: %v", provider.Name(), err)
+			return errors.InternalError(c, "Failed to start login")
+		}
+
+		c.Cookie(&fiber.Cookie{
+			Name:     helpers.SocialBindingCookie,
+			Value:    binding,
+			Path:     "/api/social",
+			MaxAge:   int(cfg.StateTTL.Seconds()),
+			Secure:   cfg.CookieSecure,
+			HTTPOnly: true,
+			SameSite: cfg.CookieSameSite,
+		})
+
+		return c.JSON(presenter.SocialLoginStartedResponse(authorizationURL))
+	}
+}
+
+// FinishSocialLoginHandler completes a social login with the code and state the provider
+// returned, in the browser that started it. The provider's ID token is verified, the account
+// linked or created, and a session opened the same way a password login does, including
+// the MFA step.
+func FinishSocialLoginHandler(queries *generated.Queries, issuer *helpers.SessionIssuer, providers map[string]*oidc.Provider, logins repository.SocialLoginRepository, verifications repository.EmailVerificationRepository, verification config.EmailVerificationConfig, signup config.SignupConfig, tokens repository.OneTimeTokenRepository, mfa repository.MFARepository, mfaConfig config.MFAConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		provider, ok := providers[c.Params("provider")]
+		if !ok {
+			return errors.NotFoundError(c, "Unknown login provider")
+		}
+
+		// Parse request body
+		var input models.SocialLoginFinish
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateSocialLoginFinish(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		// Consume the state first, so a login can be finished only once
+		state, err := logins.ConsumeState(ctx, helpers.HashToken(input.State))
+		if err == repository.ErrNotFound || (err == nil && state.Provider != provider.Name()) {
+			return errors.AuthenticationError(c, "Invalid or expired login state")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to consume %s login state: %v", provider.Name(), err)
+			return errors.InternalError(c, "Failed to complete login")
+		}
+
+		// A state finished in another browser is a login CSRF attempt: the code belongs to
+		// whoever started the login, not to the browser presenting it
+		if !helpers.SocialBindingMatches(state, c.Cookies(helpers.SocialBindingCookie)) {
+			log.Printf("🔒 Security event: %s login state presented by another browser from IP %s", provider.Name(), c.IP())
+			return errors.AuthenticationError(c, "Invalid or expired login state")
+		}
+
+		// Redeem the code and verify the ID token against the provider's keys and our nonce
+		rawIDToken, err := provider.Exchange(ctx, input.Code, state.CodeVerifier)
+		if err != nil {
+			log.Printf("🔒 %s code exchange failed: %v", provider.Name(), err)
+			return errors.AuthenticationError(c, "Login at the provider could not be verified")
+		}
+		claims, err := provider.VerifyIDToken(ctx, rawIDToken, state.Nonce)
+		if err != nil {
+			log.Printf("🔒 %s ID token rejected: %v", provider.Name(), err)
+			return errors.AuthenticationError(c, "Login at the provider could not be verified")
+		}
+
+		return completeSocialLogin(c, queries, issuer, provider, claims, logins, verifications, verification, signup, tokens, mfa, mfaConfig)
+	}
+}
+
+// completeSocialLogin resolves the profile of a verified provider account and logs it in
+func completeSocialLogin(c *fiber.Ctx, queries *generated.Queries, issuer *helpers.SessionIssuer, provider *oidc.Provider, claims *oidc.Claims, logins repository.SocialLoginRepository, verifications repository.EmailVerificationRepository, verification config.EmailVerificationConfig, signup config.SignupConfig, tokens repository.OneTimeTokenRepository, mfa repository.MFARepository, mfaConfig config.MFAConfig) error {
+	ctx := c.Context()
+
+	userID, err := helpers.ResolveSocialAccount(ctx, queries, logins, verifications, provider.Name(), claims, signup.DefaultRole)
+	switch {
+	case err == helpers.ErrSocialEmailMissing:
+		return errors.ValidationError(c, "The provider did not share an email address")
+	case err == helpers.ErrSocialEmailUnverified:
+		log.Printf("🔒 Refused unverified %s email %s", provider.Name(), claims.Email)
+		return errors.SendError(c, fiber.StatusForbidden, errors.NewAPIError(
+			errors.ErrCodeEmailNotVerified,
+			"The provider has not verified this email address",
+			"Verify the address at the provider, or sign up or log in with a password",
+		))
+	case err == helpers.ErrSocialProfileUnverified:
+		log.Printf("🔒 Refused to link %s account %s to unverified user %s", provider.Name(), claims.Subject, claims.Email)
+		return errors.SendError(c, fiber.StatusForbidden, errors.NewAPIError(
+			errors.ErrCodeEmailNotVerified,
+			"An account with this email address exists but has not verified it",
+			"Log in with your password and verify your email address, then sign in with the provider",
+		))
+	case err != nil:
+		log.Printf("❌ Failed to resolve %s account %s: %v", provider.Name(), claims.Subject, err)
+		return errors.InternalError(c, "Failed to complete login")
+	}
+
+	// Refuse unverified accounts when verification is required
+	if verification.Required {
+		verified, err := verifications.IsEmailVerified(ctx, userID)
+		if err != nil {
+			log.Printf("❌ Failed to check email verification for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to verify account status")
+		}
+		if !verified {
+			log.Printf("🔒 Social login refused for unverified user %s", userID)
+			return errors.SendError(c, fiber.StatusForbidden, errors.NewAPIError(
+				errors.ErrCodeEmailNotVerified,
+				"Email address has not been verified",
+				"",
+			))
+		}
+	}
+
+	// Users with MFA enabled get a challenge to complete at /login/mfa instead of tokens
+	mfaEnabled, err := helpers.IsMFAEnabled(ctx, mfa, userID)
+	if err != nil {
+		log.Printf("❌ Failed to check mfa for user %s: %v", userID, err)
+		return errors.InternalError(c, "Failed to verify account status")
+	}
+	if mfaEnabled {
+		challenge, err := helpers.CreateMFAChallenge(ctx, tokens, mfaConfig, userID)
+		if err != nil {
+			log.Printf("❌ Failed to create mfa challenge for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to start two-factor authentication")
+		}
+		log.Printf("🔒 MFA challenge issued to user %s", userID)
+		return c.JSON(presenter.MFAChallengeRequiredResponse(challenge, int(mfaConfig.ChallengeTTL.Seconds())))
+	}
+
+	// Get device type from middleware
+	deviceType := middleware.GetDeviceType(c)
+
+	// Open a session for this device and issue its token pair
+	tokenPair, err := issuer.IssueSession(ctx, helpers.SessionRequest{
+		UserID:      userID,
+		DeviceType:  string(deviceType),
+		UserAgent:   middleware.GetUserAgentHeaders(c),
+		IPAddress:   c.IP(),
+		AuthMethods: claims.AuthMethods,
+	})
+	if err != nil {
+		log.Printf("❌ Failed to open session for user %s on device %s: %v", userID, deviceType, err)
+		return errors.InternalError(c, "Failed to create session")
+	}
+
+	log.Printf("🚀 User %s logged in with %s from %s device", userID, provider.Name(), deviceType)
+	return c.JSON(presenter.SignInSuccessResponse(*tokenPair))
+}
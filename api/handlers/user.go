@@ -2,11 +2,16 @@ package handlers
 
 import (
 	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
 	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"log"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
 	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/manager"
 )
 
 func GetProfileHandler(queries *generated.Queries) fiber.Handler {
@@ -29,3 +34,140 @@ func GetProfileHandler(queries *generated.Queries) fiber.Handler {
 		return c.JSON(presenter.UserProfileFetchResponse(userProfile))
 	}
 }
+
+// LogoutHandler revokes the session key the current access token was signed with,
+// which invalidates both the access and refresh token of this device at once
+func LogoutHandler(jwkManager manager.JwkManager, sessions repository.SessionRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID and key ID from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		keyID, ok := c.Locals("key_id").(string)
+		if !ok || keyID == "" {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		deviceType := middleware.GetDeviceType(c)
+
+		// Delete the session key for this device; a key already replaced counts as deleted
+		if err := helpers.DeleteSessionKey(jwkManager, userUuidID, keyID); err != nil {
+			log.Printf("❌ Failed to revoke session key %s for user %s on device %s: %v", keyID, userID, deviceType, err)
+			return errors.InternalError(c, "Failed to revoke session")
+		}
+		if err := sessions.DeleteSessionByKeyID(ctx, keyID); err != nil {
+			log.Printf("❌ Failed to delete session record for key %s: %v", keyID, err)
+			return errors.InternalError(c, "Failed to revoke session")
+		}
+
+		log.Printf("🔒 User %s logged out from %s device", userID, deviceType)
+		return c.JSON(presenter.LogoutSuccessResponse(1))
+	}
+}
+
+// LogoutAllHandler revokes every session key the user has across all device types
+func LogoutAllHandler(jwkManager manager.JwkManager, sessions repository.SessionRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Delete every session key and its record
+		revoked, err := helpers.RevokeAllSessions(ctx, jwkManager, sessions, userUuidID, "")
+		if err != nil {
+			log.Printf("❌ Failed to revoke sessions for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to revoke sessions")
+		}
+
+		log.Printf("🔒 User %s logged out from all %d devices", userID, revoked)
+		return c.JSON(presenter.LogoutSuccessResponse(revoked))
+	}
+}
+
+// ListSessionsHandler returns every live session of the current user
+func ListSessionsHandler(jwkManager manager.JwkManager, sessions repository.SessionRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		currentKeyID, _ := c.Locals("key_id").(string)
+
+		// Session keys are the source of truth for which sessions are still live
+		keyIDs, err := jwkManager.GetSessionKeys(userID)
+		if err != nil {
+			log.Printf("❌ Failed to fetch session keys for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to fetch sessions")
+		}
+		liveKeys := make(map[string]bool, len(keyIDs))
+		for _, keyID := range keyIDs {
+			liveKeys[keyID] = true
+		}
+
+		records, err := sessions.ListUserSessions(ctx, userUuidID)
+		if err != nil {
+			log.Printf("❌ Failed to fetch session records for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to fetch sessions")
+		}
+
+		var liveSessions []*repository.Session
+		for _, session := range records {
+			if liveKeys[session.KeyID] {
+				liveSessions = append(liveSessions, session)
+			}
+		}
+
+		return c.JSON(presenter.SessionListResponse(liveSessions, currentKeyID))
+	}
+}
+
+// RevokeSessionHandler revokes a single session of the current user by session ID
+func RevokeSessionHandler(jwkManager manager.JwkManager, sessions repository.SessionRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user ID from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		sessionID, err := uuid.Parse(c.Params("id"))
+		if err != nil {
+			return errors.ValidationError(c, "Invalid session ID")
+		}
+
+		// Only allow revoking sessions owned by the current user
+		session, err := sessions.GetSession(ctx, sessionID)
+		if err != nil || session.UserID != userUuidID {
+			return errors.NotFoundError(c, "Session not found")
+		}
+
+		// Delete the session key, then its record
+		if err := helpers.DeleteSessionKey(jwkManager, userUuidID, session.KeyID); err != nil {
+			log.Printf("❌ Failed to revoke session %s for user %s: %v", sessionID.String(), userID, err)
+			return errors.InternalError(c, "Failed to revoke session")
+		}
+		if err := sessions.DeleteSession(ctx, sessionID); err != nil {
+			log.Printf("❌ Failed to delete session %s for user %s: %v", sessionID.String(), userID, err)
+			return errors.InternalError(c, "Failed to revoke session")
+		}
+
+		log.Printf("🔒 User %s revoked session %s on %s device", userID, sessionID.String(), session.DeviceType)
+		return c.JSON(presenter.SessionRevokedResponse(sessionID.String()))
+	}
+}
@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"bytes"
+	"fiber-api/api/errors"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/models"
+	"fiber-api/api/presenter"
+	"fiber-api/api/repository"
+	"fiber-api/api/validators"
+	"fiber-api/config"
+	"fiber-api/pkg/webauthn"
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/sushan531/auth-sqlc/generated"
+)
+
+// BeginWebAuthnRegistrationHandler issues the options for registering a new passkey
+// for the logged-in user
+func BeginWebAuthnRegistrationHandler(queries *generated.Queries, rp *webauthn.RelyingParty, tokens repository.OneTimeTokenRepository, credentials repository.WebAuthnCredentialRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+		profile, err := queries.GetUserProfile(ctx, userUuidID)
+		if err != nil {
+			log.Printf("❌ Failed to fetch profile %s for passkey registration: %v", userID, err)
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Keep authenticators from registering a second credential for the same account
+		existing, err := credentials.ListUserCredentials(ctx, userUuidID)
+		if err != nil {
+			log.Printf("❌ Failed to list passkeys for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to start passkey registration")
+		}
+		exclude := make([]webauthn.CredentialDescriptor, 0, len(existing))
+		for _, credential := range existing {
+			exclude = append(exclude, webauthn.NewCredentialDescriptor(credential.CredentialID, credential.Transports))
+		}
+
+		challenge, err := helpers.CreateWebAuthnChallenge(ctx, tokens, rp, userUuidID, repository.TokenPurposeWebAuthnRegister)
+		if err != nil {
+			log.Printf("❌ Failed to create passkey registration challenge for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to start passkey registration")
+		}
+
+		// The user handle is the raw profile UUID, so no personal data is stored on the authenticator
+		options := rp.NewCreationOptions(challenge, userUuidID[:], profile.UserEmail, profile.FullName, exclude)
+		return c.JSON(presenter.WebAuthnCreationOptionsResponse(options))
+	}
+}
+
+// FinishWebAuthnRegistrationHandler verifies the authenticator's attestation and stores the passkey
+func FinishWebAuthnRegistrationHandler(rp *webauthn.RelyingParty, tokens repository.OneTimeTokenRepository, credentials repository.WebAuthnCredentialRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Extract user from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		// Parse request body
+		var input models.WebAuthnRegistration
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateWebAuthnRegistration(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		// The challenge identifies the ceremony; it must have been issued to this user
+		challenge, err := webauthn.ChallengeOf(input.Credential.Response.ClientDataJSON)
+		if err != nil {
+			return errors.ValidationError(c, "Invalid client data")
+		}
+		ceremony, err := tokens.ConsumeToken(ctx, helpers.HashToken(challenge), repository.TokenPurposeWebAuthnRegister)
+		if err == repository.ErrNotFound || (err == nil && ceremony.UserID != userUuidID) {
+			return errors.AuthenticationError(c, "Invalid or expired registration challenge")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to consume passkey registration challenge for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to register passkey")
+		}
+
+		verified, err := rp.VerifyRegistration(input.Credential, challenge)
+		if err != nil {
+			log.Printf("🔒 Passkey registration rejected for user %s: %v", userID, err)
+			return errors.ValidationError(c, "Passkey registration could not be verified")
+		}
+
+		credential := &repository.WebAuthnCredential{
+			CredentialID:    webauthn.Encoding.EncodeToString(verified.ID),
+			UserID:          userUuidID,
+			Name:            input.Name,
+			PublicKey:       verified.PublicKey,
+			SignCount:       int64(verified.SignCount),
+			AAGUID:          verified.AAGUID,
+			AttestationType: verified.AttestationType,
+			Transports:      verified.Transports,
+		}
+		err = credentials.CreateCredential(ctx, credential)
+		if err == repository.ErrDuplicate {
+			return errors.SendError(c, fiber.StatusConflict, errors.NewAPIError(
+				errors.ErrCodeDuplicate,
+				"Passkey is already registered",
+				"",
+			))
+		}
+		if err != nil {
+			log.Printf("❌ Failed to store passkey for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to register passkey")
+		}
+
+		log.Printf("🔒 Passkey registered for user %s with %s attestation", userID, verified.AttestationType)
+		return c.Status(fiber.StatusCreated).JSON(presenter.WebAuthnRegisteredResponse(credential))
+	}
+}
+
+// BeginWebAuthnLoginHandler issues the options for a passkey login. With an email the
+// user's passkeys are listed; without one, or for an unknown email, the authenticator
+// picks a discoverable credential, so the response does not reveal registered emails.
+func BeginWebAuthnLoginHandler(queries *generated.Queries, rp *webauthn.RelyingParty, tokens repository.OneTimeTokenRepository, credentials repository.WebAuthnCredentialRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse request body; an empty body starts a discoverable credential login
+		var input models.WebAuthnLoginBegin
+		if len(c.Body()) > 0 {
+			if err := c.BodyParser(&input); err != nil {
+				return errors.ValidationError(c, "Invalid request payload")
+			}
+		}
+
+		// Validate input
+		validation := validators.ValidateWebAuthnLoginBegin(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		userID := uuid.Nil
+		var allow []webauthn.CredentialDescriptor
+		if input.UserEmail != "" {
+			if auth, err := queries.GetUserAuth(ctx, input.UserEmail); err == nil {
+				existing, err := credentials.ListUserCredentials(ctx, auth.UserProfileID)
+				if err != nil {
+					log.Printf("❌ Failed to list passkeys for user %s: %v", input.UserEmail, err)
+					return errors.InternalError(c, "Failed to start passkey login")
+				}
+				if len(existing) > 0 {
+					userID = auth.UserProfileID
+					for _, credential := range existing {
+						allow = append(allow, webauthn.NewCredentialDescriptor(credential.CredentialID, credential.Transports))
+					}
+				}
+			}
+		}
+
+		challenge, err := helpers.CreateWebAuthnChallenge(ctx, tokens, rp, userID, repository.TokenPurposeWebAuthnLogin)
+		if err != nil {
+			log.Printf("❌ Failed to create passkey login challenge: %v", err)
+			return errors.InternalError(c, "Failed to start passkey login")
+		}
+
+		return c.JSON(presenter.WebAuthnRequestOptionsResponse(rp.NewRequestOptions(challenge, allow)))
+	}
+}
+
+// FinishWebAuthnLoginHandler verifies a passkey assertion and opens a session the same way
+// a password login does
+func FinishWebAuthnLoginHandler(issuer *helpers.SessionIssuer, rp *webauthn.RelyingParty, tokens repository.OneTimeTokenRepository, credentials repository.WebAuthnCredentialRepository, verifications repository.EmailVerificationRepository, verification config.EmailVerificationConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := c.Context()
+
+		// Parse request body
+		var input models.WebAuthnLogin
+		if err := c.BodyParser(&input); err != nil {
+			return errors.ValidationError(c, "Invalid request payload")
+		}
+
+		// Validate input
+		validation := validators.ValidateWebAuthnLogin(input)
+		if !validation.IsValid {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"success": false,
+				"error": fiber.Map{
+					"code":    "VALIDATION_ERROR",
+					"message": "Validation failed",
+					"details": validation.Errors,
+				},
+			})
+		}
+
+		// The challenge identifies the ceremony and can be answered only once
+		challenge, err := webauthn.ChallengeOf(input.Credential.Response.ClientDataJSON)
+		if err != nil {
+			return errors.ValidationError(c, "Invalid client data")
+		}
+		ceremony, err := tokens.ConsumeToken(ctx, helpers.HashToken(challenge), repository.TokenPurposeWebAuthnLogin)
+		if err == repository.ErrNotFound {
+			return errors.AuthenticationError(c, "Invalid or expired login challenge")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to consume passkey login challenge: %v", err)
+			return errors.InternalError(c, "Failed to verify passkey")
+		}
+
+		credential, err := credentials.GetCredential(ctx, input.Credential.RawID)
+		if err == repository.ErrNotFound {
+			log.Printf("🔒 Passkey login with unknown credential")
+			return errors.AuthenticationError(c, "Invalid passkey")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to fetch passkey: %v", err)
+			return errors.InternalError(c, "Failed to verify passkey")
+		}
+
+		// A challenge issued for a named user only accepts that user's passkeys,
+		// and a returned user handle must match the credential owner
+		if ceremony.UserID != uuid.Nil && ceremony.UserID != credential.UserID {
+			log.Printf("🔒 Passkey of user %s used for a challenge issued to user %s", credential.UserID, ceremony.UserID)
+			return errors.AuthenticationError(c, "Invalid passkey")
+		}
+		if input.Credential.Response.UserHandle != "" {
+			userHandle, err := webauthn.Encoding.DecodeString(input.Credential.Response.UserHandle)
+			if err != nil || !bytes.Equal(userHandle, credential.UserID[:]) {
+				log.Printf("🔒 Passkey user handle does not match owner %s", credential.UserID)
+				return errors.AuthenticationError(c, "Invalid passkey")
+			}
+		}
+
+		assertion, err := rp.VerifyAuthentication(input.Credential, challenge, credential.PublicKey, uint32(credential.SignCount))
+		if err == webauthn.ErrSignCount {
+			log.Printf("🔒 Passkey %s of user %s reported a non-increasing sign count, possible cloned authenticator", credential.CredentialID, credential.UserID)
+			return errors.AuthenticationError(c, "Invalid passkey")
+		}
+		if err != nil {
+			log.Printf("🔒 Passkey login rejected for user %s: %v", credential.UserID, err)
+			return errors.AuthenticationError(c, "Invalid passkey")
+		}
+		if err := credentials.UpdateSignCount(ctx, credential.CredentialID, credential.SignCount, int64(assertion.SignCount)); err != nil {
+			if err == repository.ErrAlreadyUsed {
+				log.Printf("🔒 Concurrent passkey login for user %s rejected", credential.UserID)
+				return errors.AuthenticationError(c, "Invalid passkey")
+			}
+			log.Printf("❌ Failed to update passkey sign count for user %s: %v", credential.UserID, err)
+			return errors.InternalError(c, "Failed to verify passkey")
+		}
+
+		// Refuse unverified accounts when verification is required
+		if verification.Required {
+			verified, err := verifications.IsEmailVerified(ctx, credential.UserID)
+			if err != nil {
+				log.Printf("❌ Failed to check email verification for user %s: %v", credential.UserID, err)
+				return errors.InternalError(c, "Failed to verify account status")
+			}
+			if !verified {
+				log.Printf("🔒 Passkey login refused for unverified user %s", credential.UserID)
+				return errors.SendError(c, fiber.StatusForbidden, errors.NewAPIError(
+					errors.ErrCodeEmailNotVerified,
+					"Email address has not been verified",
+					"",
+				))
+			}
+		}
+
+		// Get device type from middleware
+		deviceType := middleware.GetDeviceType(c)
+
+		// Open a session for this device and issue its token pair
+		tokenPair, err := issuer.IssueSession(ctx, helpers.SessionRequest{
+			UserID:      credential.UserID,
+			DeviceType:  string(deviceType),
+			UserAgent:   middleware.GetUserAgentHeaders(c),
+			IPAddress:   c.IP(),
+			AuthMethods: []string{models.AMRHardwareKey},
+		})
+		if err != nil {
+			log.Printf("❌ Failed to open session for user %s on device %s: %v", credential.UserID, deviceType, err)
+			return errors.InternalError(c, "Failed to create session")
+		}
+
+		log.Printf("🚀 User %s logged in with a passkey from %s device", credential.UserID, deviceType)
+		return c.JSON(presenter.SignInSuccessResponse(*tokenPair))
+	}
+}
+
+// ListWebAuthnCredentialsHandler returns the passkeys of the logged-in user
+func ListWebAuthnCredentialsHandler(credentials repository.WebAuthnCredentialRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Extract user from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		list, err := credentials.ListUserCredentials(c.Context(), userUuidID)
+		if err != nil {
+			log.Printf("❌ Failed to list passkeys for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to fetch passkeys")
+		}
+
+		return c.JSON(presenter.WebAuthnCredentialListResponse(list))
+	}
+}
+
+// DeleteWebAuthnCredentialHandler removes a passkey of the logged-in user
+func DeleteWebAuthnCredentialHandler(credentials repository.WebAuthnCredentialRepository) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		// Extract user from JWT claims
+		userID, ok := c.Locals("user_id").(string)
+		userUuidID, err := uuid.Parse(userID)
+		if !ok || err != nil {
+			return errors.AuthenticationError(c, "Invalid user session")
+		}
+
+		err = credentials.DeleteCredential(c.Context(), userUuidID, c.Params("id"))
+		if err == repository.ErrNotFound {
+			return errors.NotFoundError(c, "Passkey not found")
+		}
+		if err != nil {
+			log.Printf("❌ Failed to delete passkey for user %s: %v", userID, err)
+			return errors.InternalError(c, "Failed to remove passkey")
+		}
+
+		log.Printf("🔒 Passkey removed for user %s", userID)
+		return c.JSON(presenter.WebAuthnCredentialDeletedResponse())
+	}
+}
@@ -1,10 +1,13 @@
 package middleware
 
 import (
+	"fiber-api/pkg/nativeapp"
+	"fiber-api/pkg/useragent"
+	"log"
 	"strings"
+	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/ua-parser/uap-go/uaparser"
 )
 
 // DeviceType represents the type of device making the request
@@ -14,24 +17,54 @@ const (
 	DeviceTypeWeb     DeviceType = "web"
 	DeviceTypeAndroid DeviceType = "android"
 	DeviceTypeIOS     DeviceType = "ios"
+	// DeviceTypeCLI is never detected from a request; it marks sessions opened through
+	// the device authorization grant by CLI tools and other input-constrained devices
+	DeviceTypeCLI DeviceType = "cli"
 )
 
-// DeviceDetectionMiddleware parses User-Agent to determine device type
+// DeviceDetectionMiddleware parses User-Agent and its Client Hints to determine device type
 // Web includes browsers on any platform (desktop, mobile web browsers)
-// Android/iOS only for native apps, not mobile browsers
-func DeviceDetectionMiddleware() fiber.Handler {
-	parser := uaparser.NewFromSaved()
-
+// Android/iOS only for native apps that sign their requests with a registered app key;
+// other clients that look like native apps are handled by the configured fallback
+func DeviceDetectionMiddleware(userAgents *useragent.Parser, apps *nativeapp.Verifier) fiber.Handler {
 	return func(c *fiber.Ctx) error {
-		userAgent := c.Get("User-Agent")
-		if userAgent == "" {
+		// Ask Chromium browsers for the hints their frozen User-Agent no longer carries
+		c.Set("Accept-CH", useragent.AcceptCH)
+
+		// Native apps identify themselves with signed app headers
+		appRequest := getAppRequest(c)
+		if appRequest.Present() {
+			identity, err := apps.Verify(appRequest, time.Now())
+			if err == nil {
+				c.Locals("device_type", nativeDeviceType(identity.Platform))
+				c.Locals("app_id", identity.AppID)
+				c.Locals("app_version", identity.Version)
+				c.Locals("user_agent", c.Get("User-Agent"))
+				return c.Next()
+			}
+			log.Printf("🔒 Unverified native app request from %s: %v", c.IP(), err)
+		}
+
+		headers := GetUserAgentHeaders(c)
+		userAgent := headers.UserAgent
+		if userAgent == "" && !headers.HasHints() && !appRequest.Present() {
 			// Default to web if no User-Agent
 			c.Locals("device_type", DeviceTypeWeb)
 			return c.Next()
 		}
 
-		client := parser.Parse(userAgent)
-		deviceType := determineDeviceType(client, userAgent)
+		device := userAgents.Detect(headers)
+		deviceType := determineDeviceType(device, userAgent)
+
+		// Without a verified signature a native app is only a guess from the User-Agent
+		if deviceType != DeviceTypeWeb || appRequest.Present() {
+			switch apps.Fallback() {
+			case nativeapp.FallbackReject:
+				return c.Status(401).JSON(fiber.Map{"error": "Unverified native app"})
+			case nativeapp.FallbackWeb:
+				deviceType = DeviceTypeWeb
+			}
+		}
 
 		// Store device information in context
 		c.Locals("device_type", deviceType)
@@ -40,10 +73,38 @@ func DeviceDetectionMiddleware() fiber.Handler {
 	}
 }
 
-// determineDeviceType analyzes parsed user agent to determine device type
-func determineDeviceType(client *uaparser.Client, userAgent string) DeviceType {
-	osFamily := strings.ToLower(client.Os.Family)
-	browserFamily := strings.ToLower(client.UserAgent.Family)
+// nativeDeviceType returns the device type of a verified native app platform
+func nativeDeviceType(platform string) DeviceType {
+	if platform == nativeapp.PlatformIOS {
+		return DeviceTypeIOS
+	}
+	return DeviceTypeAndroid
+}
+
+// getAppRequest collects the signed app headers and the request line and body they cover
+func getAppRequest(c *fiber.Ctx) nativeapp.Request {
+	return nativeapp.Request{
+		AppID:     c.Get(nativeapp.HeaderAppID),
+		Version:   c.Get(nativeapp.HeaderVersion),
+		Platform:  c.Get(nativeapp.HeaderPlatform),
+		Timestamp: c.Get(nativeapp.HeaderTimestamp),
+		Nonce:     c.Get(nativeapp.HeaderNonce),
+		Signature: c.Get(nativeapp.HeaderSignature),
+		Method:    c.Method(),
+		Path:      c.Path(),
+		Body:      c.Body(),
+	}
+}
+
+// determineDeviceType guesses the device type from the detected device
+func determineDeviceType(device *useragent.Device, userAgent string) DeviceType {
+	// Only browsers send Client Hints
+	if device.FromHints {
+		return DeviceTypeWeb
+	}
+
+	osFamily := strings.ToLower(device.Platform)
+	browserFamily := strings.ToLower(device.Browser)
 	userAgentLower := strings.ToLower(userAgent)
 
 	// Check for native Android apps
@@ -118,3 +179,14 @@ func GetDeviceType(c *fiber.Ctx) DeviceType {
 	}
 	return DeviceTypeWeb // Default fallback
 }
+
+// GetUserAgentHeaders collects the User-Agent and Client Hint headers of a request
+func GetUserAgentHeaders(c *fiber.Ctx) useragent.Headers {
+	return useragent.Headers{
+		UserAgent:       c.Get("User-Agent"),
+		UA:              c.Get(useragent.HeaderUA),
+		Platform:        c.Get(useragent.HeaderPlatform),
+		Mobile:          c.Get(useragent.HeaderMobile),
+		FullVersionList: c.Get(useragent.HeaderFullVersionList),
+	}
+}
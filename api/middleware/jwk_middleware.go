@@ -1,17 +1,27 @@
 package middleware
 
 import (
-	"crypto/sha256"
-	"fmt"
+	"fiber-api/pkg/fingerprint"
 	"strings"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/sushan531/jwk-auth/service"
-	"github.com/ua-parser/uap-go/uaparser"
 )
 
-// JWT middleware for protecting routes
-func JWTMiddleware(tokenService service.TokenService) fiber.Handler {
+// JWT middleware for protecting first-party routes. Access tokens issued to OAuth
+// clients are refused: a client acts within its granted scopes, not as the user.
+func JWTMiddleware(tokenService service.TokenService, fingerprints *fingerprint.Generator) fiber.Handler {
+	return bearerMiddleware(tokenService, fingerprints, false)
+}
+
+// OAuthTokenMiddleware protects the endpoints OAuth clients call for a user, such as
+// userinfo. It accepts client access tokens as well; handlers check their scopes.
+func OAuthTokenMiddleware(tokenService service.TokenService, fingerprints *fingerprint.Generator) fiber.Handler {
+	return bearerMiddleware(tokenService, fingerprints, true)
+}
+
+// bearerMiddleware verifies the bearer token and its device fingerprint
+func bearerMiddleware(tokenService service.TokenService, fingerprints *fingerprint.Generator, allowClientTokens bool) fiber.Handler {
 	return func(c *fiber.Ctx) error {
 		// Extract token from Authorization header
 		authHeader := c.Get("Authorization")
@@ -32,22 +42,25 @@ func JWTMiddleware(tokenService service.TokenService) fiber.Handler {
 		if err != nil {
 			return c.Status(401).JSON(fiber.Map{"error": "Invalid or expired token"})
 		}
+		if _, isClientToken := claims["client_id"]; isClientToken && !allowClientTokens {
+			return c.Status(403).JSON(fiber.Map{"error": "OAuth client tokens cannot access this API"})
+		}
 
 		// Validate device fingerprint
-		storedFingerprint, hasFingerprintClaim := claims["device_fingerprint"].(string)
-		if !hasFingerprintClaim || storedFingerprint == "" {
+		storedFingerprint, hasFingerprintClaim := fingerprint.FromClaims(claims)
+		if !hasFingerprintClaim || storedFingerprint.Hash == "" {
 			return c.Status(401).JSON(fiber.Map{"error": "Invalid token: missing device fingerprint"})
 		}
 
 		// Get current User-Agent from request and validate against stored fingerprint
-		currentUserAgent := c.Get("User-Agent")
-		if currentUserAgent == "" {
+		currentDevice := GetUserAgentHeaders(c)
+		if currentDevice.UserAgent == "" {
 			return c.Status(401).JSON(fiber.Map{"error": "Missing User-Agent header"})
 		}
 
-		// Generate fingerprint from current User-Agent and compare
-		currentFingerprint := generateDeviceFingerprintHash(currentUserAgent)
-		if currentFingerprint != storedFingerprint {
+		// Fingerprint the current User-Agent with the token's algorithm and compare.
+		// An updated browser passes in compatibility mode until the next refresh re-issues the token.
+		if fingerprints.Compare(currentDevice, storedFingerprint) == fingerprint.Mismatch {
 			return c.Status(401).JSON(fiber.Map{"error": "Device fingerprint mismatch."})
 		}
 
@@ -55,43 +68,9 @@ func JWTMiddleware(tokenService service.TokenService) fiber.Handler {
 		c.Locals("claims", claims)
 		c.Locals("user_id", claims["user_id"])
 		c.Locals("user_email", claims["user_email"])
+		c.Locals("role", claims["role"])
+		c.Locals("key_id", claims["kid"])
 
 		return c.Next()
 	}
 }
-
-// generateDeviceFingerprintHash creates a device fingerprint hash from User-Agent
-// This is a simplified version for middleware use
-func generateDeviceFingerprintHash(userAgent string) string {
-	if userAgent == "" {
-		return generateHash("unknown")
-	}
-
-	parser := uaparser.NewFromSaved()
-	client := parser.Parse(userAgent)
-
-	// Extract key components for fingerprinting
-	platform := normalizeString(client.Os.Family)
-	browser := normalizeString(client.UserAgent.Family)
-	version := normalizeString(client.UserAgent.Major)
-
-	// Create a composite string for hashing
-	composite := fmt.Sprintf("%s|%s|%s", platform, browser, version)
-
-	// Generate SHA256 hash of the composite string
-	return generateHash(composite)
-}
-
-// generateHash creates a SHA256 hash of the input string
-func generateHash(input string) string {
-	hash := sha256.Sum256([]byte(input))
-	return fmt.Sprintf("%x", hash)
-}
-
-// normalizeString normalizes strings for consistent fingerprinting
-func normalizeString(s string) string {
-	if s == "" {
-		return "unknown"
-	}
-	return strings.ToLower(strings.TrimSpace(s))
-}
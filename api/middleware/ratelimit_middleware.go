@@ -0,0 +1,123 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fiber-api/api/errors"
+	"fiber-api/config"
+	"fiber-api/pkg/ratelimit"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RateLimiter applies the configured per-route limits using a shared bucket store
+type RateLimiter struct {
+	store  ratelimit.Store
+	config config.RateLimitConfig
+}
+
+// NewRateLimiter creates a rate limiter; a nil store uses an in-memory store
+func NewRateLimiter(store ratelimit.Store, cfg config.RateLimitConfig) *RateLimiter {
+	if store == nil {
+		store = ratelimit.NewMemoryStore()
+	}
+	return &RateLimiter{store: store, config: cfg}
+}
+
+// rateLimitCheck is one bucket a request is counted against
+type rateLimitCheck struct {
+	key   string
+	limit ratelimit.Limit
+}
+
+// For returns middleware enforcing the limits of a route. A request is counted against its
+// IP, the user_email of its JSON body and the authenticated user ID, for each limit set.
+// The per-user limit needs JWTMiddleware to run first.
+func (rl *RateLimiter) For(route string) fiber.Handler {
+	limits := rl.config.For(route)
+	if !limits.IP.Enabled() && !limits.Email.Enabled() && !limits.User.Enabled() {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+
+	return func(c *fiber.Ctx) error {
+		var checks []rateLimitCheck
+		if limits.IP.Enabled() {
+			checks = append(checks, rateLimitCheck{key: route + ":ip:" + c.IP(), limit: limits.IP})
+		}
+		if limits.Email.Enabled() {
+			if email := requestEmail(c); email != "" {
+				checks = append(checks, rateLimitCheck{key: route + ":email:" + email, limit: limits.Email})
+			}
+		}
+		if limits.User.Enabled() {
+			if userID, ok := c.Locals("user_id").(string); ok && userID != "" {
+				checks = append(checks, rateLimitCheck{key: route + ":user:" + userID, limit: limits.User})
+			}
+		}
+
+		// Report the most restrictive bucket; any exhausted bucket rejects the request
+		var reported *ratelimit.Result
+		for _, check := range checks {
+			result, err := rl.store.Take(c.Context(), check.key, check.limit)
+			if err != nil {
+				// Fail open: an unavailable store must not take authentication down
+				log.Printf("❌ Rate limit store error for %s: %v", check.key, err)
+				continue
+			}
+			if reported == nil || moreRestrictive(result, *reported) {
+				r := result
+				reported = &r
+			}
+		}
+		if reported == nil {
+			return c.Next()
+		}
+
+		setRateLimitHeaders(c, *reported)
+		if !reported.Allowed {
+			c.Set(fiber.HeaderRetryAfter, strconv.Itoa(ceilSeconds(reported.RetryAfter)))
+			log.Printf("🔒 Rate limit exceeded on %s from %s", route, c.IP())
+			return errors.RateLimitError(c, "Too many requests. Try again later")
+		}
+		return c.Next()
+	}
+}
+
+// moreRestrictive reports whether a should be reported instead of b
+func moreRestrictive(a, b ratelimit.Result) bool {
+	if a.Allowed != b.Allowed {
+		return !a.Allowed
+	}
+	if !a.Allowed {
+		return a.RetryAfter > b.RetryAfter
+	}
+	return a.Remaining < b.Remaining
+}
+
+// setRateLimitHeaders sets the RateLimit-Limit, RateLimit-Remaining and RateLimit-Reset headers
+func setRateLimitHeaders(c *fiber.Ctx, result ratelimit.Result) {
+	c.Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	c.Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	c.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(result.Reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
+
+// requestEmail returns the lowercased user_email of a JSON request body, if any
+func requestEmail(c *fiber.Ctx) string {
+	var body struct {
+		UserEmail string `json:"user_email"`
+	}
+	if err := json.Unmarshal(c.Body(), &body); err != nil {
+		return ""
+	}
+	return strings.ToLower(strings.TrimSpace(body.UserEmail))
+}
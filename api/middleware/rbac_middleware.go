@@ -0,0 +1,49 @@
+package middleware
+
+import (
+	"fiber-api/api/errors"
+	"fiber-api/config"
+	"log"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequireRole allows the request only when the token's role is one of the given roles.
+// Must run after JWTMiddleware.
+func RequireRole(roles ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role := GetRole(c)
+		for _, allowed := range roles {
+			if strings.EqualFold(role, allowed) {
+				return c.Next()
+			}
+		}
+
+		log.Printf("🔒 Role %q denied access to %s %s", role, c.Method(), c.Path())
+		return errors.AuthorizationError(c, "Insufficient role for this resource")
+	}
+}
+
+// RequirePermission allows the request only when the token's role grants every given permission.
+// Must run after JWTMiddleware.
+func RequirePermission(rbac config.RBACConfig, permissions ...string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		role := GetRole(c)
+		for _, permission := range permissions {
+			if !rbac.HasPermission(role, permission) {
+				log.Printf("🔒 Role %q lacks permission %q for %s %s", role, permission, c.Method(), c.Path())
+				return errors.AuthorizationError(c, "Insufficient permissions for this resource")
+			}
+		}
+		return c.Next()
+	}
+}
+
+// GetRole is a helper function to extract the role claim from Fiber context
+func GetRole(c *fiber.Ctx) string {
+	if role, ok := c.Locals("role").(string); ok {
+		return role
+	}
+	return ""
+}
@@ -1,5 +1,18 @@
 package models
 
+// User roles stored in the profile's user_role column
+const (
+	RoleAdmin     = "admin"
+	RoleModerator = "moderator"
+	RoleUser      = "user"
+)
+
+// DefaultRole is assigned to profiles without a stored role
+const DefaultRole = RoleUser
+
+// ValidRoles lists every role a profile may hold
+var ValidRoles = []string{RoleAdmin, RoleUser, RoleModerator}
+
 // SignUp represents the request body for user registration
 type SignUp struct {
 	UserEmail string `json:"user_email" binding:"required"`
@@ -19,16 +32,35 @@ type Login struct {
 type JWTClaims struct {
 	UserID            string `json:"user_id"`
 	UserEmail         string `json:"user_email"`
-	Role              string `json:"role"`
+	Role              string `json:"role,omitempty"`
 	DeviceFingerprint string `json:"device_fingerprint"`
+	// FingerprintAlgorithm is the version of the algorithm DeviceFingerprint was made with
+	FingerprintAlgorithm int `json:"fpv"`
+	// DevicePlatform, DeviceBrowser and DeviceVersion are the parts DeviceFingerprint was made from
+	DevicePlatform string `json:"device_platform"`
+	DeviceBrowser  string `json:"device_browser"`
+	DeviceVersion  string `json:"device_version"`
+	ClientID       string `json:"client_id,omitempty"`
+	Scope          string `json:"scope,omitempty"`
 }
 
 // ToMap converts JWTClaims struct to map[string]interface{} for JWT token generation
 func (j *JWTClaims) ToMap() map[string]interface{} {
-	return map[string]interface{}{
+	claims := map[string]interface{}{
 		"user_id":            j.UserID,
 		"user_email":         j.UserEmail,
-		"role":               j.Role,
 		"device_fingerprint": j.DeviceFingerprint,
+		"fpv":                j.FingerprintAlgorithm,
+		"device_platform":    j.DevicePlatform,
+		"device_browser":     j.DeviceBrowser,
+		"device_version":     j.DeviceVersion,
+	}
+	if j.Role != "" {
+		claims["role"] = j.Role
+	}
+	if j.ClientID != "" {
+		claims["client_id"] = j.ClientID
+		claims["scope"] = j.Scope
 	}
+	return claims
 }
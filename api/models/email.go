@@ -0,0 +1,11 @@
+package models
+
+// VerifyEmail represents the request body for confirming an email address
+type VerifyEmail struct {
+	Token string `json:"token" query:"token" binding:"required"`
+}
+
+// ResendVerification represents the request body for requesting a new verification email
+type ResendVerification struct {
+	UserEmail string `json:"user_email" binding:"required"`
+}
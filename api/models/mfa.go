@@ -0,0 +1,18 @@
+package models
+
+// LoginMFA represents the request body for completing a login with a second factor
+type LoginMFA struct {
+	MFAChallenge string `json:"mfa_challenge" binding:"required"`
+	Code         string `json:"code" binding:"required"`
+}
+
+// ConfirmMFA represents the request body for confirming a TOTP enrollment
+type ConfirmMFA struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// DisableMFA represents the request body for turning off TOTP for a logged-in user
+type DisableMFA struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
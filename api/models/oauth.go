@@ -0,0 +1,34 @@
+package models
+
+// OAuth 2.0 grant types supported by the token endpoint
+const (
+	GrantTypeAuthorizationCode = "authorization_code"
+	GrantTypeRefreshToken      = "refresh_token"
+	GrantTypeClientCredentials = "client_credentials"
+	GrantTypeDeviceCode        = "urn:ietf:params:oauth:grant-type:device_code"
+)
+
+// GrantTypes lists every supported grant type
+var GrantTypes = []string{GrantTypeAuthorizationCode, GrantTypeRefreshToken, GrantTypeClientCredentials, GrantTypeDeviceCode}
+
+const (
+	// ResponseTypeCode is the only supported authorization response type
+	ResponseTypeCode = "code"
+	// CodeChallengeMethodS256 is the only accepted PKCE method
+	CodeChallengeMethodS256 = "S256"
+)
+
+// RegisterOAuthClient represents the request body for registering an OAuth client.
+// A client without grant types can only call the introspection and revocation endpoints.
+type RegisterOAuthClient struct {
+	Name         string   `json:"name" binding:"required"`
+	RedirectURIs []string `json:"redirect_uris"`
+	GrantTypes   []string `json:"grant_types"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+}
+
+// AuthorizationDecision represents the user's answer to an authorization request
+type AuthorizationDecision struct {
+	Approve bool `json:"approve"`
+}
@@ -0,0 +1,44 @@
+package models
+
+// Scopes with a meaning to the server. ScopeOpenID requests an ID token; ScopeProfile
+// and ScopeEmail release the matching ID token and userinfo claims.
+const (
+	ScopeOpenID  = "openid"
+	ScopeProfile = "profile"
+	ScopeEmail   = "email"
+)
+
+// Authentication method references recorded on sessions (RFC 8176)
+const (
+	AMRPassword     = "pwd"
+	AMROneTimeCode  = "otp"
+	AMRMultiFactor  = "mfa"
+	AMRHardwareKey  = "hwk"
+	AMRMultiChannel = "mca"
+)
+
+// UserInfo represents the OpenID Connect claims about a user. Claims outside the
+// granted scopes are left empty and omitted.
+type UserInfo struct {
+	Sub           string `json:"sub"`
+	Email         string `json:"email,omitempty"`
+	EmailVerified *bool  `json:"email_verified,omitempty"`
+	Name          string `json:"name,omitempty"`
+}
+
+// ToMap converts UserInfo to map[string]interface{} for ID token generation
+func (u *UserInfo) ToMap() map[string]interface{} {
+	claims := map[string]interface{}{
+		"sub": u.Sub,
+	}
+	if u.Email != "" {
+		claims["email"] = u.Email
+	}
+	if u.EmailVerified != nil {
+		claims["email_verified"] = *u.EmailVerified
+	}
+	if u.Name != "" {
+		claims["name"] = u.Name
+	}
+	return claims
+}
@@ -0,0 +1,18 @@
+package models
+
+// ForgotPassword represents the request body for starting a password reset
+type ForgotPassword struct {
+	UserEmail string `json:"user_email" binding:"required"`
+}
+
+// ResetPassword represents the request body for completing a password reset
+type ResetPassword struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// ChangePassword represents the request body for changing the password of a logged-in user
+type ChangePassword struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"`
+}
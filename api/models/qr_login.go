@@ -0,0 +1,17 @@
+package models
+
+// QRLoginPoll represents the request body of a browser waiting for its QR login
+type QRLoginPoll struct {
+	PollToken string `json:"poll_token" binding:"required"`
+}
+
+// QRLoginScan represents the request body of the native app after scanning a QR code
+type QRLoginScan struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// QRLoginDecision represents the app user's answer to a scanned QR login
+type QRLoginDecision struct {
+	Code    string `json:"code" binding:"required"`
+	Approve bool   `json:"approve"`
+}
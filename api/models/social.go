@@ -0,0 +1,8 @@
+package models
+
+// SocialLoginFinish represents the request body for finishing a social login with the
+// code and state the provider sent back to the redirect URL
+type SocialLoginFinish struct {
+	Code  string `json:"code" binding:"required"`
+	State string `json:"state" binding:"required"`
+}
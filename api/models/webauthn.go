@@ -0,0 +1,20 @@
+package models
+
+import "fiber-api/pkg/webauthn"
+
+// WebAuthnRegistration represents the request body for finishing a passkey registration
+type WebAuthnRegistration struct {
+	Name       string                          `json:"name"`
+	Credential webauthn.RegistrationCredential `json:"credential" binding:"required"`
+}
+
+// WebAuthnLoginBegin represents the request body for starting a passkey login.
+// Without an email the login uses a discoverable credential.
+type WebAuthnLoginBegin struct {
+	UserEmail string `json:"user_email"`
+}
+
+// WebAuthnLogin represents the request body for finishing a passkey login
+type WebAuthnLogin struct {
+	Credential webauthn.AuthenticationCredential `json:"credential" binding:"required"`
+}
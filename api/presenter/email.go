@@ -0,0 +1,18 @@
+package presenter
+
+// EmailVerifiedResponse creates a standardized email verification success response
+func EmailVerifiedResponse() BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Message: "Email verified successfully",
+	}
+}
+
+// VerificationResentResponse creates the response for a verification resend request.
+// It is identical whether or not an email was actually sent.
+func VerificationResentResponse() BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Message: "If the account exists and is not verified yet, a new verification email has been sent",
+	}
+}
@@ -0,0 +1,66 @@
+package presenter
+
+// MFAChallengeResponse represents the login response data of a user with MFA enabled
+type MFAChallengeResponse struct {
+	MFARequired  bool   `json:"mfa_required"`
+	MFAChallenge string `json:"mfa_challenge"`
+	ExpiresIn    int    `json:"expires_in"`
+}
+
+// MFAEnrollmentResponse represents a new TOTP secret awaiting confirmation
+type MFAEnrollmentResponse struct {
+	Secret     string `json:"secret"`
+	OTPAuthURI string `json:"otpauth_uri"`
+	QRCode     string `json:"qr_code"`
+}
+
+// MFARecoveryCodesResponse represents the recovery codes issued when MFA is enabled
+type MFARecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// MFAChallengeRequiredResponse creates the login response asking for a second factor
+func MFAChallengeRequiredResponse(challenge string, expiresIn int) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: MFAChallengeResponse{
+			MFARequired:  true,
+			MFAChallenge: challenge,
+			ExpiresIn:    expiresIn,
+		},
+		Message: "Two-factor authentication required",
+	}
+}
+
+// MFAEnrollmentSuccessResponse creates a standardized TOTP enrollment response
+func MFAEnrollmentSuccessResponse(secret, uri, qrCode string) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: MFAEnrollmentResponse{
+			Secret:     secret,
+			OTPAuthURI: uri,
+			QRCode:     qrCode,
+		},
+		Message: "Scan the QR code and confirm with a code from your authenticator app",
+	}
+}
+
+// MFAEnabledResponse creates the response for a confirmed enrollment.
+// Recovery codes are only ever shown here.
+func MFAEnabledResponse(recoveryCodes []string) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: MFARecoveryCodesResponse{
+			RecoveryCodes: recoveryCodes,
+		},
+		Message: "Two-factor authentication enabled. Store the recovery codes somewhere safe",
+	}
+}
+
+// MFADisabledResponse creates a standardized MFA disable response
+func MFADisabledResponse() BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Message: "Two-factor authentication disabled",
+	}
+}
@@ -0,0 +1,249 @@
+package presenter
+
+import (
+	"fiber-api/api/repository"
+	"strings"
+	"time"
+
+	"github.com/sushan531/jwk-auth/service"
+)
+
+// TokenIntrospection represents an RFC 7662 introspection response.
+// It is served as is, outside the standard response envelope.
+type TokenIntrospection struct {
+	Active    bool   `json:"active"`
+	Sub       string `json:"sub,omitempty"`
+	Username  string `json:"username,omitempty"`
+	Role      string `json:"role,omitempty"`
+	ClientID  string `json:"client_id,omitempty"`
+	Scope     string `json:"scope,omitempty"`
+	TokenType string `json:"token_type,omitempty"`
+	Exp       int64  `json:"exp,omitempty"`
+	Iat       int64  `json:"iat,omitempty"`
+}
+
+// OAuthClientResponse represents a registered OAuth client
+type OAuthClientResponse struct {
+	ClientID     string   `json:"client_id"`
+	ClientSecret string   `json:"client_secret,omitempty"`
+	Name         string   `json:"name"`
+	RedirectURIs []string `json:"redirect_uris"`
+	GrantTypes   []string `json:"grant_types"`
+	Scopes       []string `json:"scopes"`
+	Public       bool     `json:"public"`
+	CreatedAt    string   `json:"created_at"`
+}
+
+// OAuthToken represents a token endpoint response (RFC 6749 section 5.1).
+// It is served as is, outside the standard response envelope.
+type OAuthToken struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope,omitempty"`
+}
+
+// AuthorizationRequestResponse represents a pending authorization request shown on the consent page
+type AuthorizationRequestResponse struct {
+	RequestID   string   `json:"request_id"`
+	ClientID    string   `json:"client_id"`
+	ClientName  string   `json:"client_name"`
+	RedirectURI string   `json:"redirect_uri"`
+	Scopes      []string `json:"scopes"`
+	ExpiresAt   string   `json:"expires_at"`
+}
+
+// AuthorizationDecision represents the outcome of the consent step
+type AuthorizationDecision struct {
+	RedirectTo string `json:"redirect_to"`
+}
+
+// DeviceAuthorization represents a device authorization response (RFC 8628 section 3.2).
+// It is served as is, outside the standard response envelope.
+type DeviceAuthorization struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete"`
+	ExpiresIn               int64  `json:"expires_in"`
+	Interval                int64  `json:"interval"`
+}
+
+// DeviceAuthorizationRequestResponse represents a pending device authorization request
+// shown to the user who entered its user code
+type DeviceAuthorizationRequestResponse struct {
+	UserCode   string   `json:"user_code"`
+	ClientID   string   `json:"client_id"`
+	ClientName string   `json:"client_name"`
+	Scopes     []string `json:"scopes"`
+	ExpiresAt  string   `json:"expires_at"`
+}
+
+// InactiveTokenResponse creates the introspection response of an inactive or unknown token
+func InactiveTokenResponse() TokenIntrospection {
+	return TokenIntrospection{Active: false}
+}
+
+// TokenIntrospectionResponse creates the introspection response of an active token
+func TokenIntrospectionResponse(claims map[string]interface{}, tokenType string) TokenIntrospection {
+	// client_credentials tokens have no user; their subject is the client
+	sub := claimString(claims, "user_id")
+	if sub == "" {
+		sub = claimString(claims, "client_id")
+	}
+	return TokenIntrospection{
+		Active:    true,
+		Sub:       sub,
+		Username:  claimString(claims, "user_email"),
+		Role:      claimString(claims, "role"),
+		ClientID:  claimString(claims, "client_id"),
+		Scope:     claimString(claims, "scope"),
+		TokenType: tokenType,
+		Exp:       claimInt(claims, "exp"),
+		Iat:       claimInt(claims, "iat"),
+	}
+}
+
+// OAuthTokenResponse creates a token endpoint response; idToken is empty without the openid scope
+func OAuthTokenResponse(tokenPair service.TokenPair, idToken string, scope string) OAuthToken {
+	return OAuthToken{
+		AccessToken:  tokenPair.AccessToken,
+		TokenType:    tokenPair.TokenType,
+		ExpiresIn:    tokenPair.ExpiresIn,
+		RefreshToken: tokenPair.RefreshToken,
+		IDToken:      idToken,
+		Scope:        scope,
+	}
+}
+
+// AuthorizationRequestDetailsResponse creates a standardized pending authorization request response
+func AuthorizationRequestDetailsResponse(authorization *repository.OAuthAuthorization, client *repository.OAuthClient) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: AuthorizationRequestResponse{
+			RequestID:   authorization.RequestID.String(),
+			ClientID:    client.ClientID.String(),
+			ClientName:  client.Name,
+			RedirectURI: authorization.RedirectURI,
+			Scopes:      nonNil(strings.Fields(authorization.Scope)),
+			ExpiresAt:   authorization.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+		Message: "Authorization request retrieved successfully",
+	}
+}
+
+// AuthorizationDecisionResponse creates a standardized consent decision response carrying
+// the client redirect the user agent should follow
+func AuthorizationDecisionResponse(redirectTo string) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data:    AuthorizationDecision{RedirectTo: redirectTo},
+		Message: "Authorization decision recorded successfully",
+	}
+}
+
+// DeviceAuthorizationResponse creates a device authorization response; verificationURIComplete
+// already carries the user code, e.g. for a QR code
+func DeviceAuthorizationResponse(deviceCode string, userCode string, verificationURI string, verificationURIComplete string, expiresIn time.Duration, interval time.Duration) DeviceAuthorization {
+	return DeviceAuthorization{
+		DeviceCode:              deviceCode,
+		UserCode:                userCode,
+		VerificationURI:         verificationURI,
+		VerificationURIComplete: verificationURIComplete,
+		ExpiresIn:               int64(expiresIn.Seconds()),
+		Interval:                int64(interval.Seconds()),
+	}
+}
+
+// DeviceAuthorizationDetailsResponse creates a standardized pending device authorization request response
+func DeviceAuthorizationDetailsResponse(userCode string, authorization *repository.OAuthDeviceAuthorization, client *repository.OAuthClient) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: DeviceAuthorizationRequestResponse{
+			UserCode:   userCode,
+			ClientID:   client.ClientID.String(),
+			ClientName: client.Name,
+			Scopes:     nonNil(strings.Fields(authorization.Scope)),
+			ExpiresAt:  authorization.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+		Message: "Device authorization request retrieved successfully",
+	}
+}
+
+// DeviceAuthorizationDecisionResponse creates a standardized device authorization decision response
+func DeviceAuthorizationDecisionResponse(approved bool) BaseResponse {
+	message := "Device authorization denied"
+	if approved {
+		message = "Device authorized successfully. Return to your device to continue"
+	}
+	return BaseResponse{
+		Success: true,
+		Message: message,
+	}
+}
+
+// OAuthClientCreatedResponse creates the registration response, the only one that includes the client secret
+func OAuthClientCreatedResponse(client *repository.OAuthClient, secret string) BaseResponse {
+	data := oauthClientResponse(client)
+	data.ClientSecret = secret
+	return BaseResponse{
+		Success: true,
+		Data:    data,
+		Message: "OAuth client registered successfully. Store the client secret now; it cannot be shown again",
+	}
+}
+
+// OAuthClientListResponse creates a standardized OAuth client list response
+func OAuthClientListResponse(clients []*repository.OAuthClient) BaseResponse {
+	data := make([]OAuthClientResponse, 0, len(clients))
+	for _, client := range clients {
+		data = append(data, oauthClientResponse(client))
+	}
+	return BaseResponse{
+		Success: true,
+		Data:    data,
+		Message: "OAuth clients retrieved successfully",
+	}
+}
+
+// OAuthClientDeletedResponse creates a standardized OAuth client removal response
+func OAuthClientDeletedResponse() BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Message: "OAuth client deleted successfully",
+	}
+}
+
+func oauthClientResponse(client *repository.OAuthClient) OAuthClientResponse {
+	return OAuthClientResponse{
+		ClientID:     client.ClientID.String(),
+		Name:         client.Name,
+		RedirectURIs: nonNil(client.RedirectURIs),
+		GrantTypes:   nonNil(client.GrantTypes),
+		Scopes:       nonNil(client.Scopes),
+		Public:       client.Public,
+		CreatedAt:    client.CreatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// nonNil returns an empty list instead of nil, so it renders as [] rather than null
+func nonNil(values []string) []string {
+	if values == nil {
+		return []string{}
+	}
+	return values
+}
+
+// claimString returns a string claim, or an empty string when it is missing
+func claimString(claims map[string]interface{}, name string) string {
+	value, _ := claims[name].(string)
+	return value
+}
+
+// claimInt returns a numeric claim, or zero when it is missing
+func claimInt(claims map[string]interface{}, name string) int64 {
+	value, _ := claims[name].(float64)
+	return int64(value)
+}
@@ -0,0 +1,61 @@
+package presenter
+
+import "fiber-api/api/models"
+
+// OpenIDConfiguration represents the OpenID Connect discovery document.
+// It is served as is, outside the standard response envelope.
+type OpenIDConfiguration struct {
+	Issuer                                    string   `json:"issuer"`
+	AuthorizationEndpoint                     string   `json:"authorization_endpoint"`
+	TokenEndpoint                             string   `json:"token_endpoint"`
+	TokenEndpointAuthMethodsSupported         []string `json:"token_endpoint_auth_methods_supported"`
+	UserInfoEndpoint                          string   `json:"userinfo_endpoint"`
+	JWKSURI                                   string   `json:"jwks_uri"`
+	IntrospectionEndpoint                     string   `json:"introspection_endpoint"`
+	IntrospectionEndpointAuthMethodsSupported []string `json:"introspection_endpoint_auth_methods_supported"`
+	RevocationEndpoint                        string   `json:"revocation_endpoint"`
+	RevocationEndpointAuthMethodsSupported    []string `json:"revocation_endpoint_auth_methods_supported"`
+	DeviceAuthorizationEndpoint               string   `json:"device_authorization_endpoint"`
+	ScopesSupported                           []string `json:"scopes_supported"`
+	ResponseTypesSupported                    []string `json:"response_types_supported"`
+	GrantTypesSupported                       []string `json:"grant_types_supported"`
+	CodeChallengeMethodsSupported             []string `json:"code_challenge_methods_supported"`
+	SubjectTypesSupported                     []string `json:"subject_types_supported"`
+	IDTokenSigningAlgValuesSupported          []string `json:"id_token_signing_alg_values_supported"`
+	ClaimsSupported                           []string `json:"claims_supported"`
+}
+
+// clientAuthMethods lists the ways a confidential client can authenticate at the /oauth endpoints
+var clientAuthMethods = []string{"client_secret_basic", "client_secret_post"}
+
+// OpenIDConfigurationResponse creates the discovery document of the given issuer.
+// Public clients authenticate at the token endpoint with their client_id alone.
+func OpenIDConfigurationResponse(issuer string, scopes []string) OpenIDConfiguration {
+	return OpenIDConfiguration{
+		Issuer:                            issuer,
+		AuthorizationEndpoint:             issuer + "/oauth/authorize",
+		TokenEndpoint:                     issuer + "/oauth/token",
+		TokenEndpointAuthMethodsSupported: append(append([]string{}, clientAuthMethods...), "none"),
+		UserInfoEndpoint:                  issuer + "/oauth/userinfo",
+		JWKSURI:                           issuer + "/.well-known/jwks.json",
+		IntrospectionEndpoint:             issuer + "/oauth/introspect",
+		IntrospectionEndpointAuthMethodsSupported: clientAuthMethods,
+		RevocationEndpoint:                        issuer + "/oauth/revoke",
+		RevocationEndpointAuthMethodsSupported:    clientAuthMethods,
+		DeviceAuthorizationEndpoint:               issuer + "/oauth/device_authorization",
+		ScopesSupported:                           nonNil(scopes),
+		ResponseTypesSupported:                    []string{models.ResponseTypeCode},
+		GrantTypesSupported:                       models.GrantTypes,
+		CodeChallengeMethodsSupported:             []string{models.CodeChallengeMethodS256},
+		SubjectTypesSupported:                     []string{"public"},
+		IDTokenSigningAlgValuesSupported:          []string{"RS256"},
+		ClaimsSupported: []string{
+			"kid", "iat", "exp", "token_type",
+			"user_id", "user_email", "role", "device_fingerprint", "fpv",
+			"device_platform", "device_browser", "device_version",
+			"client_id", "scope",
+			"iss", "sub", "aud", "auth_time", "nonce", "amr",
+			"name", "email", "email_verified",
+		},
+	}
+}
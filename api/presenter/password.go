@@ -0,0 +1,29 @@
+package presenter
+
+// PasswordResetRequestedResponse creates the response for a password reset request.
+// It is identical whether or not the email is registered.
+func PasswordResetRequestedResponse() BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Message: "If the email is registered, a password reset link has been sent",
+	}
+}
+
+// PasswordResetSuccessResponse creates a standardized password reset success response
+func PasswordResetSuccessResponse() BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Message: "Password has been reset. Please log in again",
+	}
+}
+
+// PasswordChangedResponse creates a standardized change password success response
+func PasswordChangedResponse(revokedSessions int) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: LogoutResponse{
+			RevokedSessions: revokedSessions,
+		},
+		Message: "Password changed successfully",
+	}
+}
@@ -0,0 +1,93 @@
+package presenter
+
+import (
+	"fiber-api/api/repository"
+	"time"
+)
+
+// QR login states reported to the waiting browser
+const (
+	QRLoginStatusPending = "pending"
+	QRLoginStatusScanned = "scanned"
+)
+
+// QRLoginStartResponse represents a new QR login. The browser renders QRPayload as a QR
+// code and keeps PollToken to itself.
+type QRLoginStartResponse struct {
+	LoginID   string `json:"login_id"`
+	QRPayload string `json:"qr_payload"`
+	PollToken string `json:"poll_token"`
+	Interval  int64  `json:"interval"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// QRLoginStatus represents a QR login the user has not decided on yet
+type QRLoginStatus struct {
+	Status string `json:"status"`
+}
+
+// QRLoginRequestResponse represents the browser asking to log in, shown in the app before approval
+type QRLoginRequestResponse struct {
+	LoginID   string `json:"login_id"`
+	Platform  string `json:"platform"`
+	Browser   string `json:"browser"`
+	IPAddress string `json:"ip_address"`
+	CreatedAt string `json:"created_at"`
+	ExpiresAt string `json:"expires_at"`
+}
+
+// QRLoginStartedResponse creates a standardized QR login start response
+func QRLoginStartedResponse(login *repository.QRLogin, qrPayload string, pollToken string, interval time.Duration) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: QRLoginStartResponse{
+			LoginID:   login.LoginID.String(),
+			QRPayload: qrPayload,
+			PollToken: pollToken,
+			Interval:  int64(interval.Seconds()),
+			ExpiresAt: login.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+		Message: "Scan the QR code with the mobile app to log in",
+	}
+}
+
+// QRLoginPendingResponse creates a standardized response for a login still awaiting approval
+func QRLoginPendingResponse(login *repository.QRLogin) BaseResponse {
+	status := QRLoginStatusPending
+	if login.ScannedAt.Valid {
+		status = QRLoginStatusScanned
+	}
+	return BaseResponse{
+		Success: true,
+		Data:    QRLoginStatus{Status: status},
+		Message: "Waiting for approval in the mobile app",
+	}
+}
+
+// QRLoginRequestDetailsResponse creates a standardized scanned QR login response
+func QRLoginRequestDetailsResponse(login *repository.QRLogin) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: QRLoginRequestResponse{
+			LoginID:   login.LoginID.String(),
+			Platform:  login.Platform,
+			Browser:   login.Browser,
+			IPAddress: login.IPAddress,
+			CreatedAt: login.CreatedAt.UTC().Format(time.RFC3339),
+			ExpiresAt: login.ExpiresAt.UTC().Format(time.RFC3339),
+		},
+		Message: "QR login retrieved successfully",
+	}
+}
+
+// QRLoginDecisionResponse creates a standardized QR login decision response
+func QRLoginDecisionResponse(approved bool) BaseResponse {
+	message := "QR login denied"
+	if approved {
+		message = "QR login approved successfully"
+	}
+	return BaseResponse{
+		Success: true,
+		Message: message,
+	}
+}
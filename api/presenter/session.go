@@ -0,0 +1,66 @@
+package presenter
+
+import (
+	"fiber-api/api/repository"
+	"time"
+)
+
+// SessionResponse represents a single active session
+type SessionResponse struct {
+	SessionID       string  `json:"session_id"`
+	KeyID           string  `json:"key_id"`
+	DeviceType      string  `json:"device_type"`
+	Platform        string  `json:"platform"`
+	Browser         string  `json:"browser"`
+	Version         string  `json:"version"`
+	IPAddress       string  `json:"ip_address"`
+	CreatedAt       string  `json:"created_at"`
+	LastRefreshedAt *string `json:"last_refreshed_at"`
+	Current         bool    `json:"current"`
+}
+
+// SessionRevokedData represents session revocation response data
+type SessionRevokedData struct {
+	SessionID string `json:"session_id"`
+}
+
+// SessionListResponse creates a standardized active sessions response
+func SessionListResponse(sessions []*repository.Session, currentKeyID string) BaseResponse {
+	data := make([]SessionResponse, 0, len(sessions))
+	for _, session := range sessions {
+		var lastRefreshedAt *string
+		if session.LastRefreshedAt.Valid {
+			formatted := session.LastRefreshedAt.Time.UTC().Format(time.RFC3339)
+			lastRefreshedAt = &formatted
+		}
+		data = append(data, SessionResponse{
+			SessionID:       session.SessionID.String(),
+			KeyID:           session.KeyID,
+			DeviceType:      session.DeviceType,
+			Platform:        session.Platform,
+			Browser:         session.Browser,
+			Version:         session.Version,
+			IPAddress:       session.IPAddress,
+			CreatedAt:       session.CreatedAt.UTC().Format(time.RFC3339),
+			LastRefreshedAt: lastRefreshedAt,
+			Current:         session.KeyID == currentKeyID,
+		})
+	}
+
+	return BaseResponse{
+		Success: true,
+		Data:    data,
+		Message: "Sessions retrieved successfully",
+	}
+}
+
+// SessionRevokedResponse creates a standardized session revocation response
+func SessionRevokedResponse(sessionID string) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: SessionRevokedData{
+			SessionID: sessionID,
+		},
+		Message: "Session revoked successfully",
+	}
+}
@@ -0,0 +1,29 @@
+package presenter
+
+// SocialProvidersResponse represents the configured social login providers
+type SocialProvidersResponse struct {
+	Providers []string `json:"providers"`
+}
+
+// SocialLoginStartResponse represents the provider URL that continues a social login
+type SocialLoginStartResponse struct {
+	AuthorizationURL string `json:"authorization_url"`
+}
+
+// SocialProvidersListResponse creates a standardized social provider list response
+func SocialProvidersListResponse(providers []string) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data:    SocialProvidersResponse{Providers: nonNil(providers)},
+		Message: "Social login providers retrieved successfully",
+	}
+}
+
+// SocialLoginStartedResponse creates a standardized social login start response
+func SocialLoginStartedResponse(authorizationURL string) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data:    SocialLoginStartResponse{AuthorizationURL: authorizationURL},
+		Message: "Continue the login at the provider",
+	}
+}
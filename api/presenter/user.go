@@ -25,3 +25,27 @@ func UserProfileFetchResponse(data generated.GetUserProfileRow) BaseResponse {
 		Message: "Profile retrieved successfully",
 	}
 }
+
+// LogoutResponse represents session revocation response data
+type LogoutResponse struct {
+	RevokedSessions int `json:"revoked_sessions"`
+}
+
+// LogoutSuccessResponse creates a standardized logout success response
+func LogoutSuccessResponse(revokedSessions int) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data: LogoutResponse{
+			RevokedSessions: revokedSessions,
+		},
+		Message: "Logged out successfully",
+	}
+}
+
+// AccountUnlockedResponse creates a standardized account unlock response
+func AccountUnlockedResponse() BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Message: "Account unlocked successfully",
+	}
+}
@@ -0,0 +1,96 @@
+package presenter
+
+import (
+	"fiber-api/api/repository"
+	"fiber-api/pkg/webauthn"
+	"time"
+)
+
+// WebAuthnCreationOptionsData wraps registration options as navigator.credentials.create expects them
+type WebAuthnCreationOptionsData struct {
+	PublicKey webauthn.CreationOptions `json:"publicKey"`
+}
+
+// WebAuthnRequestOptionsData wraps authentication options as navigator.credentials.get expects them
+type WebAuthnRequestOptionsData struct {
+	PublicKey webauthn.RequestOptions `json:"publicKey"`
+}
+
+// WebAuthnCredentialResponse represents a registered passkey
+type WebAuthnCredentialResponse struct {
+	CredentialID    string   `json:"credential_id"`
+	Name            string   `json:"name"`
+	AttestationType string   `json:"attestation_type"`
+	Transports      []string `json:"transports"`
+	CreatedAt       string   `json:"created_at"`
+	LastUsedAt      *string  `json:"last_used_at"`
+}
+
+// WebAuthnCreationOptionsResponse creates the response starting a passkey registration
+func WebAuthnCreationOptionsResponse(options webauthn.CreationOptions) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data:    WebAuthnCreationOptionsData{PublicKey: options},
+		Message: "Registration options created",
+	}
+}
+
+// WebAuthnRequestOptionsResponse creates the response starting a passkey login
+func WebAuthnRequestOptionsResponse(options webauthn.RequestOptions) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data:    WebAuthnRequestOptionsData{PublicKey: options},
+		Message: "Authentication options created",
+	}
+}
+
+// WebAuthnRegisteredResponse creates a standardized passkey registration success response
+func WebAuthnRegisteredResponse(credential *repository.WebAuthnCredential) BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Data:    newWebAuthnCredentialResponse(credential),
+		Message: "Passkey registered successfully",
+	}
+}
+
+// WebAuthnCredentialListResponse creates a standardized passkey list response
+func WebAuthnCredentialListResponse(credentials []*repository.WebAuthnCredential) BaseResponse {
+	data := make([]WebAuthnCredentialResponse, 0, len(credentials))
+	for _, credential := range credentials {
+		data = append(data, newWebAuthnCredentialResponse(credential))
+	}
+
+	return BaseResponse{
+		Success: true,
+		Data:    data,
+		Message: "Passkeys retrieved successfully",
+	}
+}
+
+// WebAuthnCredentialDeletedResponse creates a standardized passkey removal response
+func WebAuthnCredentialDeletedResponse() BaseResponse {
+	return BaseResponse{
+		Success: true,
+		Message: "Passkey removed successfully",
+	}
+}
+
+func newWebAuthnCredentialResponse(credential *repository.WebAuthnCredential) WebAuthnCredentialResponse {
+	var lastUsedAt *string
+	if credential.LastUsedAt.Valid {
+		formatted := credential.LastUsedAt.Time.UTC().Format(time.RFC3339)
+		lastUsedAt = &formatted
+	}
+	transports := credential.Transports
+	if transports == nil {
+		transports = []string{}
+	}
+	return WebAuthnCredentialResponse{
+		CredentialID:    credential.CredentialID,
+		Name:            credential.Name,
+		AttestationType: credential.AttestationType,
+		Transports:      transports,
+		CreatedAt:       credential.CreatedAt.UTC().Format(time.RFC3339),
+		LastUsedAt:      lastUsedAt,
+	}
+}
@@ -0,0 +1,48 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+// EmailVerificationRepository tracks which users have verified their email address
+type EmailVerificationRepository interface {
+	IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error)
+	MarkEmailVerified(ctx context.Context, userID uuid.UUID) error
+}
+
+type emailVerificationRepository struct {
+	db *sql.DB
+}
+
+// NewEmailVerificationRepository creates an email verification repository backed by PostgreSQL
+func NewEmailVerificationRepository(db *sql.DB) EmailVerificationRepository {
+	return &emailVerificationRepository{db: db}
+}
+
+// IsEmailVerified reports whether the user has verified their email address
+func (r *emailVerificationRepository) IsEmailVerified(ctx context.Context, userID uuid.UUID) (bool, error) {
+	var verified bool
+	err := r.db.QueryRowContext(ctx, `
+		SELECT EXISTS (SELECT 1 FROM user_email_verifications WHERE user_profile_id = $1)`, userID,
+	).Scan(&verified)
+	if err != nil {
+		return false, fmt.Errorf("failed to check email verification for user %s: %w", userID.String(), err)
+	}
+	return verified, nil
+}
+
+// MarkEmailVerified records the user's email as verified; verifying twice keeps the first timestamp
+func (r *emailVerificationRepository) MarkEmailVerified(ctx context.Context, userID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_email_verifications (user_profile_id)
+		VALUES ($1)
+		ON CONFLICT (user_profile_id) DO NOTHING`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to mark email verified for user %s: %w", userID.String(), err)
+	}
+	return nil
+}
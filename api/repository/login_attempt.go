@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// LoginAttempt tracks the recent failed logins of an email address
+type LoginAttempt struct {
+	Email        string
+	FailedCount  int
+	LastFailedAt time.Time
+	LockedUntil  sql.NullTime
+}
+
+// LoginAttemptRepository manages failed login counters
+type LoginAttemptRepository interface {
+	GetLoginAttempt(ctx context.Context, email string) (*LoginAttempt, error)
+	RecordFailedLogin(ctx context.Context, email string, maxAttempts int, lockout time.Duration) (*LoginAttempt, error)
+	ClearLoginAttempts(ctx context.Context, email string) error
+}
+
+type loginAttemptRepository struct {
+	db *sql.DB
+}
+
+// NewLoginAttemptRepository creates a login attempt repository backed by PostgreSQL
+func NewLoginAttemptRepository(db *sql.DB) LoginAttemptRepository {
+	return &loginAttemptRepository{db: db}
+}
+
+// GetLoginAttempt returns the failed login state of an email.
+// Returns ErrNotFound if there were no failures since the last reset.
+func (r *loginAttemptRepository) GetLoginAttempt(ctx context.Context, email string) (*LoginAttempt, error) {
+	var attempt LoginAttempt
+	err := r.db.QueryRowContext(ctx, `
+		SELECT email, failed_count, last_failed_at, locked_until
+		FROM login_attempts WHERE email = $1`, email,
+	).Scan(&attempt.Email, &attempt.FailedCount, &attempt.LastFailedAt, &attempt.LockedUntil)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get login attempts for %s: %w", email, err)
+	}
+	return &attempt, nil
+}
+
+// RecordFailedLogin atomically counts a failed login and locks the email for the lockout
+// duration once maxAttempts failures accumulate; maxAttempts of zero never locks.
+// The count restarts after an expired lock or when the previous failure is older than
+// the lockout duration.
+func (r *loginAttemptRepository) RecordFailedLogin(ctx context.Context, email string, maxAttempts int, lockout time.Duration) (*LoginAttempt, error) {
+	var attempt LoginAttempt
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO login_attempts AS a (email, failed_count, last_failed_at, locked_until)
+		VALUES ($1, 1, NOW(), CASE WHEN $2::int > 0 AND $2::int <= 1 THEN NOW() + $3::float8 * INTERVAL '1 second' END)
+		ON CONFLICT (email) DO UPDATE SET
+			failed_count = CASE
+				WHEN a.locked_until <= NOW() OR a.last_failed_at <= NOW() - $3::float8 * INTERVAL '1 second' THEN 1
+				ELSE a.failed_count + 1
+			END,
+			last_failed_at = NOW(),
+			locked_until = CASE
+				WHEN a.locked_until > NOW() THEN a.locked_until
+				WHEN $2::int > 0 AND (CASE
+					WHEN a.locked_until <= NOW() OR a.last_failed_at <= NOW() - $3::float8 * INTERVAL '1 second' THEN 1
+					ELSE a.failed_count + 1
+				END) >= $2::int THEN NOW() + $3::float8 * INTERVAL '1 second'
+			END
+		RETURNING email, failed_count, last_failed_at, locked_until`,
+		email, maxAttempts, lockout.Seconds(),
+	).Scan(&attempt.Email, &attempt.FailedCount, &attempt.LastFailedAt, &attempt.LockedUntil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record failed login for %s: %w", email, err)
+	}
+	return &attempt, nil
+}
+
+// ClearLoginAttempts resets the failure count and lifts any lock of an email
+func (r *loginAttemptRepository) ClearLoginAttempts(ctx context.Context, email string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM login_attempts WHERE email = $1`, email); err != nil {
+		return fmt.Errorf("failed to clear login attempts for %s: %w", email, err)
+	}
+	return nil
+}
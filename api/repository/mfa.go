@@ -0,0 +1,160 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MFA represents a user's TOTP enrollment
+type MFA struct {
+	UserID          uuid.UUID
+	EncryptedSecret string
+	EnabledAt       sql.NullTime
+	LastUsedStep    int64
+	CreatedAt       time.Time
+}
+
+// Enabled reports whether the enrollment was confirmed
+func (m *MFA) Enabled() bool {
+	return m.EnabledAt.Valid
+}
+
+// MFARepository manages TOTP enrollments and their recovery codes
+type MFARepository interface {
+	GetMFA(ctx context.Context, userID uuid.UUID) (*MFA, error)
+	SavePendingMFA(ctx context.Context, userID uuid.UUID, encryptedSecret string) error
+	EnableMFA(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error
+	UpdateLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) error
+	DeleteMFA(ctx context.Context, userID uuid.UUID) error
+	ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error
+}
+
+type mfaRepository struct {
+	db *sql.DB
+}
+
+// NewMFARepository creates an MFA repository backed by PostgreSQL
+func NewMFARepository(db *sql.DB) MFARepository {
+	return &mfaRepository{db: db}
+}
+
+// GetMFA returns the user's enrollment, pending or enabled.
+// Returns ErrNotFound if the user never enrolled.
+func (r *mfaRepository) GetMFA(ctx context.Context, userID uuid.UUID) (*MFA, error) {
+	var mfa MFA
+	err := r.db.QueryRowContext(ctx, `
+		SELECT user_profile_id, encrypted_secret, enabled_at, last_used_step, created_at
+		FROM user_mfa WHERE user_profile_id = $1`, userID,
+	).Scan(&mfa.UserID, &mfa.EncryptedSecret, &mfa.EnabledAt, &mfa.LastUsedStep, &mfa.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get mfa for user %s: %w", userID.String(), err)
+	}
+	return &mfa, nil
+}
+
+// SavePendingMFA stores a new secret awaiting confirmation, replacing any earlier pending one.
+// Returns ErrAlreadyUsed if the user already has MFA enabled.
+func (r *mfaRepository) SavePendingMFA(ctx context.Context, userID uuid.UUID, encryptedSecret string) error {
+	result, err := r.db.ExecContext(ctx, `
+		INSERT INTO user_mfa (user_profile_id, encrypted_secret)
+		VALUES ($1, $2)
+		ON CONFLICT (user_profile_id) DO UPDATE
+		SET encrypted_secret = EXCLUDED.encrypted_secret, last_used_step = 0, created_at = NOW()
+		WHERE user_mfa.enabled_at IS NULL`, userID, encryptedSecret)
+	if err != nil {
+		return fmt.Errorf("failed to save pending mfa for user %s: %w", userID.String(), err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// EnableMFA confirms a pending enrollment with the step of its first code and
+// stores the recovery code hashes, replacing any earlier ones.
+// Returns ErrNotFound if there is no pending enrollment.
+func (r *mfaRepository) EnableMFA(ctx context.Context, userID uuid.UUID, step int64, recoveryCodeHashes []string) error {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `
+		UPDATE user_mfa SET enabled_at = NOW(), last_used_step = $2
+		WHERE user_profile_id = $1 AND enabled_at IS NULL`, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to enable mfa for user %s: %w", userID.String(), err)
+	}
+	if err := expectRows(result); err != nil {
+		return err
+	}
+
+	if _, err := tx.ExecContext(ctx, `
+		DELETE FROM mfa_recovery_codes WHERE user_profile_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to clear recovery codes for user %s: %w", userID.String(), err)
+	}
+	for _, hash := range recoveryCodeHashes {
+		if _, err := tx.ExecContext(ctx, `
+			INSERT INTO mfa_recovery_codes (code_hash, user_profile_id)
+			VALUES ($1, $2)`, hash, userID); err != nil {
+			return fmt.Errorf("failed to store recovery code for user %s: %w", userID.String(), err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit mfa enrollment for user %s: %w", userID.String(), err)
+	}
+	return nil
+}
+
+// UpdateLastUsedStep records the time step of an accepted code so it cannot be replayed.
+// Returns ErrAlreadyUsed if a code of the same or a later step was already accepted.
+func (r *mfaRepository) UpdateLastUsedStep(ctx context.Context, userID uuid.UUID, step int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_mfa SET last_used_step = $2
+		WHERE user_profile_id = $1 AND last_used_step < $2`, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to update mfa step for user %s: %w", userID.String(), err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteMFA removes the user's enrollment and, by cascade, their recovery codes
+func (r *mfaRepository) DeleteMFA(ctx context.Context, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM user_mfa WHERE user_profile_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete mfa for user %s: %w", userID.String(), err)
+	}
+	return expectRows(result)
+}
+
+// ConsumeRecoveryCode atomically marks an unused recovery code as used.
+// Returns ErrNotFound if the code does not exist or was already used.
+func (r *mfaRepository) ConsumeRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE mfa_recovery_codes SET used_at = NOW()
+		WHERE code_hash = $1 AND user_profile_id = $2 AND used_at IS NULL`, codeHash, userID)
+	if err != nil {
+		return fmt.Errorf("failed to consume recovery code for user %s: %w", userID.String(), err)
+	}
+	return expectRows(result)
+}
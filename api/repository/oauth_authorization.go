@@ -0,0 +1,179 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthAuthorization represents an authorization request and, once the user approves
+// it, the authorization code issued for it. AuthTime and AuthMethods describe how the
+// consenting user authenticated.
+type OAuthAuthorization struct {
+	RequestID     uuid.UUID
+	ClientID      uuid.UUID
+	RedirectURI   string
+	Scope         string
+	State         string
+	Nonce         string
+	CodeChallenge string
+	UserID        uuid.NullUUID
+	AuthTime      sql.NullTime
+	AuthMethods   []string
+	CodeHash      sql.NullString
+	SessionID     uuid.NullUUID
+	ExpiresAt     time.Time
+	UsedAt        sql.NullTime
+	CreatedAt     time.Time
+}
+
+// Pending reports whether the request still awaits the user's decision
+func (a *OAuthAuthorization) Pending() bool {
+	return !a.CodeHash.Valid && time.Now().Before(a.ExpiresAt)
+}
+
+// OAuthAuthorizationRepository manages authorization requests and codes
+type OAuthAuthorizationRepository interface {
+	CreateAuthorization(ctx context.Context, authorization *OAuthAuthorization) error
+	GetAuthorization(ctx context.Context, requestID uuid.UUID) (*OAuthAuthorization, error)
+	GetAuthorizationByCode(ctx context.Context, codeHash string) (*OAuthAuthorization, error)
+	ApproveAuthorization(ctx context.Context, authorization *OAuthAuthorization, codeTTL time.Duration) error
+	ConsumeAuthorizationCode(ctx context.Context, codeHash string) error
+	SetAuthorizationSession(ctx context.Context, requestID uuid.UUID, sessionID uuid.UUID) error
+	DeleteAuthorization(ctx context.Context, requestID uuid.UUID) error
+}
+
+type oauthAuthorizationRepository struct {
+	db *sql.DB
+}
+
+// NewOAuthAuthorizationRepository creates an OAuth authorization repository backed by PostgreSQL
+func NewOAuthAuthorizationRepository(db *sql.DB) OAuthAuthorizationRepository {
+	return &oauthAuthorizationRepository{db: db}
+}
+
+const oauthAuthorizationColumns = `request_id, client_id, redirect_uri, scope, state, nonce, code_challenge,
+	user_profile_id, auth_time, amr, code_hash, session_id, expires_at, used_at, created_at`
+
+// CreateAuthorization stores a new authorization request, assigning its ID
+func (r *oauthAuthorizationRepository) CreateAuthorization(ctx context.Context, authorization *OAuthAuthorization) error {
+	authorization.RequestID = uuid.New()
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO oauth_authorizations (request_id, client_id, redirect_uri, scope, state, nonce, code_challenge, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`,
+		authorization.RequestID, authorization.ClientID, authorization.RedirectURI, authorization.Scope,
+		authorization.State, authorization.Nonce, authorization.CodeChallenge, authorization.ExpiresAt,
+	).Scan(&authorization.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create authorization request for client %s: %w", authorization.ClientID.String(), err)
+	}
+	return nil
+}
+
+// GetAuthorization retrieves an authorization request by its ID
+func (r *oauthAuthorizationRepository) GetAuthorization(ctx context.Context, requestID uuid.UUID) (*OAuthAuthorization, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+oauthAuthorizationColumns+` FROM oauth_authorizations WHERE request_id = $1`, requestID)
+	return scanOAuthAuthorization(row)
+}
+
+// GetAuthorizationByCode retrieves the authorization an authorization code was issued for
+func (r *oauthAuthorizationRepository) GetAuthorizationByCode(ctx context.Context, codeHash string) (*OAuthAuthorization, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+oauthAuthorizationColumns+` FROM oauth_authorizations WHERE code_hash = $1`, codeHash)
+	return scanOAuthAuthorization(row)
+}
+
+// ApproveAuthorization binds a pending request to the consenting user, their authentication
+// and the hash of its code, valid for codeTTL. Returns ErrAlreadyUsed if the request was
+// decided before or expired.
+func (r *oauthAuthorizationRepository) ApproveAuthorization(ctx context.Context, authorization *OAuthAuthorization, codeTTL time.Duration) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE oauth_authorizations
+		SET user_profile_id = $2, auth_time = $3, amr = $4, code_hash = $5, expires_at = NOW() + $6::float8 * INTERVAL '1 second'
+		WHERE request_id = $1 AND code_hash IS NULL AND expires_at > NOW()`,
+		authorization.RequestID, authorization.UserID, authorization.AuthTime,
+		strings.Join(authorization.AuthMethods, " "), authorization.CodeHash, codeTTL.Seconds())
+	if err != nil {
+		return fmt.Errorf("failed to approve authorization request %s: %w", authorization.RequestID.String(), err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// ConsumeAuthorizationCode atomically redeems an unexpired authorization code.
+// Returns ErrAlreadyUsed if the code was redeemed before, including by a concurrent request.
+func (r *oauthAuthorizationRepository) ConsumeAuthorizationCode(ctx context.Context, codeHash string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE oauth_authorizations SET used_at = NOW()
+		WHERE code_hash = $1 AND used_at IS NULL AND expires_at > NOW()`, codeHash)
+	if err != nil {
+		return fmt.Errorf("failed to consume authorization code: %w", err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// SetAuthorizationSession records the session an authorization code was exchanged for,
+// so a replayed code can revoke it
+func (r *oauthAuthorizationRepository) SetAuthorizationSession(ctx context.Context, requestID uuid.UUID, sessionID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE oauth_authorizations SET session_id = $2 WHERE request_id = $1`, requestID, sessionID)
+	if err != nil {
+		return fmt.Errorf("failed to record session of authorization %s: %w", requestID.String(), err)
+	}
+	return expectRows(result)
+}
+
+// DeleteAuthorization removes an authorization request, e.g. when the user denies it
+func (r *oauthAuthorizationRepository) DeleteAuthorization(ctx context.Context, requestID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM oauth_authorizations WHERE request_id = $1`, requestID); err != nil {
+		return fmt.Errorf("failed to delete authorization request %s: %w", requestID.String(), err)
+	}
+	return nil
+}
+
+// scanOAuthAuthorization maps an oauth_authorizations row to an OAuthAuthorization
+func scanOAuthAuthorization(row rowScanner) (*OAuthAuthorization, error) {
+	var authorization OAuthAuthorization
+	var authMethods string
+	err := row.Scan(
+		&authorization.RequestID,
+		&authorization.ClientID,
+		&authorization.RedirectURI,
+		&authorization.Scope,
+		&authorization.State,
+		&authorization.Nonce,
+		&authorization.CodeChallenge,
+		&authorization.UserID,
+		&authorization.AuthTime,
+		&authMethods,
+		&authorization.CodeHash,
+		&authorization.SessionID,
+		&authorization.ExpiresAt,
+		&authorization.UsedAt,
+		&authorization.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan authorization request: %w", err)
+	}
+	authorization.AuthMethods = strings.Fields(authMethods)
+	return &authorization, nil
+}
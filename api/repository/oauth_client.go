@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthClient represents a registered OAuth 2.0 client
+type OAuthClient struct {
+	ClientID         uuid.UUID
+	ClientSecretHash string
+	Name             string
+	RedirectURIs     []string
+	GrantTypes       []string
+	Scopes           []string
+	// Public clients (SPAs, native apps) have no secret and authenticate with PKCE only
+	Public bool
+	// OwnerID is the admin who registered the client
+	OwnerID   uuid.NullUUID
+	CreatedAt time.Time
+}
+
+// HasRedirectURI reports whether uri exactly matches one of the registered redirect URIs
+func (c *OAuthClient) HasRedirectURI(uri string) bool {
+	return slices.Contains(c.RedirectURIs, uri)
+}
+
+// AllowsGrant reports whether the client may use the given grant type
+func (c *OAuthClient) AllowsGrant(grantType string) bool {
+	return slices.Contains(c.GrantTypes, grantType)
+}
+
+// AllowsScopes reports whether every requested scope is registered for the client
+func (c *OAuthClient) AllowsScopes(scopes []string) bool {
+	for _, scope := range scopes {
+		if !slices.Contains(c.Scopes, scope) {
+			return false
+		}
+	}
+	return true
+}
+
+// OAuthClientRepository manages registered OAuth 2.0 clients
+type OAuthClientRepository interface {
+	CreateClient(ctx context.Context, client *OAuthClient) error
+	GetClient(ctx context.Context, clientID uuid.UUID) (*OAuthClient, error)
+	ListClients(ctx context.Context) ([]*OAuthClient, error)
+	DeleteClient(ctx context.Context, clientID uuid.UUID) error
+}
+
+type oauthClientRepository struct {
+	db *sql.DB
+}
+
+// NewOAuthClientRepository creates an OAuth client repository backed by PostgreSQL
+func NewOAuthClientRepository(db *sql.DB) OAuthClientRepository {
+	return &oauthClientRepository{db: db}
+}
+
+const oauthClientColumns = `client_id, client_secret_hash, name, redirect_uris, grant_types, scopes,
+	public, owner_user_id, created_at`
+
+// CreateClient registers a new client, generating its ID when not set
+func (r *oauthClientRepository) CreateClient(ctx context.Context, client *OAuthClient) error {
+	if client.ClientID == uuid.Nil {
+		client.ClientID = uuid.New()
+	}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO oauth_clients (client_id, client_secret_hash, name, redirect_uris, grant_types, scopes, public, owner_user_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`,
+		client.ClientID, sql.NullString{String: client.ClientSecretHash, Valid: client.ClientSecretHash != ""}, client.Name,
+		strings.Join(client.RedirectURIs, " "), strings.Join(client.GrantTypes, " "), strings.Join(client.Scopes, " "),
+		client.Public, client.OwnerID,
+	).Scan(&client.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create oauth client %s: %w", client.Name, err)
+	}
+	return nil
+}
+
+// GetClient retrieves a client by its ID
+func (r *oauthClientRepository) GetClient(ctx context.Context, clientID uuid.UUID) (*OAuthClient, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+oauthClientColumns+` FROM oauth_clients WHERE client_id = $1`, clientID)
+	return scanOAuthClient(row)
+}
+
+// ListClients returns all registered clients, newest first
+func (r *oauthClientRepository) ListClients(ctx context.Context) ([]*OAuthClient, error) {
+	rows, err := r.db.QueryContext(ctx, `SELECT `+oauthClientColumns+` FROM oauth_clients ORDER BY created_at DESC`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query oauth clients: %w", err)
+	}
+	defer rows.Close()
+
+	var clients []*OAuthClient
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, client)
+	}
+	return clients, rows.Err()
+}
+
+// DeleteClient removes a client.
+// Returns ErrNotFound if the client does not exist.
+func (r *oauthClientRepository) DeleteClient(ctx context.Context, clientID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `DELETE FROM oauth_clients WHERE client_id = $1`, clientID)
+	if err != nil {
+		return fmt.Errorf("failed to delete oauth client %s: %w", clientID.String(), err)
+	}
+	return expectRows(result)
+}
+
+// scanOAuthClient maps an oauth_clients row to an OAuthClient
+func scanOAuthClient(row rowScanner) (*OAuthClient, error) {
+	var client OAuthClient
+	var secretHash sql.NullString
+	var redirectURIs, grantTypes, scopes string
+	err := row.Scan(
+		&client.ClientID,
+		&secretHash,
+		&client.Name,
+		&redirectURIs,
+		&grantTypes,
+		&scopes,
+		&client.Public,
+		&client.OwnerID,
+		&client.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan oauth client: %w", err)
+	}
+	client.ClientSecretHash = secretHash.String
+	client.RedirectURIs = strings.Fields(redirectURIs)
+	client.GrantTypes = strings.Fields(grantTypes)
+	client.Scopes = strings.Fields(scopes)
+	return &client, nil
+}
@@ -0,0 +1,195 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// OAuthDeviceAuthorization represents a device authorization request (RFC 8628) and,
+// once a user approves it, the user and authentication the device's session is opened for
+type OAuthDeviceAuthorization struct {
+	DeviceCodeHash string
+	UserCode       string
+	ClientID       uuid.UUID
+	Scope          string
+	UserID         uuid.NullUUID
+	AuthTime       sql.NullTime
+	AuthMethods    []string
+	DeniedAt       sql.NullTime
+	// Interval is the minimum time between two polls of the device
+	Interval     time.Duration
+	LastPolledAt sql.NullTime
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// Pending reports whether the request still awaits the user's decision
+func (a *OAuthDeviceAuthorization) Pending() bool {
+	return !a.UserID.Valid && !a.DeniedAt.Valid && time.Now().Before(a.ExpiresAt)
+}
+
+// OAuthDeviceAuthorizationRepository manages device authorization requests
+type OAuthDeviceAuthorizationRepository interface {
+	CreateDeviceAuthorization(ctx context.Context, authorization *OAuthDeviceAuthorization) error
+	GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (*OAuthDeviceAuthorization, error)
+	ApproveDeviceAuthorization(ctx context.Context, authorization *OAuthDeviceAuthorization) error
+	DenyDeviceAuthorization(ctx context.Context, userCode string) error
+	PollDeviceAuthorization(ctx context.Context, deviceCodeHash string) (*OAuthDeviceAuthorization, bool, error)
+	ConsumeDeviceAuthorization(ctx context.Context, deviceCodeHash string) error
+}
+
+type oauthDeviceAuthorizationRepository struct {
+	db *sql.DB
+}
+
+// NewOAuthDeviceAuthorizationRepository creates a device authorization repository backed by PostgreSQL
+func NewOAuthDeviceAuthorizationRepository(db *sql.DB) OAuthDeviceAuthorizationRepository {
+	return &oauthDeviceAuthorizationRepository{db: db}
+}
+
+const oauthDeviceAuthorizationColumns = `device_code_hash, user_code, client_id, scope, user_profile_id,
+	auth_time, amr, denied_at, interval_seconds, last_polled_at, expires_at, created_at`
+
+// CreateDeviceAuthorization stores a new device authorization request.
+// Returns ErrDuplicate if its user code is already taken.
+func (r *oauthDeviceAuthorizationRepository) CreateDeviceAuthorization(ctx context.Context, authorization *OAuthDeviceAuthorization) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO oauth_device_authorizations (device_code_hash, user_code, client_id, scope, interval_seconds, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT DO NOTHING
+		RETURNING created_at`,
+		authorization.DeviceCodeHash, authorization.UserCode, authorization.ClientID, authorization.Scope,
+		int(authorization.Interval.Seconds()), authorization.ExpiresAt,
+	).Scan(&authorization.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create device authorization for client %s: %w", authorization.ClientID.String(), err)
+	}
+	return nil
+}
+
+// GetDeviceAuthorizationByUserCode retrieves a device authorization request by its user code
+func (r *oauthDeviceAuthorizationRepository) GetDeviceAuthorizationByUserCode(ctx context.Context, userCode string) (*OAuthDeviceAuthorization, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+oauthDeviceAuthorizationColumns+` FROM oauth_device_authorizations WHERE user_code = $1`, userCode)
+	return scanOAuthDeviceAuthorization(row)
+}
+
+// ApproveDeviceAuthorization binds a pending request to the approving user and their
+// authentication. Returns ErrAlreadyUsed if the request was decided before or expired.
+func (r *oauthDeviceAuthorizationRepository) ApproveDeviceAuthorization(ctx context.Context, authorization *OAuthDeviceAuthorization) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE oauth_device_authorizations
+		SET user_profile_id = $2, auth_time = $3, amr = $4
+		WHERE user_code = $1 AND user_profile_id IS NULL AND denied_at IS NULL AND expires_at > NOW()`,
+		authorization.UserCode, authorization.UserID, authorization.AuthTime, strings.Join(authorization.AuthMethods, " "))
+	if err != nil {
+		return fmt.Errorf("failed to approve device authorization: %w", err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// DenyDeviceAuthorization records the user's refusal of a pending request.
+// Returns ErrAlreadyUsed if the request was decided before or expired.
+func (r *oauthDeviceAuthorizationRepository) DenyDeviceAuthorization(ctx context.Context, userCode string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE oauth_device_authorizations SET denied_at = NOW()
+		WHERE user_code = $1 AND user_profile_id IS NULL AND denied_at IS NULL AND expires_at > NOW()`, userCode)
+	if err != nil {
+		return fmt.Errorf("failed to deny device authorization: %w", err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// PollDeviceAuthorization records a poll of the device and returns its request. The
+// returned flag reports a poll within the interval of the previous one; the interval
+// then grows by 5 seconds (RFC 8628 section 3.5).
+func (r *oauthDeviceAuthorizationRepository) PollDeviceAuthorization(ctx context.Context, deviceCodeHash string) (*OAuthDeviceAuthorization, bool, error) {
+	var slowDown bool
+	row := r.db.QueryRowContext(ctx, `
+		UPDATE oauth_device_authorizations AS d
+		SET last_polled_at = NOW(),
+			interval_seconds = d.interval_seconds + CASE WHEN p.too_fast THEN 5 ELSE 0 END
+		FROM (
+			SELECT device_code_hash AS polled_hash,
+				COALESCE(last_polled_at > NOW() - interval_seconds * INTERVAL '1 second', FALSE) AS too_fast
+			FROM oauth_device_authorizations
+			WHERE device_code_hash = $1
+			FOR UPDATE
+		) AS p
+		WHERE d.device_code_hash = p.polled_hash
+		RETURNING `+oauthDeviceAuthorizationColumns+`, p.too_fast`, deviceCodeHash)
+	authorization, err := scanOAuthDeviceAuthorization(row, &slowDown)
+	if err != nil {
+		return nil, false, err
+	}
+	return authorization, slowDown, nil
+}
+
+// ConsumeDeviceAuthorization atomically removes an approved, unexpired request once
+// its tokens are issued. Returns ErrAlreadyUsed if a concurrent poll redeemed it first.
+func (r *oauthDeviceAuthorizationRepository) ConsumeDeviceAuthorization(ctx context.Context, deviceCodeHash string) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM oauth_device_authorizations
+		WHERE device_code_hash = $1 AND user_profile_id IS NOT NULL AND expires_at > NOW()`, deviceCodeHash)
+	if err != nil {
+		return fmt.Errorf("failed to consume device authorization: %w", err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// scanOAuthDeviceAuthorization maps an oauth_device_authorizations row to an
+// OAuthDeviceAuthorization; extra receives any columns selected after the row's own
+func scanOAuthDeviceAuthorization(row rowScanner, extra ...interface{}) (*OAuthDeviceAuthorization, error) {
+	var authorization OAuthDeviceAuthorization
+	var authMethods string
+	var intervalSeconds int
+	dest := []interface{}{
+		&authorization.DeviceCodeHash,
+		&authorization.UserCode,
+		&authorization.ClientID,
+		&authorization.Scope,
+		&authorization.UserID,
+		&authorization.AuthTime,
+		&authMethods,
+		&authorization.DeniedAt,
+		&intervalSeconds,
+		&authorization.LastPolledAt,
+		&authorization.ExpiresAt,
+		&authorization.CreatedAt,
+	}
+	if err := row.Scan(append(dest, extra...)...); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan device authorization: %w", err)
+	}
+	authorization.AuthMethods = strings.Fields(authMethods)
+	authorization.Interval = time.Duration(intervalSeconds) * time.Second
+	return &authorization, nil
+}
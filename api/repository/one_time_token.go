@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// One-time token purposes
+const (
+	TokenPurposePasswordReset     = "password_reset"
+	TokenPurposeEmailVerification = "email_verification"
+	TokenPurposeMFAChallenge      = "mfa_challenge"
+	TokenPurposeWebAuthnRegister  = "webauthn_register"
+	TokenPurposeWebAuthnLogin     = "webauthn_login"
+)
+
+// OneTimeToken represents a hashed, expiring, single-use token issued to a user
+type OneTimeToken struct {
+	TokenHash string
+	UserID    uuid.UUID
+	Purpose   string
+	ExpiresAt time.Time
+	UsedAt    sql.NullTime
+	CreatedAt time.Time
+}
+
+// OneTimeTokenRepository manages single-use tokens
+type OneTimeTokenRepository interface {
+	CreateToken(ctx context.Context, token *OneTimeToken) error
+	ConsumeToken(ctx context.Context, tokenHash string, purpose string) (*OneTimeToken, error)
+	DeleteUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error
+	GetLatestTokenCreatedAt(ctx context.Context, userID uuid.UUID, purpose string) (time.Time, error)
+}
+
+type oneTimeTokenRepository struct {
+	db *sql.DB
+}
+
+// NewOneTimeTokenRepository creates a one-time token repository backed by PostgreSQL
+func NewOneTimeTokenRepository(db *sql.DB) OneTimeTokenRepository {
+	return &oneTimeTokenRepository{db: db}
+}
+
+// CreateToken stores a new token hash
+func (r *oneTimeTokenRepository) CreateToken(ctx context.Context, token *OneTimeToken) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO one_time_tokens (token_hash, user_profile_id, purpose, expires_at)
+		VALUES ($1, $2, $3, $4)
+		RETURNING created_at`,
+		token.TokenHash, token.UserID, token.Purpose, token.ExpiresAt,
+	).Scan(&token.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create %s token for user %s: %w", token.Purpose, token.UserID.String(), err)
+	}
+	return nil
+}
+
+// ConsumeToken atomically marks an unexpired, unused token as used and returns it.
+// Returns ErrNotFound if the token does not exist, is expired or was already used.
+func (r *oneTimeTokenRepository) ConsumeToken(ctx context.Context, tokenHash string, purpose string) (*OneTimeToken, error) {
+	var token OneTimeToken
+	err := r.db.QueryRowContext(ctx, `
+		UPDATE one_time_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND purpose = $2 AND used_at IS NULL AND expires_at > NOW()
+		RETURNING token_hash, user_profile_id, purpose, expires_at, used_at, created_at`,
+		tokenHash, purpose,
+	).Scan(&token.TokenHash, &token.UserID, &token.Purpose, &token.ExpiresAt, &token.UsedAt, &token.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to consume %s token: %w", purpose, err)
+	}
+	return &token, nil
+}
+
+// DeleteUserTokens removes all tokens of a purpose for a user, invalidating any still outstanding
+func (r *oneTimeTokenRepository) DeleteUserTokens(ctx context.Context, userID uuid.UUID, purpose string) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM one_time_tokens
+		WHERE user_profile_id = $1 AND purpose = $2`, userID, purpose)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s tokens for user %s: %w", purpose, userID.String(), err)
+	}
+	return nil
+}
+
+// GetLatestTokenCreatedAt returns when the user was last issued a token of the purpose.
+// Returns ErrNotFound if no such token exists.
+func (r *oneTimeTokenRepository) GetLatestTokenCreatedAt(ctx context.Context, userID uuid.UUID, purpose string) (time.Time, error) {
+	var createdAt sql.NullTime
+	err := r.db.QueryRowContext(ctx, `
+		SELECT MAX(created_at) FROM one_time_tokens
+		WHERE user_profile_id = $1 AND purpose = $2`, userID, purpose,
+	).Scan(&createdAt)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to get latest %s token for user %s: %w", purpose, userID.String(), err)
+	}
+	if !createdAt.Valid {
+		return time.Time{}, ErrNotFound
+	}
+	return createdAt.Time, nil
+}
@@ -0,0 +1,177 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// QRLogin represents a cross-device login: a web browser waiting for a user of the
+// native app to scan its QR code and approve it
+type QRLogin struct {
+	LoginID       uuid.UUID
+	PollTokenHash string
+	ScanCodeHash  string
+	// FingerprintHash, Platform, Browser and IPAddress describe the waiting browser
+	FingerprintHash string
+	Platform        string
+	Browser         string
+	IPAddress       string
+	UserID          uuid.NullUUID
+	ScannedAt       sql.NullTime
+	DeniedAt        sql.NullTime
+	ExpiresAt       time.Time
+	CreatedAt       time.Time
+}
+
+// Pending reports whether the login still awaits the user's decision
+func (l *QRLogin) Pending() bool {
+	return !l.UserID.Valid && !l.DeniedAt.Valid && time.Now().Before(l.ExpiresAt)
+}
+
+// QRLoginRepository manages cross-device QR logins
+type QRLoginRepository interface {
+	CreateLogin(ctx context.Context, login *QRLogin) error
+	GetLoginByScanCode(ctx context.Context, scanCodeHash string) (*QRLogin, error)
+	GetLoginByPollToken(ctx context.Context, pollTokenHash string) (*QRLogin, error)
+	MarkLoginScanned(ctx context.Context, loginID uuid.UUID) error
+	ApproveLogin(ctx context.Context, loginID uuid.UUID, userID uuid.UUID) error
+	DenyLogin(ctx context.Context, loginID uuid.UUID) error
+	ConsumeLogin(ctx context.Context, loginID uuid.UUID) error
+}
+
+type qrLoginRepository struct {
+	db *sql.DB
+}
+
+// NewQRLoginRepository creates a QR login repository backed by PostgreSQL
+func NewQRLoginRepository(db *sql.DB) QRLoginRepository {
+	return &qrLoginRepository{db: db}
+}
+
+const qrLoginColumns = `login_id, poll_token_hash, scan_code_hash, fingerprint_hash, platform, browser,
+	ip_address, user_profile_id, scanned_at, denied_at, expires_at, created_at`
+
+// CreateLogin stores a new pending QR login, assigning its ID
+func (r *qrLoginRepository) CreateLogin(ctx context.Context, login *QRLogin) error {
+	login.LoginID = uuid.New()
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO qr_logins (login_id, poll_token_hash, scan_code_hash, fingerprint_hash, platform, browser, ip_address, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING created_at`,
+		login.LoginID, login.PollTokenHash, login.ScanCodeHash, login.FingerprintHash,
+		login.Platform, login.Browser, login.IPAddress, login.ExpiresAt,
+	).Scan(&login.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create qr login: %w", err)
+	}
+	return nil
+}
+
+// GetLoginByScanCode retrieves the QR login a scanned code belongs to
+func (r *qrLoginRepository) GetLoginByScanCode(ctx context.Context, scanCodeHash string) (*QRLogin, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+qrLoginColumns+` FROM qr_logins WHERE scan_code_hash = $1`, scanCodeHash)
+	return scanQRLogin(row)
+}
+
+// GetLoginByPollToken retrieves the QR login of a waiting browser
+func (r *qrLoginRepository) GetLoginByPollToken(ctx context.Context, pollTokenHash string) (*QRLogin, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+qrLoginColumns+` FROM qr_logins WHERE poll_token_hash = $1`, pollTokenHash)
+	return scanQRLogin(row)
+}
+
+// MarkLoginScanned records the first scan of a pending login, so the browser can ask
+// the user to confirm in the app
+func (r *qrLoginRepository) MarkLoginScanned(ctx context.Context, loginID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		UPDATE qr_logins SET scanned_at = COALESCE(scanned_at, NOW())
+		WHERE login_id = $1`, loginID)
+	if err != nil {
+		return fmt.Errorf("failed to mark qr login %s scanned: %w", loginID.String(), err)
+	}
+	return nil
+}
+
+// ApproveLogin binds a pending login to the approving user.
+// Returns ErrAlreadyUsed if the login was decided before or expired.
+func (r *qrLoginRepository) ApproveLogin(ctx context.Context, loginID uuid.UUID, userID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE qr_logins SET user_profile_id = $2
+		WHERE login_id = $1 AND user_profile_id IS NULL AND denied_at IS NULL AND expires_at > NOW()`, loginID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to approve qr login %s: %w", loginID.String(), err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// DenyLogin records the user's refusal of a pending login.
+// Returns ErrAlreadyUsed if the login was decided before or expired.
+func (r *qrLoginRepository) DenyLogin(ctx context.Context, loginID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE qr_logins SET denied_at = NOW()
+		WHERE login_id = $1 AND user_profile_id IS NULL AND denied_at IS NULL AND expires_at > NOW()`, loginID)
+	if err != nil {
+		return fmt.Errorf("failed to deny qr login %s: %w", loginID.String(), err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// ConsumeLogin atomically removes an approved, unexpired login once the browser's
+// session is opened. Returns ErrAlreadyUsed if a concurrent poll redeemed it first.
+func (r *qrLoginRepository) ConsumeLogin(ctx context.Context, loginID uuid.UUID) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM qr_logins
+		WHERE login_id = $1 AND user_profile_id IS NOT NULL AND expires_at > NOW()`, loginID)
+	if err != nil {
+		return fmt.Errorf("failed to consume qr login %s: %w", loginID.String(), err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// scanQRLogin maps a qr_logins row to a QRLogin
+func scanQRLogin(row rowScanner) (*QRLogin, error) {
+	var login QRLogin
+	err := row.Scan(
+		&login.LoginID,
+		&login.PollTokenHash,
+		&login.ScanCodeHash,
+		&login.FingerprintHash,
+		&login.Platform,
+		&login.Browser,
+		&login.IPAddress,
+		&login.UserID,
+		&login.ScannedAt,
+		&login.DeniedAt,
+		&login.ExpiresAt,
+		&login.CreatedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan qr login: %w", err)
+	}
+	return &login, nil
+}
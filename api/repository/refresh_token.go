@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RefreshToken represents an issued refresh token within a session's token family
+type RefreshToken struct {
+	TokenHash string
+	SessionID uuid.UUID
+	UserID    uuid.UUID
+	KeyID     string
+	IssuedAt  time.Time
+	UsedAt    sql.NullTime
+}
+
+// RefreshTokenRepository tracks issued refresh tokens to enforce single use
+type RefreshTokenRepository interface {
+	CreateRefreshToken(ctx context.Context, token *RefreshToken) error
+	GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error)
+	MarkRefreshTokenUsed(ctx context.Context, tokenHash string) error
+}
+
+type refreshTokenRepository struct {
+	db *sql.DB
+}
+
+// NewRefreshTokenRepository creates a refresh token repository backed by PostgreSQL
+func NewRefreshTokenRepository(db *sql.DB) RefreshTokenRepository {
+	return &refreshTokenRepository{db: db}
+}
+
+// CreateRefreshToken records a newly issued refresh token
+func (r *refreshTokenRepository) CreateRefreshToken(ctx context.Context, token *RefreshToken) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO refresh_tokens (token_hash, session_id, user_profile_id, key_id)
+		VALUES ($1, $2, $3, $4)
+		RETURNING issued_at`,
+		token.TokenHash, token.SessionID, token.UserID, token.KeyID,
+	).Scan(&token.IssuedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record refresh token for session %s: %w", token.SessionID.String(), err)
+	}
+	return nil
+}
+
+// GetRefreshToken retrieves a refresh token record by its hash
+func (r *refreshTokenRepository) GetRefreshToken(ctx context.Context, tokenHash string) (*RefreshToken, error) {
+	var token RefreshToken
+	err := r.db.QueryRowContext(ctx, `
+		SELECT token_hash, session_id, user_profile_id, key_id, issued_at, used_at
+		FROM refresh_tokens WHERE token_hash = $1`, tokenHash,
+	).Scan(&token.TokenHash, &token.SessionID, &token.UserID, &token.KeyID, &token.IssuedAt, &token.UsedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get refresh token: %w", err)
+	}
+	return &token, nil
+}
+
+// MarkRefreshTokenUsed atomically consumes a refresh token.
+// Returns ErrAlreadyUsed if the token was consumed before, including by a concurrent request.
+func (r *refreshTokenRepository) MarkRefreshTokenUsed(ctx context.Context, tokenHash string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE refresh_tokens SET used_at = NOW()
+		WHERE token_hash = $1 AND used_at IS NULL`, tokenHash)
+	if err != nil {
+		return fmt.Errorf("failed to mark refresh token used: %w", err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+)
+
+var (
+	// ErrNotFound is returned when a requested record does not exist
+	ErrNotFound = errors.New("record not found")
+
+	// ErrAlreadyUsed is returned when a single-use record is consumed a second time
+	ErrAlreadyUsed = errors.New("record already used")
+
+	// ErrDuplicate is returned when inserting a record whose key already exists
+	ErrDuplicate = errors.New("record already exists")
+)
+
+// expectRows returns ErrNotFound when a write statement matched no rows
+func expectRows(result sql.Result) error {
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,213 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session represents a persisted device login backed by a JWK session key.
+// ClientID and Scope are set for sessions opened through the OAuth authorization server.
+// AuthTime and AuthMethods describe the authentication that opened the session.
+// FingerprintAlgorithm, Platform, Browser and Version describe the device fingerprint.
+type Session struct {
+	SessionID  uuid.UUID
+	UserID     uuid.UUID
+	KeyID      string
+	DeviceType string
+	// FingerprintAlgorithm is the version of the fingerprint algorithm of the device
+	FingerprintAlgorithm int
+	Platform             string
+	Browser              string
+	Version              string
+	IPAddress            string
+	ClientID             uuid.NullUUID
+	Scope                string
+	AuthTime             time.Time
+	AuthMethods          []string
+	CreatedAt            time.Time
+	LastRefreshedAt      sql.NullTime
+}
+
+// SessionRepository manages persisted session records
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *Session) error
+	GetSession(ctx context.Context, sessionID uuid.UUID) (*Session, error)
+	GetSessionByKeyID(ctx context.Context, keyID string) (*Session, error)
+	ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*Session, error)
+	RotateSessionKey(ctx context.Context, oldKeyID string, newKeyID string, ipAddress string) error
+	UpdateSessionFingerprint(ctx context.Context, session *Session) error
+	DeleteSession(ctx context.Context, sessionID uuid.UUID) error
+	DeleteSessionByKeyID(ctx context.Context, keyID string) error
+	DeleteUserSessions(ctx context.Context, userID uuid.UUID) error
+	DeleteUserDeviceSessions(ctx context.Context, userID uuid.UUID, deviceType string) error
+}
+
+type sessionRepository struct {
+	db *sql.DB
+}
+
+// NewSessionRepository creates a session repository backed by PostgreSQL
+func NewSessionRepository(db *sql.DB) SessionRepository {
+	return &sessionRepository{db: db}
+}
+
+const sessionColumns = `session_id, user_profile_id, key_id, device_type, fingerprint_algorithm, platform,
+	browser, browser_version, ip_address, client_id, scope, auth_time, amr, created_at, last_refreshed_at`
+
+// CreateSession inserts a new session record, assigning its ID and creation time.
+// A zero AuthTime is set to the creation time.
+func (r *sessionRepository) CreateSession(ctx context.Context, session *Session) error {
+	session.SessionID = uuid.New()
+	if session.AuthTime.IsZero() {
+		session.AuthTime = time.Now()
+	}
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO user_sessions (session_id, user_profile_id, key_id, device_type, fingerprint_algorithm, platform, browser, browser_version, ip_address, client_id, scope, auth_time, amr)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		RETURNING created_at`,
+		session.SessionID, session.UserID, session.KeyID, session.DeviceType, session.FingerprintAlgorithm,
+		session.Platform, session.Browser, session.Version, session.IPAddress,
+		session.ClientID, session.Scope, session.AuthTime, strings.Join(session.AuthMethods, " "),
+	).Scan(&session.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create session for user %s: %w", session.UserID.String(), err)
+	}
+	return nil
+}
+
+// GetSession retrieves a session by its ID
+func (r *sessionRepository) GetSession(ctx context.Context, sessionID uuid.UUID) (*Session, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM user_sessions WHERE session_id = $1`, sessionID)
+	return scanSession(row)
+}
+
+// GetSessionByKeyID retrieves the session currently backed by the given key ID
+func (r *sessionRepository) GetSessionByKeyID(ctx context.Context, keyID string) (*Session, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+sessionColumns+` FROM user_sessions WHERE key_id = $1`, keyID)
+	return scanSession(row)
+}
+
+// ListUserSessions returns all session records of a user, newest first
+func (r *sessionRepository) ListUserSessions(ctx context.Context, userID uuid.UUID) ([]*Session, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+sessionColumns+` FROM user_sessions
+		WHERE user_profile_id = $1
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sessions for user %s: %w", userID.String(), err)
+	}
+	defer rows.Close()
+
+	var sessions []*Session
+	for rows.Next() {
+		session, err := scanSession(rows)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, session)
+	}
+	return sessions, rows.Err()
+}
+
+// RotateSessionKey moves a session to the key issued by a token refresh
+func (r *sessionRepository) RotateSessionKey(ctx context.Context, oldKeyID string, newKeyID string, ipAddress string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions
+		SET key_id = $2, ip_address = $3, last_refreshed_at = NOW()
+		WHERE key_id = $1`, oldKeyID, newKeyID, ipAddress)
+	if err != nil {
+		return fmt.Errorf("failed to rotate session key %s: %w", oldKeyID, err)
+	}
+	return expectRows(result)
+}
+
+// UpdateSessionFingerprint records the device fingerprint a refresh moved a session to:
+// the current algorithm, and the browser version its device updated to
+func (r *sessionRepository) UpdateSessionFingerprint(ctx context.Context, session *Session) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_sessions
+		SET fingerprint_algorithm = $2, platform = $3, browser = $4, browser_version = $5
+		WHERE session_id = $1`, session.SessionID, session.FingerprintAlgorithm, session.Platform, session.Browser, session.Version)
+	if err != nil {
+		return fmt.Errorf("failed to update device fingerprint of session %s: %w", session.SessionID.String(), err)
+	}
+	return expectRows(result)
+}
+
+// DeleteSession removes a session record by its ID
+func (r *sessionRepository) DeleteSession(ctx context.Context, sessionID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE session_id = $1`, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session %s: %w", sessionID.String(), err)
+	}
+	return nil
+}
+
+// DeleteSessionByKeyID removes the session record backed by the given key ID
+func (r *sessionRepository) DeleteSessionByKeyID(ctx context.Context, keyID string) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE key_id = $1`, keyID); err != nil {
+		return fmt.Errorf("failed to delete session for key %s: %w", keyID, err)
+	}
+	return nil
+}
+
+// DeleteUserSessions removes all session records of a user
+func (r *sessionRepository) DeleteUserSessions(ctx context.Context, userID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM user_sessions WHERE user_profile_id = $1`, userID); err != nil {
+		return fmt.Errorf("failed to delete sessions for user %s: %w", userID.String(), err)
+	}
+	return nil
+}
+
+// DeleteUserDeviceSessions removes the session records of a user for one device type.
+// A new login replaces the session key of that device type, so older records are stale.
+func (r *sessionRepository) DeleteUserDeviceSessions(ctx context.Context, userID uuid.UUID, deviceType string) error {
+	_, err := r.db.ExecContext(ctx, `
+		DELETE FROM user_sessions
+		WHERE user_profile_id = $1 AND device_type = $2`, userID, deviceType)
+	if err != nil {
+		return fmt.Errorf("failed to delete %s sessions for user %s: %w", deviceType, userID.String(), err)
+	}
+	return nil
+}
+
+// rowScanner is satisfied by both *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanSession maps a user_sessions row to a Session
+func scanSession(row rowScanner) (*Session, error) {
+	var session Session
+	var authMethods string
+	err := row.Scan(
+		&session.SessionID,
+		&session.UserID,
+		&session.KeyID,
+		&session.DeviceType,
+		&session.FingerprintAlgorithm,
+		&session.Platform,
+		&session.Browser,
+		&session.Version,
+		&session.IPAddress,
+		&session.ClientID,
+		&session.Scope,
+		&session.AuthTime,
+		&authMethods,
+		&session.CreatedAt,
+		&session.LastRefreshedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan session: %w", err)
+	}
+	session.AuthMethods = strings.Fields(authMethods)
+	return &session, nil
+}
@@ -0,0 +1,129 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+	jwkrepository "github.com/sushan531/jwk-auth/core/repository"
+)
+
+// KeysetRepository stores the keysets session keys are kept in. A user's keyset stays
+// with the user's auth record; owners registered with RegisterOwner, such as OAuth
+// clients, keep theirs in signing_keysets, apart from every user account.
+type KeysetRepository interface {
+	jwkrepository.UserAuthRepository
+	// RegisterOwner makes signing_keysets the home of ownerID's keys
+	RegisterOwner(ctx context.Context, ownerID uuid.UUID) error
+	// DeleteOwner removes a registered owner together with its keys
+	DeleteOwner(ctx context.Context, ownerID uuid.UUID) error
+}
+
+type keysetRepository struct {
+	db    *sql.DB
+	users jwkrepository.UserAuthRepository
+}
+
+// NewKeysetRepository creates a keyset repository that keeps registered owners' keysets
+// in PostgreSQL and passes every other owner on to the users' keyset repository
+func NewKeysetRepository(db *sql.DB, users jwkrepository.UserAuthRepository) KeysetRepository {
+	return &keysetRepository{db: db, users: users}
+}
+
+// RegisterOwner adds an owner without keys; registering it again keeps its keys
+func (r *keysetRepository) RegisterOwner(ctx context.Context, ownerID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO signing_keysets (owner_id) VALUES ($1)
+		ON CONFLICT (owner_id) DO NOTHING`, ownerID)
+	if err != nil {
+		return fmt.Errorf("failed to register keyset owner %s: %w", ownerID.String(), err)
+	}
+	return nil
+}
+
+// DeleteOwner removes a registered owner and its keys. Unknown owners are not an error.
+func (r *keysetRepository) DeleteOwner(ctx context.Context, ownerID uuid.UUID) error {
+	if _, err := r.db.ExecContext(ctx, `DELETE FROM signing_keysets WHERE owner_id = $1`, ownerID); err != nil {
+		return fmt.Errorf("failed to delete keyset owner %s: %w", ownerID.String(), err)
+	}
+	return nil
+}
+
+// SaveUserKeyset saves the keyset of a registered owner, or of a user otherwise
+func (r *keysetRepository) SaveUserKeyset(userID uuid.UUID, keyData string, encryptionKey string) error {
+	result, err := r.db.ExecContext(context.Background(), `
+		UPDATE signing_keysets SET keyset_data = $2, encryption_key = $3, updated_at = NOW()
+		WHERE owner_id = $1`, userID, keyData, encryptionKey)
+	if err != nil {
+		return fmt.Errorf("failed to save keyset of %s: %w", userID.String(), err)
+	}
+	if err := expectRows(result); errors.Is(err, ErrNotFound) {
+		return r.users.SaveUserKeyset(userID, keyData, encryptionKey)
+	} else if err != nil {
+		return fmt.Errorf("failed to save keyset of %s: %w", userID.String(), err)
+	}
+	return nil
+}
+
+// GetUserKeyset returns the keyset of a registered owner, or of a user otherwise.
+// A registered owner without keys has no keyset, reported with the message jwk-auth
+// expects for a missing keyset.
+func (r *keysetRepository) GetUserKeyset(userID uuid.UUID) (*jwkrepository.UserKeyset, error) {
+	keyset := &jwkrepository.UserKeyset{UserID: userID}
+	err := r.db.QueryRowContext(context.Background(), `
+		SELECT keyset_data, encryption_key FROM signing_keysets WHERE owner_id = $1`, userID,
+	).Scan(&keyset.KeyData, &keyset.EncryptionKey)
+	if errors.Is(err, sql.ErrNoRows) {
+		return r.users.GetUserKeyset(userID)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get keyset of %s: %w", userID.String(), err)
+	}
+	if keyset.KeyData == "" {
+		return nil, fmt.Errorf("no keyset found for user %s", userID.String())
+	}
+	return keyset, nil
+}
+
+// DeleteUserKeyset empties the keyset of a registered owner, which stays registered,
+// or deletes a user's keyset otherwise
+func (r *keysetRepository) DeleteUserKeyset(userID uuid.UUID) error {
+	result, err := r.db.ExecContext(context.Background(), `
+		UPDATE signing_keysets SET keyset_data = '', encryption_key = '', updated_at = NOW()
+		WHERE owner_id = $1`, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete keyset of %s: %w", userID.String(), err)
+	}
+	if err := expectRows(result); errors.Is(err, ErrNotFound) {
+		return r.users.DeleteUserKeyset(userID)
+	} else if err != nil {
+		return fmt.Errorf("failed to delete keyset of %s: %w", userID.String(), err)
+	}
+	return nil
+}
+
+// GetAllUserKeysets returns the keysets of all users and registered owners
+func (r *keysetRepository) GetAllUserKeysets() ([]*jwkrepository.UserKeyset, error) {
+	keysets, err := r.users.GetAllUserKeysets()
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := r.db.QueryContext(context.Background(), `
+		SELECT owner_id, keyset_data, encryption_key FROM signing_keysets WHERE keyset_data <> ''`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query signing keysets: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var keyset jwkrepository.UserKeyset
+		if err := rows.Scan(&keyset.UserID, &keyset.KeyData, &keyset.EncryptionKey); err != nil {
+			return nil, fmt.Errorf("failed to scan signing keyset: %w", err)
+		}
+		keysets = append(keysets, &keyset)
+	}
+	return keysets, rows.Err()
+}
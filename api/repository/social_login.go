@@ -0,0 +1,130 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// SocialLoginState represents a login in progress at an external OpenID Connect provider.
+// BindingHash is the hash of the browser binding cookie of the browser that started it.
+type SocialLoginState struct {
+	StateHash    string
+	Provider     string
+	Nonce        string
+	CodeVerifier string
+	BindingHash  string
+	ExpiresAt    time.Time
+	CreatedAt    time.Time
+}
+
+// UserIdentity represents a provider account linked to a profile
+type UserIdentity struct {
+	Provider    string
+	Subject     string
+	UserID      uuid.UUID
+	Email       string
+	CreatedAt   time.Time
+	LastLoginAt sql.NullTime
+}
+
+// SocialLoginRepository manages social login states and linked identities
+type SocialLoginRepository interface {
+	CreateState(ctx context.Context, state *SocialLoginState) error
+	ConsumeState(ctx context.Context, stateHash string) (*SocialLoginState, error)
+	GetIdentity(ctx context.Context, provider string, subject string) (*UserIdentity, error)
+	CreateIdentity(ctx context.Context, identity *UserIdentity) error
+	TouchIdentity(ctx context.Context, provider string, subject string) error
+}
+
+type socialLoginRepository struct {
+	db *sql.DB
+}
+
+// NewSocialLoginRepository creates a social login repository backed by PostgreSQL
+func NewSocialLoginRepository(db *sql.DB) SocialLoginRepository {
+	return &socialLoginRepository{db: db}
+}
+
+// CreateState stores a new login state
+func (r *socialLoginRepository) CreateState(ctx context.Context, state *SocialLoginState) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO social_login_states (state_hash, provider, nonce, code_verifier, binding_hash, expires_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING created_at`,
+		state.StateHash, state.Provider, state.Nonce, state.CodeVerifier, state.BindingHash, state.ExpiresAt,
+	).Scan(&state.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to create %s login state: %w", state.Provider, err)
+	}
+	return nil
+}
+
+// ConsumeState atomically deletes an unexpired login state and returns it.
+// Returns ErrNotFound if the state does not exist, is expired or was already used.
+func (r *socialLoginRepository) ConsumeState(ctx context.Context, stateHash string) (*SocialLoginState, error) {
+	var state SocialLoginState
+	err := r.db.QueryRowContext(ctx, `
+		DELETE FROM social_login_states
+		WHERE state_hash = $1 AND expires_at > NOW()
+		RETURNING state_hash, provider, nonce, code_verifier, binding_hash, expires_at, created_at`, stateHash,
+	).Scan(&state.StateHash, &state.Provider, &state.Nonce, &state.CodeVerifier, &state.BindingHash, &state.ExpiresAt, &state.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to consume login state: %w", err)
+	}
+	return &state, nil
+}
+
+// GetIdentity retrieves the identity linked to a provider subject
+func (r *socialLoginRepository) GetIdentity(ctx context.Context, provider string, subject string) (*UserIdentity, error) {
+	var identity UserIdentity
+	err := r.db.QueryRowContext(ctx, `
+		SELECT provider, subject, user_profile_id, email, created_at, last_login_at
+		FROM user_identities
+		WHERE provider = $1 AND subject = $2`, provider, subject,
+	).Scan(&identity.Provider, &identity.Subject, &identity.UserID, &identity.Email, &identity.CreatedAt, &identity.LastLoginAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to get %s identity: %w", provider, err)
+	}
+	return &identity, nil
+}
+
+// CreateIdentity links a provider subject to a profile.
+// Returns ErrDuplicate if the subject is already linked.
+func (r *socialLoginRepository) CreateIdentity(ctx context.Context, identity *UserIdentity) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO user_identities (provider, subject, user_profile_id, email, last_login_at)
+		VALUES ($1, $2, $3, $4, NOW())
+		ON CONFLICT (provider, subject) DO NOTHING
+		RETURNING created_at`,
+		identity.Provider, identity.Subject, identity.UserID, identity.Email,
+	).Scan(&identity.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to link %s identity to user %s: %w", identity.Provider, identity.UserID.String(), err)
+	}
+	return nil
+}
+
+// TouchIdentity records a login through a linked identity
+func (r *socialLoginRepository) TouchIdentity(ctx context.Context, provider string, subject string) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE user_identities SET last_login_at = NOW()
+		WHERE provider = $1 AND subject = $2`, provider, subject)
+	if err != nil {
+		return fmt.Errorf("failed to update %s identity: %w", provider, err)
+	}
+	return expectRows(result)
+}
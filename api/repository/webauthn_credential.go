@@ -0,0 +1,154 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// WebAuthnCredential represents a registered passkey
+type WebAuthnCredential struct {
+	CredentialID    string
+	UserID          uuid.UUID
+	Name            string
+	PublicKey       []byte
+	SignCount       int64
+	AAGUID          []byte
+	AttestationType string
+	Transports      []string
+	CreatedAt       time.Time
+	LastUsedAt      sql.NullTime
+}
+
+// WebAuthnCredentialRepository manages registered passkeys
+type WebAuthnCredentialRepository interface {
+	CreateCredential(ctx context.Context, credential *WebAuthnCredential) error
+	GetCredential(ctx context.Context, credentialID string) (*WebAuthnCredential, error)
+	ListUserCredentials(ctx context.Context, userID uuid.UUID) ([]*WebAuthnCredential, error)
+	UpdateSignCount(ctx context.Context, credentialID string, oldCount int64, newCount int64) error
+	DeleteCredential(ctx context.Context, userID uuid.UUID, credentialID string) error
+}
+
+type webAuthnCredentialRepository struct {
+	db *sql.DB
+}
+
+// NewWebAuthnCredentialRepository creates a WebAuthn credential repository backed by PostgreSQL
+func NewWebAuthnCredentialRepository(db *sql.DB) WebAuthnCredentialRepository {
+	return &webAuthnCredentialRepository{db: db}
+}
+
+const webAuthnCredentialColumns = `credential_id, user_profile_id, name, public_key, sign_count,
+	aaguid, attestation_type, transports, created_at, last_used_at`
+
+// CreateCredential stores a newly registered credential.
+// Returns ErrDuplicate if the credential ID is already registered.
+func (r *webAuthnCredentialRepository) CreateCredential(ctx context.Context, credential *WebAuthnCredential) error {
+	err := r.db.QueryRowContext(ctx, `
+		INSERT INTO webauthn_credentials (credential_id, user_profile_id, name, public_key, sign_count, aaguid, attestation_type, transports)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (credential_id) DO NOTHING
+		RETURNING created_at`,
+		credential.CredentialID, credential.UserID, credential.Name, credential.PublicKey, credential.SignCount,
+		credential.AAGUID, credential.AttestationType, strings.Join(credential.Transports, ","),
+	).Scan(&credential.CreatedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return ErrDuplicate
+		}
+		return fmt.Errorf("failed to create webauthn credential for user %s: %w", credential.UserID.String(), err)
+	}
+	return nil
+}
+
+// GetCredential retrieves a credential by its ID
+func (r *webAuthnCredentialRepository) GetCredential(ctx context.Context, credentialID string) (*WebAuthnCredential, error) {
+	row := r.db.QueryRowContext(ctx, `SELECT `+webAuthnCredentialColumns+` FROM webauthn_credentials WHERE credential_id = $1`, credentialID)
+	return scanWebAuthnCredential(row)
+}
+
+// ListUserCredentials returns all credentials of a user, newest first
+func (r *webAuthnCredentialRepository) ListUserCredentials(ctx context.Context, userID uuid.UUID) ([]*WebAuthnCredential, error) {
+	rows, err := r.db.QueryContext(ctx, `
+		SELECT `+webAuthnCredentialColumns+` FROM webauthn_credentials
+		WHERE user_profile_id = $1
+		ORDER BY created_at DESC`, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query webauthn credentials for user %s: %w", userID.String(), err)
+	}
+	defer rows.Close()
+
+	var credentials []*WebAuthnCredential
+	for rows.Next() {
+		credential, err := scanWebAuthnCredential(rows)
+		if err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+	return credentials, rows.Err()
+}
+
+// UpdateSignCount records a successful authentication. The update only applies while the
+// stored counter still equals oldCount, so two concurrent logins with one counter value
+// cannot both succeed; the loser gets ErrAlreadyUsed.
+func (r *webAuthnCredentialRepository) UpdateSignCount(ctx context.Context, credentialID string, oldCount int64, newCount int64) error {
+	result, err := r.db.ExecContext(ctx, `
+		UPDATE webauthn_credentials SET sign_count = $3, last_used_at = NOW()
+		WHERE credential_id = $1 AND sign_count = $2`, credentialID, oldCount, newCount)
+	if err != nil {
+		return fmt.Errorf("failed to update sign count of webauthn credential: %w", err)
+	}
+	if err := expectRows(result); err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return ErrAlreadyUsed
+		}
+		return err
+	}
+	return nil
+}
+
+// DeleteCredential removes a credential owned by the user.
+// Returns ErrNotFound if the user has no such credential.
+func (r *webAuthnCredentialRepository) DeleteCredential(ctx context.Context, userID uuid.UUID, credentialID string) error {
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM webauthn_credentials
+		WHERE credential_id = $1 AND user_profile_id = $2`, credentialID, userID)
+	if err != nil {
+		return fmt.Errorf("failed to delete webauthn credential for user %s: %w", userID.String(), err)
+	}
+	return expectRows(result)
+}
+
+// scanWebAuthnCredential maps a webauthn_credentials row to a WebAuthnCredential
+func scanWebAuthnCredential(row rowScanner) (*WebAuthnCredential, error) {
+	var credential WebAuthnCredential
+	var transports string
+	err := row.Scan(
+		&credential.CredentialID,
+		&credential.UserID,
+		&credential.Name,
+		&credential.PublicKey,
+		&credential.SignCount,
+		&credential.AAGUID,
+		&credential.AttestationType,
+		&transports,
+		&credential.CreatedAt,
+		&credential.LastUsedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("failed to scan webauthn credential: %w", err)
+	}
+	if transports != "" {
+		credential.Transports = strings.Split(transports, ",")
+	}
+	return &credential, nil
+}
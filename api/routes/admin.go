@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"fiber-api/api/handlers"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"fiber-api/pkg/mailer"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/manager"
+)
+
+func AdminRouter(route fiber.Router, queries *generated.Queries, signup config.SignupConfig, tokens repository.OneTimeTokenRepository, mail mailer.Mailer, verification config.EmailVerificationConfig, attempts repository.LoginAttemptRepository, jwkManager manager.JwkManager, clients repository.OAuthClientRepository, oauth config.OAuthConfig) {
+	route.Post("/users", handlers.AdminCreateUserHandler(queries, signup, tokens, mail, verification))
+	route.Post("/users/:id/unlock", handlers.AdminUnlockUserHandler(queries, attempts))
+	route.Post("/oauth/clients", handlers.AdminCreateOAuthClientHandler(clients, oauth))
+	route.Get("/oauth/clients", handlers.AdminListOAuthClientsHandler(clients))
+	route.Delete("/oauth/clients/:id", handlers.AdminDeleteOAuthClientHandler(jwkManager, clients))
+}
@@ -2,15 +2,19 @@ package routes
 
 import (
 	"fiber-api/api/handlers"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"fiber-api/pkg/mailer"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/sushan531/auth-sqlc/generated"
-	"github.com/sushan531/jwk-auth/core/manager"
-	"github.com/sushan531/jwk-auth/service"
 )
 
-func AuthRouter(route fiber.Router, queries *generated.Queries, jwkManager manager.JwkManager, tokenService service.TokenService) {
-	route.Post("/signup", handlers.UserSignUpHandler(queries))
-	route.Post("/login", handlers.LoginHandler(queries, jwkManager, tokenService))
-	route.Post("/refresh", handlers.RefreshTokenHandler(queries, tokenService))
+func AuthRouter(route fiber.Router, queries *generated.Queries, issuer *helpers.SessionIssuer, signup config.SignupConfig, tokens repository.OneTimeTokenRepository, verifications repository.EmailVerificationRepository, mail mailer.Mailer, verification config.EmailVerificationConfig, mfa repository.MFARepository, mfaConfig config.MFAConfig, attempts repository.LoginAttemptRepository, lockout config.LockoutConfig, limiter *middleware.RateLimiter) {
+	route.Post("/signup", limiter.For(config.RateLimitRouteSignup), handlers.UserSignUpHandler(queries, signup, tokens, mail, verification))
+	route.Post("/login", limiter.For(config.RateLimitRouteLogin), handlers.LoginHandler(queries, issuer, verifications, verification, tokens, mfa, mfaConfig, attempts, lockout))
+	route.Post("/login/mfa", limiter.For(config.RateLimitRouteLoginMFA), handlers.LoginMFAHandler(issuer, tokens, mfa, mfaConfig))
+	route.Post("/refresh", limiter.For(config.RateLimitRouteRefresh), handlers.RefreshTokenHandler(issuer))
 }
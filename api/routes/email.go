@@ -0,0 +1,17 @@
+package routes
+
+import (
+	"fiber-api/api/handlers"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"fiber-api/pkg/mailer"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+)
+
+func EmailRouter(route fiber.Router, queries *generated.Queries, tokens repository.OneTimeTokenRepository, verifications repository.EmailVerificationRepository, mail mailer.Mailer, cfg config.EmailVerificationConfig) {
+	route.Get("/verify", handlers.VerifyEmailHandler(tokens, verifications))
+	route.Post("/verify", handlers.VerifyEmailHandler(tokens, verifications))
+	route.Post("/verify/resend", handlers.ResendVerificationHandler(queries, tokens, verifications, mail, cfg))
+}
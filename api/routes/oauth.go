@@ -0,0 +1,30 @@
+package routes
+
+import (
+	"fiber-api/api/handlers"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/manager"
+	"github.com/sushan531/jwk-auth/service"
+)
+
+// OAuthRouter registers the OAuth 2.0 endpoints. The consent and device verification
+// endpoints run behind requireAuth and userinfo behind requireClientAuth; the others are
+// public or authenticate the calling client themselves.
+func OAuthRouter(route fiber.Router, jwkManager manager.JwkManager, tokenService service.TokenService, queries *generated.Queries, issuer *helpers.SessionIssuer, sessions repository.SessionRepository, refreshTokens repository.RefreshTokenRepository, clients repository.OAuthClientRepository, authorizations repository.OAuthAuthorizationRepository, devices repository.OAuthDeviceAuthorizationRepository, verifications repository.EmailVerificationRepository, cfg config.OAuthConfig, oidc config.OIDCConfig, requireAuth fiber.Handler, requireClientAuth fiber.Handler, limiter *middleware.RateLimiter) {
+	route.Get("/authorize", handlers.AuthorizeHandler(clients, authorizations, cfg))
+	route.Get("/authorize/requests/:id", requireAuth, handlers.GetAuthorizationRequestHandler(clients, authorizations))
+	route.Post("/authorize/requests/:id", requireAuth, handlers.DecideAuthorizationHandler(sessions, authorizations, cfg))
+	route.Post("/device_authorization", handlers.DeviceAuthorizationHandler(clients, devices, cfg))
+	route.Get("/device/requests/:user_code", requireAuth, limiter.For(config.RateLimitRouteDeviceVerification), handlers.GetDeviceAuthorizationHandler(clients, devices))
+	route.Post("/device/requests/:user_code", requireAuth, limiter.For(config.RateLimitRouteDeviceVerification), handlers.DecideDeviceAuthorizationHandler(sessions, devices))
+	route.Post("/token", handlers.TokenHandler(issuer, clients, authorizations, devices, oidc))
+	route.Get("/userinfo", requireClientAuth, handlers.UserInfoHandler(queries, verifications))
+	route.Post("/introspect", handlers.IntrospectTokenHandler(tokenService, sessions, refreshTokens, clients))
+	route.Post("/revoke", handlers.RevokeTokenHandler(jwkManager, tokenService, sessions, refreshTokens, clients))
+}
@@ -0,0 +1,15 @@
+package routes
+
+import (
+	"fiber-api/api/handlers"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// WellKnownRouter registers the public discovery documents
+func WellKnownRouter(route fiber.Router, jwks *helpers.JWKSCache, cfg config.OIDCConfig, oauth config.OAuthConfig) {
+	route.Get("/jwks.json", handlers.JWKSHandler(jwks, cfg))
+	route.Get("/openid-configuration", handlers.OpenIDConfigurationHandler(cfg, oauth))
+}
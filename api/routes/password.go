@@ -0,0 +1,18 @@
+package routes
+
+import (
+	"fiber-api/api/handlers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"fiber-api/pkg/mailer"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/manager"
+)
+
+func PasswordRouter(route fiber.Router, queries *generated.Queries, jwkManager manager.JwkManager, sessions repository.SessionRepository, tokens repository.OneTimeTokenRepository, mail mailer.Mailer, cfg config.PasswordResetConfig, limiter *middleware.RateLimiter) {
+	route.Post("/forgot", limiter.For(config.RateLimitRouteForgotPassword), handlers.ForgotPasswordHandler(queries, tokens, mail, cfg))
+	route.Post("/reset", handlers.ResetPasswordHandler(queries, jwkManager, sessions, tokens))
+}
@@ -0,0 +1,20 @@
+package routes
+
+import (
+	"fiber-api/api/handlers"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// QRLoginRouter registers the cross-device QR login routes. Browsers start and poll a
+// login; the scan and approval routes run behind requireAuth for the native app.
+func QRLoginRouter(route fiber.Router, issuer *helpers.SessionIssuer, sessions repository.SessionRepository, logins repository.QRLoginRepository, cfg config.QRLoginConfig, requireAuth fiber.Handler, limiter *middleware.RateLimiter) {
+	route.Post("/", limiter.For(config.RateLimitRouteQRLogin), handlers.StartQRLoginHandler(logins, issuer.Fingerprints, cfg))
+	route.Post("/poll", limiter.For(config.RateLimitRouteQRLoginPoll), handlers.PollQRLoginHandler(issuer, logins))
+	route.Post("/scan", requireAuth, handlers.ScanQRLoginHandler(sessions, logins))
+	route.Post("/approve", requireAuth, handlers.DecideQRLoginHandler(sessions, logins))
+}
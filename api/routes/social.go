@@ -0,0 +1,19 @@
+package routes
+
+import (
+	"fiber-api/api/handlers"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"fiber-api/pkg/oidc"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+)
+
+// SocialRouter registers the login routes of external OpenID Connect providers
+func SocialRouter(route fiber.Router, queries *generated.Queries, issuer *helpers.SessionIssuer, providers map[string]*oidc.Provider, logins repository.SocialLoginRepository, verifications repository.EmailVerificationRepository, verification config.EmailVerificationConfig, signup config.SignupConfig, tokens repository.OneTimeTokenRepository, mfa repository.MFARepository, mfaConfig config.MFAConfig, cfg config.SocialLoginConfig) {
+	route.Get("/providers", handlers.ListSocialProvidersHandler(providers))
+	route.Post("/:provider/begin", handlers.BeginSocialLoginHandler(providers, logins, cfg))
+	route.Post("/:provider/finish", handlers.FinishSocialLoginHandler(queries, issuer, providers, logins, verifications, verification, signup, tokens, mfa, mfaConfig))
+}
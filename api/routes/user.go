@@ -2,11 +2,23 @@ package routes
 
 import (
 	"fiber-api/api/handlers"
+	"fiber-api/api/middleware"
+	"fiber-api/api/repository"
+	"fiber-api/config"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/sushan531/auth-sqlc/generated"
+	"github.com/sushan531/jwk-auth/core/manager"
 )
 
-func UserRouter(route fiber.Router, queries *generated.Queries) {
-	route.Get("/profile", handlers.GetProfileHandler(queries))
+func UserRouter(route fiber.Router, queries *generated.Queries, jwkManager manager.JwkManager, sessions repository.SessionRepository, rbac config.RBACConfig, mfa repository.MFARepository, mfaConfig config.MFAConfig) {
+	route.Get("/profile", middleware.RequirePermission(rbac, config.PermissionProfileRead), handlers.GetProfileHandler(queries))
+	route.Post("/logout", handlers.LogoutHandler(jwkManager, sessions))
+	route.Post("/logout-all", handlers.LogoutAllHandler(jwkManager, sessions))
+	route.Post("/password", handlers.ChangePasswordHandler(queries, jwkManager, sessions))
+	route.Get("/sessions", middleware.RequirePermission(rbac, config.PermissionSessionsManage), handlers.ListSessionsHandler(jwkManager, sessions))
+	route.Delete("/sessions/:id", middleware.RequirePermission(rbac, config.PermissionSessionsManage), handlers.RevokeSessionHandler(jwkManager, sessions))
+	route.Post("/mfa/enroll", handlers.EnrollMFAHandler(queries, mfa, mfaConfig))
+	route.Post("/mfa/confirm", handlers.ConfirmMFAHandler(queries, mfa, mfaConfig))
+	route.Post("/mfa/disable", handlers.DisableMFAHandler(queries, mfa, mfaConfig))
 }
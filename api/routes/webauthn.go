@@ -0,0 +1,23 @@
+package routes
+
+import (
+	"fiber-api/api/handlers"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/repository"
+	"fiber-api/config"
+	"fiber-api/pkg/webauthn"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/sushan531/auth-sqlc/generated"
+)
+
+// WebAuthnRouter registers the passkey routes. Registration and credential management
+// run behind requireAuth; the login ceremony is public.
+func WebAuthnRouter(route fiber.Router, queries *generated.Queries, rp *webauthn.RelyingParty, issuer *helpers.SessionIssuer, tokens repository.OneTimeTokenRepository, credentials repository.WebAuthnCredentialRepository, verifications repository.EmailVerificationRepository, verification config.EmailVerificationConfig, requireAuth fiber.Handler) {
+	route.Post("/register/begin", requireAuth, handlers.BeginWebAuthnRegistrationHandler(queries, rp, tokens, credentials))
+	route.Post("/register/finish", requireAuth, handlers.FinishWebAuthnRegistrationHandler(rp, tokens, credentials))
+	route.Post("/login/begin", handlers.BeginWebAuthnLoginHandler(queries, rp, tokens, credentials))
+	route.Post("/login/finish", handlers.FinishWebAuthnLoginHandler(issuer, rp, tokens, credentials, verifications, verification))
+	route.Get("/credentials", requireAuth, handlers.ListWebAuthnCredentialsHandler(credentials))
+	route.Delete("/credentials/:id", requireAuth, handlers.DeleteWebAuthnCredentialHandler(credentials))
+}
@@ -2,11 +2,20 @@ package services
 
 import (
 	"database/sql"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/repository"
+	"fiber-api/pkg/fingerprint"
+	"fiber-api/pkg/mailer"
+	"fiber-api/pkg/nativeapp"
+	"fiber-api/pkg/oidc"
+	"fiber-api/pkg/useragent"
+	"fiber-api/pkg/webauthn"
+	"time"
 
 	"github.com/sushan531/auth-sqlc/generated"
 	"github.com/sushan531/jwk-auth/core/config"
 	"github.com/sushan531/jwk-auth/core/manager"
-	"github.com/sushan531/jwk-auth/core/repository"
+	jwkrepository "github.com/sushan531/jwk-auth/core/repository"
 	"github.com/sushan531/jwk-auth/service"
 )
 
@@ -14,15 +23,45 @@ import (
 type AuthAPIServiceConfig struct {
 	DatabaseURL string
 	Config      *config.Config
+	Mail        mailer.Config
+	WebAuthn    webauthn.Config
+	Social      []oidc.Config
+	// UserAgent is the number of parsed User-Agent strings to cache
+	UserAgent   int
+	Fingerprint fingerprint.Config
+	NativeApps  nativeapp.Config
+	// JWKSCacheTTL is how long the published signing keys are served from memory
+	JWKSCacheTTL time.Duration
 }
 
 // AuthAPIService encapsulates all auth-related dependencies and functionality
 type AuthAPIService struct {
-	DB           *sql.DB
-	Queries      *generated.Queries
-	JWKManager   manager.JwkManager
-	TokenService service.TokenService
-	Config       *config.Config
+	DB                  *sql.DB
+	Queries             *generated.Queries
+	JWKManager          manager.JwkManager
+	Keysets             repository.KeysetRepository
+	JWKS                *helpers.JWKSCache
+	TokenService        service.TokenService
+	Sessions            repository.SessionRepository
+	RefreshTokens       repository.RefreshTokenRepository
+	OneTimeTokens       repository.OneTimeTokenRepository
+	Verifications       repository.EmailVerificationRepository
+	MFA                 repository.MFARepository
+	Passkeys            repository.WebAuthnCredentialRepository
+	LoginAttempts       repository.LoginAttemptRepository
+	OAuthClients        repository.OAuthClientRepository
+	OAuthAuthorizations repository.OAuthAuthorizationRepository
+	OAuthDevices        repository.OAuthDeviceAuthorizationRepository
+	SocialLogins        repository.SocialLoginRepository
+	QRLogins            repository.QRLoginRepository
+	WebAuthn            *webauthn.RelyingParty
+	SocialProviders     map[string]*oidc.Provider
+	UserAgents          *useragent.Parser
+	Fingerprints        *fingerprint.Generator
+	NativeApps          *nativeapp.Verifier
+	Issuer              *helpers.SessionIssuer
+	Mailer              mailer.Mailer
+	Config              *config.Config
 }
 
 // NewAuthAPIService creates a new auth manager with all dependencies initialized
@@ -37,17 +76,96 @@ func NewAuthAPIService(cfg AuthAPIServiceConfig) (*AuthAPIService, error) {
 	queries := generated.New(db)
 
 	// Initialize repositories and managers
-	userRepo := repository.NewUserAuthRepository(queries)
-	jwkManager := manager.NewJwkManager(userRepo, cfg.Config)
-	jwtManager := manager.NewJwtManager(jwkManager)
+	// Keys of OAuth clients are kept apart from every user's keyset
+	keysets := repository.NewKeysetRepository(db, jwkrepository.NewUserAuthRepository(queries))
+	// Key changes mark the published JWKS out of date
+	jwks := helpers.NewJWKSCache(keysets, cfg.JWKSCacheTTL)
+	jwkManager := helpers.NotifyKeyChanges(manager.NewJwkManager(keysets, cfg.Config), jwks.Invalidate)
+	// Tokens name their signing key in the JOSE header under its published kid
+	jwtManager := helpers.NewJwtManager(jwkManager)
 	tokenService := service.NewTokenService(jwtManager, jwkManager, cfg.Config)
 
+	// Initialize mail delivery
+	mail, err := mailer.New(cfg.Mail)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Initialize the WebAuthn relying party
+	rp, err := webauthn.New(cfg.WebAuthn)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	// Initialize the social login providers
+	providers := make(map[string]*oidc.Provider, len(cfg.Social))
+	for _, providerConfig := range cfg.Social {
+		provider, err := oidc.New(providerConfig)
+		if err != nil {
+			db.Close()
+			return nil, err
+		}
+		providers[provider.Name()] = provider
+	}
+
+	// Build the User-Agent parser once; compiling its regex database is expensive
+	userAgents, err := useragent.New(cfg.UserAgent)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	fingerprints := fingerprint.New(userAgents, cfg.Fingerprint)
+
+	// Initialize the native app signature verifier
+	nativeApps, err := nativeapp.New(cfg.NativeApps)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	sessions := repository.NewSessionRepository(db)
+	refreshTokens := repository.NewRefreshTokenRepository(db)
+	verifications := repository.NewEmailVerificationRepository(db)
+
 	return &AuthAPIService{
-		DB:           db,
-		Queries:      queries,
-		JWKManager:   jwkManager,
-		TokenService: tokenService,
-		Config:       cfg.Config,
+		DB:                  db,
+		Queries:             queries,
+		JWKManager:          jwkManager,
+		Keysets:             keysets,
+		JWKS:                jwks,
+		TokenService:        tokenService,
+		Sessions:            sessions,
+		RefreshTokens:       refreshTokens,
+		OneTimeTokens:       repository.NewOneTimeTokenRepository(db),
+		Verifications:       verifications,
+		MFA:                 repository.NewMFARepository(db),
+		Passkeys:            repository.NewWebAuthnCredentialRepository(db),
+		LoginAttempts:       repository.NewLoginAttemptRepository(db),
+		OAuthClients:        repository.NewOAuthClientRepository(db),
+		OAuthAuthorizations: repository.NewOAuthAuthorizationRepository(db),
+		OAuthDevices:        repository.NewOAuthDeviceAuthorizationRepository(db),
+		SocialLogins:        repository.NewSocialLoginRepository(db),
+		QRLogins:            repository.NewQRLoginRepository(db),
+		WebAuthn:            rp,
+		SocialProviders:     providers,
+		UserAgents:          userAgents,
+		Fingerprints:        fingerprints,
+		NativeApps:          nativeApps,
+		Issuer: &helpers.SessionIssuer{
+			Queries:       queries,
+			JWKManager:    jwkManager,
+			Keysets:       keysets,
+			TokenService:  tokenService,
+			Sessions:      sessions,
+			RefreshTokens: refreshTokens,
+			Verifications: verifications,
+			Fingerprints:  fingerprints,
+		},
+		Mailer: mail,
+		Config: cfg.Config,
 	}, nil
 }
 
@@ -66,7 +184,112 @@ func (am *AuthAPIService) GetJWKManager() manager.JwkManager {
 	return am.JWKManager
 }
 
+// GetKeysetRepository returns the session keyset repository for external use
+func (am *AuthAPIService) GetKeysetRepository() repository.KeysetRepository {
+	return am.Keysets
+}
+
+// GetJWKSCache returns the cache of the published signing keys for external use
+func (am *AuthAPIService) GetJWKSCache() *helpers.JWKSCache {
+	return am.JWKS
+}
+
 // GetAuthService returns the auth service for external use
 func (am *AuthAPIService) GetAuthService() service.TokenService {
 	return am.TokenService
 }
+
+// GetSessionRepository returns the session repository for external use
+func (am *AuthAPIService) GetSessionRepository() repository.SessionRepository {
+	return am.Sessions
+}
+
+// GetRefreshTokenRepository returns the refresh token repository for external use
+func (am *AuthAPIService) GetRefreshTokenRepository() repository.RefreshTokenRepository {
+	return am.RefreshTokens
+}
+
+// GetOneTimeTokenRepository returns the one-time token repository for external use
+func (am *AuthAPIService) GetOneTimeTokenRepository() repository.OneTimeTokenRepository {
+	return am.OneTimeTokens
+}
+
+// GetEmailVerificationRepository returns the email verification repository for external use
+func (am *AuthAPIService) GetEmailVerificationRepository() repository.EmailVerificationRepository {
+	return am.Verifications
+}
+
+// GetMFARepository returns the MFA repository for external use
+func (am *AuthAPIService) GetMFARepository() repository.MFARepository {
+	return am.MFA
+}
+
+// GetWebAuthnCredentialRepository returns the passkey repository for external use
+func (am *AuthAPIService) GetWebAuthnCredentialRepository() repository.WebAuthnCredentialRepository {
+	return am.Passkeys
+}
+
+// GetLoginAttemptRepository returns the failed login repository for external use
+func (am *AuthAPIService) GetLoginAttemptRepository() repository.LoginAttemptRepository {
+	return am.LoginAttempts
+}
+
+// GetOAuthClientRepository returns the OAuth client repository for external use
+func (am *AuthAPIService) GetOAuthClientRepository() repository.OAuthClientRepository {
+	return am.OAuthClients
+}
+
+// GetOAuthAuthorizationRepository returns the OAuth authorization repository for external use
+func (am *AuthAPIService) GetOAuthAuthorizationRepository() repository.OAuthAuthorizationRepository {
+	return am.OAuthAuthorizations
+}
+
+// GetOAuthDeviceAuthorizationRepository returns the OAuth device authorization repository for external use
+func (am *AuthAPIService) GetOAuthDeviceAuthorizationRepository() repository.OAuthDeviceAuthorizationRepository {
+	return am.OAuthDevices
+}
+
+// GetRelyingParty returns the WebAuthn relying party for external use
+func (am *AuthAPIService) GetRelyingParty() *webauthn.RelyingParty {
+	return am.WebAuthn
+}
+
+// GetSocialLoginRepository returns the social login repository for external use
+func (am *AuthAPIService) GetSocialLoginRepository() repository.SocialLoginRepository {
+	return am.SocialLogins
+}
+
+// GetQRLoginRepository returns the QR login repository for external use
+func (am *AuthAPIService) GetQRLoginRepository() repository.QRLoginRepository {
+	return am.QRLogins
+}
+
+// GetSocialProviders returns the social login providers by name for external use
+func (am *AuthAPIService) GetSocialProviders() map[string]*oidc.Provider {
+	return am.SocialProviders
+}
+
+// GetSessionIssuer returns the session issuer for external use
+func (am *AuthAPIService) GetSessionIssuer() *helpers.SessionIssuer {
+	return am.Issuer
+}
+
+// GetMailer returns the mailer for external use
+func (am *AuthAPIService) GetMailer() mailer.Mailer {
+	return am.Mailer
+}
+
+// GetUserAgentParser returns the shared User-Agent parser for external use
+func (am *AuthAPIService) GetUserAgentParser() *useragent.Parser {
+	return am.UserAgents
+}
+
+// GetFingerprintGenerator returns the device fingerprint generator for external use
+func (am *AuthAPIService) GetFingerprintGenerator() *fingerprint.Generator {
+	return am.Fingerprints
+}
+
+// GetNativeAppVerifier returns the native app signature verifier for external use
+func (am *AuthAPIService) GetNativeAppVerifier() *nativeapp.Verifier {
+	return am.NativeApps
+}
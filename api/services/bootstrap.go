@@ -0,0 +1,48 @@
+package services
+
+import (
+	"context"
+	"fiber-api/api/handlers/helpers"
+	"fiber-api/api/models"
+	"fiber-api/api/validators"
+	appconfig "fiber-api/config"
+	"fmt"
+	"log"
+)
+
+// BootstrapAdmin creates the first admin account if it does not exist yet.
+// It is a no-op when no bootstrap admin is configured or the email is already registered.
+func (am *AuthAPIService) BootstrapAdmin(ctx context.Context, cfg appconfig.BootstrapAdminConfig) error {
+	if !cfg.Enabled() {
+		return nil
+	}
+
+	// Skip if the account already exists
+	if _, err := am.Queries.GetUserAuth(ctx, cfg.Email); err == nil {
+		log.Printf("🔒 Bootstrap admin %s already exists, skipping", cfg.Email)
+		return nil
+	}
+
+	input := models.SignUp{
+		UserEmail: cfg.Email,
+		Password:  cfg.Password,
+		FullName:  cfg.FullName,
+		UserRole:  models.RoleAdmin,
+	}
+	if validation := validators.ValidateSignUp(input); !validation.IsValid {
+		return fmt.Errorf("invalid bootstrap admin configuration: %v", validation.Errors)
+	}
+
+	user, err := helpers.CreateUserProfile(ctx, am.Queries, input, models.RoleAdmin)
+	if err != nil {
+		return fmt.Errorf("failed to create bootstrap admin %s: %w", cfg.Email, err)
+	}
+
+	// The operator supplied this address, so it counts as verified
+	if err := am.Verifications.MarkEmailVerified(ctx, user.UserProfileID); err != nil {
+		return fmt.Errorf("failed to verify bootstrap admin %s: %w", cfg.Email, err)
+	}
+
+	log.Printf("🚀 Bootstrap admin %s created", cfg.Email)
+	return nil
+}
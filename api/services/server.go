@@ -2,7 +2,14 @@ package services
 
 import (
 	"fiber-api/api/middleware"
+	"fiber-api/api/models"
 	"fiber-api/api/routes"
+	appconfig "fiber-api/config"
+	"fiber-api/pkg/fingerprint"
+	"fiber-api/pkg/mailer"
+	"fiber-api/pkg/nativeapp"
+	"fiber-api/pkg/ratelimit"
+	"fiber-api/pkg/webauthn"
 	"log"
 
 	"github.com/gofiber/fiber/v2"
@@ -11,15 +18,34 @@ import (
 
 // ServerConfig holds the configuration for the server
 type ServerConfig struct {
-	DatabaseURL string
-	Port        string
-	Config      *config.Config
+	DatabaseURL   string
+	Port          string
+	Config        *config.Config
+	RBAC          appconfig.RBACConfig
+	Signup        appconfig.SignupConfig
+	Mail          mailer.Config
+	PasswordReset appconfig.PasswordResetConfig
+	Verification  appconfig.EmailVerificationConfig
+	MFA           appconfig.MFAConfig
+	Lockout       appconfig.LockoutConfig
+	RateLimit     appconfig.RateLimitConfig
+	// RateLimitStore holds rate limit buckets; nil keeps them in memory, per instance
+	RateLimitStore ratelimit.Store
+	WebAuthn       webauthn.Config
+	OIDC           appconfig.OIDCConfig
+	OAuth          appconfig.OAuthConfig
+	Social         appconfig.SocialLoginConfig
+	QRLogin        appconfig.QRLoginConfig
+	UserAgent      appconfig.UserAgentConfig
+	Fingerprint    fingerprint.Config
+	NativeApps     nativeapp.Config
 }
 
 // ServerService encapsulates the entire server functionality
 type ServerService struct {
 	App            *fiber.App
 	AuthAPIService *AuthAPIService
+	RateLimiter    *middleware.RateLimiter
 	Config         ServerConfig
 }
 
@@ -30,8 +56,15 @@ func NewAPIServerService(cfg ServerConfig) (*ServerService, error) {
 
 	// Initialize auth manager
 	authService, err := NewAuthAPIService(AuthAPIServiceConfig{
-		DatabaseURL: cfg.DatabaseURL,
-		Config:      cfg.Config,
+		DatabaseURL:  cfg.DatabaseURL,
+		Config:       cfg.Config,
+		Mail:         cfg.Mail,
+		WebAuthn:     cfg.WebAuthn,
+		Social:       cfg.Social.Providers,
+		UserAgent:    cfg.UserAgent.CacheSize,
+		Fingerprint:  cfg.Fingerprint,
+		NativeApps:   cfg.NativeApps,
+		JWKSCacheTTL: cfg.OIDC.JWKSCacheTTL,
 	})
 	if err != nil {
 		return nil, err
@@ -45,34 +78,195 @@ func NewAPIServerService(cfg ServerConfig) (*ServerService, error) {
 	return &ServerService{
 		App:            app,
 		AuthAPIService: authService,
+		RateLimiter:    middleware.NewRateLimiter(cfg.RateLimitStore, cfg.RateLimit),
 		Config:         cfg,
 	}, nil
 }
 
 // RegisterAuthRoutes registers authentication routes
 func (ss *ServerService) RegisterAuthRoutes() {
-	authRoute := ss.App.Group("/api", middleware.DeviceDetectionMiddleware())
+	authRoute := ss.App.Group("/api", middleware.DeviceDetectionMiddleware(ss.AuthAPIService.GetUserAgentParser(), ss.AuthAPIService.GetNativeAppVerifier()))
 	routes.AuthRouter(
 		authRoute,
 		ss.AuthAPIService.GetQueries(),
+		ss.AuthAPIService.GetSessionIssuer(),
+		ss.Config.Signup,
+		ss.AuthAPIService.GetOneTimeTokenRepository(),
+		ss.AuthAPIService.GetEmailVerificationRepository(),
+		ss.AuthAPIService.GetMailer(),
+		ss.Config.Verification,
+		ss.AuthAPIService.GetMFARepository(),
+		ss.Config.MFA,
+		ss.AuthAPIService.GetLoginAttemptRepository(),
+		ss.Config.Lockout,
+		ss.RateLimiter,
+	)
+
+	passwordRoute := ss.App.Group("/api/password", middleware.DeviceDetectionMiddleware(ss.AuthAPIService.GetUserAgentParser(), ss.AuthAPIService.GetNativeAppVerifier()))
+	routes.PasswordRouter(
+		passwordRoute,
+		ss.AuthAPIService.GetQueries(),
+		ss.AuthAPIService.GetJWKManager(),
+		ss.AuthAPIService.GetSessionRepository(),
+		ss.AuthAPIService.GetOneTimeTokenRepository(),
+		ss.AuthAPIService.GetMailer(),
+		ss.Config.PasswordReset,
+		ss.RateLimiter,
+	)
+
+	emailRoute := ss.App.Group("/api/email", middleware.DeviceDetectionMiddleware(ss.AuthAPIService.GetUserAgentParser(), ss.AuthAPIService.GetNativeAppVerifier()))
+	routes.EmailRouter(
+		emailRoute,
+		ss.AuthAPIService.GetQueries(),
+		ss.AuthAPIService.GetOneTimeTokenRepository(),
+		ss.AuthAPIService.GetEmailVerificationRepository(),
+		ss.AuthAPIService.GetMailer(),
+		ss.Config.Verification,
+	)
+}
+
+// RegisterWebAuthnRoutes registers passkey registration and login routes
+func (ss *ServerService) RegisterWebAuthnRoutes() {
+	webauthnRoute := ss.App.Group("/api/webauthn", middleware.DeviceDetectionMiddleware(ss.AuthAPIService.GetUserAgentParser(), ss.AuthAPIService.GetNativeAppVerifier()))
+	routes.WebAuthnRouter(
+		webauthnRoute,
+		ss.AuthAPIService.GetQueries(),
+		ss.AuthAPIService.GetRelyingParty(),
+		ss.AuthAPIService.GetSessionIssuer(),
+		ss.AuthAPIService.GetOneTimeTokenRepository(),
+		ss.AuthAPIService.GetWebAuthnCredentialRepository(),
+		ss.AuthAPIService.GetEmailVerificationRepository(),
+		ss.Config.Verification,
+		middleware.JWTMiddleware(ss.AuthAPIService.GetAuthService(), ss.AuthAPIService.GetFingerprintGenerator()),
+	)
+}
+
+// RegisterSocialRoutes registers the external OpenID Connect provider login routes
+func (ss *ServerService) RegisterSocialRoutes() {
+	socialRoute := ss.App.Group("/api/social", middleware.DeviceDetectionMiddleware(ss.AuthAPIService.GetUserAgentParser(), ss.AuthAPIService.GetNativeAppVerifier()))
+	routes.SocialRouter(
+		socialRoute,
+		ss.AuthAPIService.GetQueries(),
+		ss.AuthAPIService.GetSessionIssuer(),
+		ss.AuthAPIService.GetSocialProviders(),
+		ss.AuthAPIService.GetSocialLoginRepository(),
+		ss.AuthAPIService.GetEmailVerificationRepository(),
+		ss.Config.Verification,
+		ss.Config.Signup,
+		ss.AuthAPIService.GetOneTimeTokenRepository(),
+		ss.AuthAPIService.GetMFARepository(),
+		ss.Config.MFA,
+		ss.Config.Social,
+	)
+}
+
+// RegisterQRLoginRoutes registers the cross-device QR login routes
+func (ss *ServerService) RegisterQRLoginRoutes() {
+	qrLoginRoute := ss.App.Group("/api/qr-login", middleware.DeviceDetectionMiddleware(ss.AuthAPIService.GetUserAgentParser(), ss.AuthAPIService.GetNativeAppVerifier()))
+	routes.QRLoginRouter(
+		qrLoginRoute,
+		ss.AuthAPIService.GetSessionIssuer(),
+		ss.AuthAPIService.GetSessionRepository(),
+		ss.AuthAPIService.GetQRLoginRepository(),
+		ss.Config.QRLogin,
+		middleware.JWTMiddleware(ss.AuthAPIService.GetAuthService(), ss.AuthAPIService.GetFingerprintGenerator()),
+		ss.RateLimiter,
+	)
+}
+
+// RegisterWellKnownRoutes registers the JWKS and OpenID Connect discovery documents
+func (ss *ServerService) RegisterWellKnownRoutes() {
+	wellKnownRoute := ss.App.Group("/.well-known")
+	routes.WellKnownRouter(
+		wellKnownRoute,
+		ss.AuthAPIService.GetJWKSCache(),
+		ss.Config.OIDC,
+		ss.Config.OAuth,
+	)
+}
+
+// RegisterOAuthRoutes registers the OAuth 2.0 authorization server and OpenID Connect routes
+func (ss *ServerService) RegisterOAuthRoutes() {
+	oauthRoute := ss.App.Group("/oauth")
+	routes.OAuthRouter(
+		oauthRoute,
 		ss.AuthAPIService.GetJWKManager(),
-		ss.AuthAPIService.TokenService,
+		ss.AuthAPIService.GetAuthService(),
+		ss.AuthAPIService.GetQueries(),
+		ss.AuthAPIService.GetSessionIssuer(),
+		ss.AuthAPIService.GetSessionRepository(),
+		ss.AuthAPIService.GetRefreshTokenRepository(),
+		ss.AuthAPIService.GetOAuthClientRepository(),
+		ss.AuthAPIService.GetOAuthAuthorizationRepository(),
+		ss.AuthAPIService.GetOAuthDeviceAuthorizationRepository(),
+		ss.AuthAPIService.GetEmailVerificationRepository(),
+		ss.Config.OAuth,
+		ss.Config.OIDC,
+		middleware.JWTMiddleware(ss.AuthAPIService.GetAuthService(), ss.AuthAPIService.GetFingerprintGenerator()),
+		middleware.OAuthTokenMiddleware(ss.AuthAPIService.GetAuthService(), ss.AuthAPIService.GetFingerprintGenerator()),
+		ss.RateLimiter,
 	)
 }
 
 // RegisterUserRoutes registers user routes with JWT middleware
 func (ss *ServerService) RegisterUserRoutes() {
 	userRoute := ss.App.Group("/api/user",
-		middleware.DeviceDetectionMiddleware(),
-		middleware.JWTMiddleware(ss.AuthAPIService.GetAuthService()),
+		middleware.DeviceDetectionMiddleware(ss.AuthAPIService.GetUserAgentParser(), ss.AuthAPIService.GetNativeAppVerifier()),
+		middleware.JWTMiddleware(ss.AuthAPIService.GetAuthService(), ss.AuthAPIService.GetFingerprintGenerator()),
 	)
-	routes.UserRouter(userRoute, ss.AuthAPIService.GetQueries())
+	routes.UserRouter(
+		userRoute,
+		ss.AuthAPIService.GetQueries(),
+		ss.AuthAPIService.GetJWKManager(),
+		ss.AuthAPIService.GetSessionRepository(),
+		ss.Config.RBAC,
+		ss.AuthAPIService.GetMFARepository(),
+		ss.Config.MFA,
+	)
+}
+
+// RegisterAdminRoutes registers admin-only routes with JWT and role middleware
+func (ss *ServerService) RegisterAdminRoutes() {
+	adminRoute := ss.App.Group("/api/admin",
+		middleware.DeviceDetectionMiddleware(ss.AuthAPIService.GetUserAgentParser(), ss.AuthAPIService.GetNativeAppVerifier()),
+		middleware.JWTMiddleware(ss.AuthAPIService.GetAuthService(), ss.AuthAPIService.GetFingerprintGenerator()),
+		ss.RequireRole(models.RoleAdmin),
+	)
+	routes.AdminRouter(
+		adminRoute,
+		ss.AuthAPIService.GetQueries(),
+		ss.Config.Signup,
+		ss.AuthAPIService.GetOneTimeTokenRepository(),
+		ss.AuthAPIService.GetMailer(),
+		ss.Config.Verification,
+		ss.AuthAPIService.GetLoginAttemptRepository(),
+		ss.AuthAPIService.GetJWKManager(),
+		ss.AuthAPIService.GetOAuthClientRepository(),
+		ss.Config.OAuth,
+	)
+}
+
+// RequireRole returns middleware restricting a group or route to the given roles
+func (ss *ServerService) RequireRole(roles ...string) fiber.Handler {
+	return middleware.RequireRole(roles...)
+}
+
+// RequirePermission returns middleware restricting a group or route to roles
+// granting every given permission, per the configured role mapping
+func (ss *ServerService) RequirePermission(permissions ...string) fiber.Handler {
+	return middleware.RequirePermission(ss.Config.RBAC, permissions...)
 }
 
-// RegisterAllRoutes registers both auth and user routes
+// RegisterAllRoutes registers auth, passkey, social, QR login, discovery, OAuth, user and admin routes
 func (ss *ServerService) RegisterAllRoutes() {
 	ss.RegisterAuthRoutes()
+	ss.RegisterWebAuthnRoutes()
+	ss.RegisterSocialRoutes()
+	ss.RegisterQRLoginRoutes()
+	ss.RegisterWellKnownRoutes()
+	ss.RegisterOAuthRoutes()
 	ss.RegisterUserRoutes()
+	ss.RegisterAdminRoutes()
 }
 
 // Start starts the server on the configured port
@@ -37,17 +37,7 @@ func ValidateSignUp(input models.SignUp) ValidationResult {
 	}
 
 	// Password validation
-	if input.Password == "" {
-		errors = append(errors, ValidationError{
-			Field:   "password",
-			Message: "Password is required",
-		})
-	} else if len(input.Password) < 8 {
-		errors = append(errors, ValidationError{
-			Field:   "password",
-			Message: "Password must be at least 8 characters long",
-		})
-	}
+	errors = append(errors, validatePassword("password", input.Password)...)
 
 	// Full name validation
 	if input.FullName == "" {
@@ -64,11 +54,10 @@ func ValidateSignUp(input models.SignUp) ValidationResult {
 
 	// User role validation (if provided)
 	if input.UserRole != "" {
-		validRoles := []string{"admin", "user", "moderator"}
-		if !contains(validRoles, strings.ToLower(input.UserRole)) {
+		if !contains(models.ValidRoles, strings.ToLower(input.UserRole)) {
 			errors = append(errors, ValidationError{
 				Field:   "user_role",
-				Message: fmt.Sprintf("Invalid role. Must be one of: %s", strings.Join(validRoles, ", ")),
+				Message: fmt.Sprintf("Invalid role. Must be one of: %s", strings.Join(models.ValidRoles, ", ")),
 			})
 		}
 	}
@@ -110,6 +99,23 @@ func ValidateLogin(input models.Login) ValidationResult {
 	}
 }
 
+// validatePassword applies the password rules shared by signup, reset and change password
+func validatePassword(field string, password string) []ValidationError {
+	if password == "" {
+		return []ValidationError{{
+			Field:   field,
+			Message: "Password is required",
+		}}
+	}
+	if len(password) < 8 {
+		return []ValidationError{{
+			Field:   field,
+			Message: "Password must be at least 8 characters long",
+		}}
+	}
+	return nil
+}
+
 // isValidEmail validates email format using regex
 func isValidEmail(email string) bool {
 	emailRegex := regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@[a-zA-Z0-9.-]+\.[a-zA-Z]{2,}$`)
@@ -0,0 +1,46 @@
+package validators
+
+import (
+	"fiber-api/api/models"
+)
+
+// ValidateVerifyEmail validates email verification input
+func ValidateVerifyEmail(input models.VerifyEmail) ValidationResult {
+	var errors []ValidationError
+
+	// Token validation
+	if input.Token == "" {
+		errors = append(errors, ValidationError{
+			Field:   "token",
+			Message: "Verification token is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateResendVerification validates verification email resend input
+func ValidateResendVerification(input models.ResendVerification) ValidationResult {
+	var errors []ValidationError
+
+	// Email validation
+	if input.UserEmail == "" {
+		errors = append(errors, ValidationError{
+			Field:   "user_email",
+			Message: "Email is required",
+		})
+	} else if !isValidEmail(input.UserEmail) {
+		errors = append(errors, ValidationError{
+			Field:   "user_email",
+			Message: "Invalid email format",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
@@ -0,0 +1,75 @@
+package validators
+
+import (
+	"fiber-api/api/models"
+)
+
+// ValidateLoginMFA validates second factor login input
+func ValidateLoginMFA(input models.LoginMFA) ValidationResult {
+	var errors []ValidationError
+
+	// Challenge validation
+	if input.MFAChallenge == "" {
+		errors = append(errors, ValidationError{
+			Field:   "mfa_challenge",
+			Message: "MFA challenge is required",
+		})
+	}
+
+	// Code validation
+	if input.Code == "" {
+		errors = append(errors, ValidationError{
+			Field:   "code",
+			Message: "Authentication code is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateConfirmMFA validates TOTP enrollment confirmation input
+func ValidateConfirmMFA(input models.ConfirmMFA) ValidationResult {
+	var errors []ValidationError
+
+	// Code validation
+	if input.Code == "" {
+		errors = append(errors, ValidationError{
+			Field:   "code",
+			Message: "Authentication code is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateDisableMFA validates MFA disable input
+func ValidateDisableMFA(input models.DisableMFA) ValidationResult {
+	var errors []ValidationError
+
+	// Password validation
+	if input.Password == "" {
+		errors = append(errors, ValidationError{
+			Field:   "password",
+			Message: "Password is required",
+		})
+	}
+
+	// Code validation
+	if input.Code == "" {
+		errors = append(errors, ValidationError{
+			Field:   "code",
+			Message: "Authentication code is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
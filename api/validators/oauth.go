@@ -0,0 +1,84 @@
+package validators
+
+import (
+	"fiber-api/api/models"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// ValidateRegisterOAuthClient validates OAuth client registration input against the supported scopes
+func ValidateRegisterOAuthClient(input models.RegisterOAuthClient, supportedScopes []string) ValidationResult {
+	var errors []ValidationError
+
+	// Name validation
+	if strings.TrimSpace(input.Name) == "" {
+		errors = append(errors, ValidationError{
+			Field:   "name",
+			Message: "Client name is required",
+		})
+	}
+
+	// Grant type validation
+	for _, grantType := range input.GrantTypes {
+		if !slices.Contains(models.GrantTypes, grantType) {
+			errors = append(errors, ValidationError{
+				Field:   "grant_types",
+				Message: "Unsupported grant type: " + grantType,
+			})
+		}
+	}
+	if input.Public && slices.Contains(input.GrantTypes, models.GrantTypeClientCredentials) {
+		errors = append(errors, ValidationError{
+			Field:   "grant_types",
+			Message: "Public clients cannot use the client_credentials grant",
+		})
+	}
+
+	// Redirect URI validation
+	if slices.Contains(input.GrantTypes, models.GrantTypeAuthorizationCode) && len(input.RedirectURIs) == 0 {
+		errors = append(errors, ValidationError{
+			Field:   "redirect_uris",
+			Message: "At least one redirect URI is required for the authorization_code grant",
+		})
+	}
+	for _, redirectURI := range input.RedirectURIs {
+		if !isValidRedirectURI(redirectURI) {
+			errors = append(errors, ValidationError{
+				Field:   "redirect_uris",
+				Message: "Redirect URIs must be absolute, without a fragment: " + redirectURI,
+			})
+		}
+	}
+
+	// Scope validation
+	for _, scope := range input.Scopes {
+		if !slices.Contains(supportedScopes, scope) {
+			errors = append(errors, ValidationError{
+				Field:   "scopes",
+				Message: "Unsupported scope: " + scope,
+			})
+		}
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// isValidRedirectURI accepts absolute URIs without a fragment, including custom
+// schemes of native apps (RFC 6749 section 3.1.2)
+func isValidRedirectURI(redirectURI string) bool {
+	if strings.ContainsAny(redirectURI, " \t\n") {
+		return false
+	}
+	parsed, err := url.Parse(redirectURI)
+	if err != nil || !parsed.IsAbs() || parsed.Fragment != "" || strings.Contains(redirectURI, "#") {
+		return false
+	}
+	if parsed.Scheme == "http" || parsed.Scheme == "https" {
+		return parsed.Host != ""
+	}
+	return true
+}
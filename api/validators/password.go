@@ -0,0 +1,77 @@
+package validators
+
+import (
+	"fiber-api/api/models"
+)
+
+// ValidateForgotPassword validates password reset request input
+func ValidateForgotPassword(input models.ForgotPassword) ValidationResult {
+	var errors []ValidationError
+
+	// Email validation
+	if input.UserEmail == "" {
+		errors = append(errors, ValidationError{
+			Field:   "user_email",
+			Message: "Email is required",
+		})
+	} else if !isValidEmail(input.UserEmail) {
+		errors = append(errors, ValidationError{
+			Field:   "user_email",
+			Message: "Invalid email format",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateResetPassword validates password reset completion input
+func ValidateResetPassword(input models.ResetPassword) ValidationResult {
+	var errors []ValidationError
+
+	// Token validation
+	if input.Token == "" {
+		errors = append(errors, ValidationError{
+			Field:   "token",
+			Message: "Reset token is required",
+		})
+	}
+
+	// Password validation
+	errors = append(errors, validatePassword("new_password", input.NewPassword)...)
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateChangePassword validates change password input
+func ValidateChangePassword(input models.ChangePassword) ValidationResult {
+	var errors []ValidationError
+
+	// Current password validation
+	if input.CurrentPassword == "" {
+		errors = append(errors, ValidationError{
+			Field:   "current_password",
+			Message: "Current password is required",
+		})
+	}
+
+	// New password validation
+	newPasswordErrors := validatePassword("new_password", input.NewPassword)
+	errors = append(errors, newPasswordErrors...)
+	if len(newPasswordErrors) == 0 && input.NewPassword == input.CurrentPassword {
+		errors = append(errors, ValidationError{
+			Field:   "new_password",
+			Message: "New password must differ from the current password",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
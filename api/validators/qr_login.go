@@ -0,0 +1,37 @@
+package validators
+
+import "fiber-api/api/models"
+
+// ValidateQRLoginPoll validates the poll request of a waiting browser
+func ValidateQRLoginPoll(input models.QRLoginPoll) ValidationResult {
+	var errors []ValidationError
+
+	if input.PollToken == "" {
+		errors = append(errors, ValidationError{
+			Field:   "poll_token",
+			Message: "Poll token is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateQRLoginCode validates the scanned QR code sent by the native app
+func ValidateQRLoginCode(code string) ValidationResult {
+	var errors []ValidationError
+
+	if code == "" {
+		errors = append(errors, ValidationError{
+			Field:   "code",
+			Message: "QR code is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
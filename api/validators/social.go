@@ -0,0 +1,27 @@
+package validators
+
+import "fiber-api/api/models"
+
+// ValidateSocialLoginFinish validates social login completion input
+func ValidateSocialLoginFinish(input models.SocialLoginFinish) ValidationResult {
+	var errors []ValidationError
+
+	if input.Code == "" {
+		errors = append(errors, ValidationError{
+			Field:   "code",
+			Message: "Authorization code is required",
+		})
+	}
+
+	if input.State == "" {
+		errors = append(errors, ValidationError{
+			Field:   "state",
+			Message: "State is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
@@ -0,0 +1,100 @@
+package validators
+
+import (
+	"fiber-api/api/models"
+)
+
+// maxCredentialNameLength bounds the label a user gives a passkey
+const maxCredentialNameLength = 64
+
+// ValidateWebAuthnRegistration validates passkey registration input
+func ValidateWebAuthnRegistration(input models.WebAuthnRegistration) ValidationResult {
+	var errors []ValidationError
+
+	// Name validation
+	if len(input.Name) > maxCredentialNameLength {
+		errors = append(errors, ValidationError{
+			Field:   "name",
+			Message: "Name must be at most 64 characters",
+		})
+	}
+
+	// Credential validation
+	if input.Credential.RawID == "" {
+		errors = append(errors, ValidationError{
+			Field:   "credential.rawId",
+			Message: "Credential ID is required",
+		})
+	}
+	if input.Credential.Response.ClientDataJSON == "" {
+		errors = append(errors, ValidationError{
+			Field:   "credential.response.clientDataJSON",
+			Message: "Client data is required",
+		})
+	}
+	if input.Credential.Response.AttestationObject == "" {
+		errors = append(errors, ValidationError{
+			Field:   "credential.response.attestationObject",
+			Message: "Attestation object is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateWebAuthnLoginBegin validates passkey login start input
+func ValidateWebAuthnLoginBegin(input models.WebAuthnLoginBegin) ValidationResult {
+	var errors []ValidationError
+
+	// Email is optional, but must be valid when given
+	if input.UserEmail != "" && !isValidEmail(input.UserEmail) {
+		errors = append(errors, ValidationError{
+			Field:   "user_email",
+			Message: "Invalid email format",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
+
+// ValidateWebAuthnLogin validates passkey login input
+func ValidateWebAuthnLogin(input models.WebAuthnLogin) ValidationResult {
+	var errors []ValidationError
+
+	// Credential validation
+	if input.Credential.RawID == "" {
+		errors = append(errors, ValidationError{
+			Field:   "credential.rawId",
+			Message: "Credential ID is required",
+		})
+	}
+	if input.Credential.Response.ClientDataJSON == "" {
+		errors = append(errors, ValidationError{
+			Field:   "credential.response.clientDataJSON",
+			Message: "Client data is required",
+		})
+	}
+	if input.Credential.Response.AuthenticatorData == "" {
+		errors = append(errors, ValidationError{
+			Field:   "credential.response.authenticatorData",
+			Message: "Authenticator data is required",
+		})
+	}
+	if input.Credential.Response.Signature == "" {
+		errors = append(errors, ValidationError{
+			Field:   "credential.response.signature",
+			Message: "Signature is required",
+		})
+	}
+
+	return ValidationResult{
+		IsValid: len(errors) == 0,
+		Errors:  errors,
+	}
+}
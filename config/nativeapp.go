@@ -0,0 +1,26 @@
+package config
+
+import (
+	"fiber-api/pkg/nativeapp"
+	"strings"
+	"time"
+)
+
+// loadNativeApps reads NATIVE_APP_<NAME>_ID, _PLATFORM, _SECRET and _PUBLIC_KEY for every
+// app named in NATIVE_APPS (e.g. android,ios), plus the shared verification settings
+func loadNativeApps() nativeapp.Config {
+	cfg := nativeapp.Config{
+		MaxSkew:  getEnvAsDuration("NATIVE_APP_MAX_CLOCK_SKEW", 5*time.Minute),
+		Fallback: strings.ToLower(getEnv("NATIVE_APP_FALLBACK", nativeapp.FallbackWeb)),
+	}
+	for _, name := range getEnvAsSlice("NATIVE_APPS", nil) {
+		prefix := "NATIVE_APP_" + strings.ToUpper(name) + "_"
+		cfg.Apps = append(cfg.Apps, nativeapp.App{
+			ID:        getEnv(prefix+"ID", ""),
+			Platform:  strings.ToLower(getEnv(prefix+"PLATFORM", strings.ToLower(name))),
+			Secret:    getEnv(prefix+"SECRET", ""),
+			PublicKey: getEnv(prefix+"PUBLIC_KEY", ""),
+		})
+	}
+	return cfg
+}
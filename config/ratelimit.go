@@ -0,0 +1,104 @@
+package config
+
+import (
+	"fiber-api/pkg/ratelimit"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+)
+
+// Rate-limited routes, each configured by RATE_LIMIT_<ROUTE> (e.g. RATE_LIMIT_LOGIN)
+const (
+	RateLimitRouteSignup             = "signup"
+	RateLimitRouteLogin              = "login"
+	RateLimitRouteLoginMFA           = "login_mfa"
+	RateLimitRouteRefresh            = "refresh"
+	RateLimitRouteForgotPassword     = "forgot_password"
+	RateLimitRouteDeviceVerification = "device_verification"
+	RateLimitRouteQRLogin            = "qr_login"
+	RateLimitRouteQRLoginPoll        = "qr_login_poll"
+)
+
+// RouteRateLimit holds the limits of one route per key; a zero limit is not applied
+type RouteRateLimit struct {
+	// IP limits requests per client IP address
+	IP ratelimit.Limit
+	// Email limits requests per user_email in the request body
+	Email ratelimit.Limit
+	// User limits requests per authenticated user ID
+	User ratelimit.Limit
+}
+
+// RateLimitConfig holds the per-route request limits
+type RateLimitConfig struct {
+	Enabled bool
+	Routes  map[string]RouteRateLimit
+}
+
+// For returns the limits of a route; routes without limits get a zero RouteRateLimit
+func (r RateLimitConfig) For(route string) RouteRateLimit {
+	if !r.Enabled {
+		return RouteRateLimit{}
+	}
+	return r.Routes[route]
+}
+
+// defaultRouteRateLimits is used for routes whose RATE_LIMIT_<ROUTE> is not set
+func defaultRouteRateLimits() map[string]string {
+	return map[string]string{
+		RateLimitRouteSignup:             "ip=10/1h",
+		RateLimitRouteLogin:              "ip=20/1m,email=10/1m",
+		RateLimitRouteLoginMFA:           "ip=10/1m",
+		RateLimitRouteRefresh:            "ip=60/1m",
+		RateLimitRouteForgotPassword:     "ip=5/15m,email=3/15m",
+		RateLimitRouteDeviceVerification: "ip=30/1m,user=10/1m",
+		RateLimitRouteQRLogin:            "ip=20/1m",
+		RateLimitRouteQRLoginPoll:        "ip=120/1m",
+	}
+}
+
+// loadRouteRateLimits reads RATE_LIMIT_<ROUTE> for every rate-limited route. A malformed
+// value is logged and the route keeps its default limits.
+func loadRouteRateLimits() map[string]RouteRateLimit {
+	routes := make(map[string]RouteRateLimit)
+	for route, defaultValue := range defaultRouteRateLimits() {
+		key := "RATE_LIMIT_" + strings.ToUpper(route)
+		value := defaultValue
+		if configured := os.Getenv(key); strings.TrimSpace(configured) != "" {
+			if _, err := parseRouteRateLimit(configured); err != nil {
+				log.Printf("❌ Ignoring %s=%q: %v; using the default %q", key, configured, err, defaultValue)
+			} else {
+				value = configured
+			}
+		}
+		routes[route], _ = parseRouteRateLimit(value)
+	}
+	return routes
+}
+
+// parseRouteRateLimit parses "ip=20/1m,email=5/1m,user=30/1m"; any malformed entry rejects the value
+func parseRouteRateLimit(value string) (RouteRateLimit, error) {
+	var limit RouteRateLimit
+	for _, entry := range strings.Split(value, ",") {
+		key, spec, found := strings.Cut(entry, "=")
+		if !found {
+			return RouteRateLimit{}, fmt.Errorf("entry %q is not <key>=<limit>", strings.TrimSpace(entry))
+		}
+		parsed, err := ratelimit.ParseLimit(spec)
+		if err != nil {
+			return RouteRateLimit{}, err
+		}
+		switch strings.ToLower(strings.TrimSpace(key)) {
+		case "ip":
+			limit.IP = parsed
+		case "email":
+			limit.Email = parsed
+		case "user":
+			limit.User = parsed
+		default:
+			return RouteRateLimit{}, fmt.Errorf("unknown rate limit key %q", strings.TrimSpace(key))
+		}
+	}
+	return limit, nil
+}
@@ -0,0 +1,67 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// Permissions checked by RequirePermission middleware
+const (
+	PermissionAll            = "*"
+	PermissionProfileRead    = "profile:read"
+	PermissionSessionsManage = "sessions:manage"
+	PermissionUsersRead      = "users:read"
+	PermissionUsersManage    = "users:manage"
+)
+
+// RBACConfig maps each role to the permissions it grants
+type RBACConfig struct {
+	RolePermissions map[string][]string
+}
+
+// defaultRolePermissions is used when RBAC_ROLE_PERMISSIONS is not set
+func defaultRolePermissions() map[string][]string {
+	return map[string][]string{
+		"admin":     {PermissionAll},
+		"moderator": {PermissionProfileRead, PermissionSessionsManage, PermissionUsersRead},
+		"user":      {PermissionProfileRead, PermissionSessionsManage},
+	}
+}
+
+// HasPermission reports whether the role grants the permission, either directly or via "*"
+func (r RBACConfig) HasPermission(role string, permission string) bool {
+	for _, granted := range r.RolePermissions[strings.ToLower(role)] {
+		if granted == PermissionAll || granted == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRolePermissions parses "role=perm1,perm2;role2=perm3" into a role to permissions map
+func parseRolePermissions(value string) map[string][]string {
+	rolePermissions := make(map[string][]string)
+	for _, entry := range strings.Split(value, ";") {
+		role, permissions, found := strings.Cut(entry, "=")
+		role = strings.ToLower(strings.TrimSpace(role))
+		if !found || role == "" {
+			continue
+		}
+		for _, permission := range strings.Split(permissions, ",") {
+			if permission = strings.TrimSpace(permission); permission != "" {
+				rolePermissions[role] = append(rolePermissions[role], permission)
+			}
+		}
+	}
+	return rolePermissions
+}
+
+// getEnvAsRolePermissions gets a role to permissions mapping from an environment variable
+func getEnvAsRolePermissions(key string, defaultValue map[string][]string) map[string][]string {
+	if value := os.Getenv(key); value != "" {
+		if rolePermissions := parseRolePermissions(value); len(rolePermissions) > 0 {
+			return rolePermissions
+		}
+	}
+	return defaultValue
+}
@@ -0,0 +1,69 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"strings"
+)
+
+// ErrRoleNotSelfService is returned when a public signup requests a role it may not take
+var ErrRoleNotSelfService = errors.New("role cannot be requested at signup")
+
+// SignupConfig holds the role policy for account creation
+type SignupConfig struct {
+	// DefaultRole is assigned to every public signup that does not request a role
+	DefaultRole string
+	// SelfServiceRoles are the roles a public signup may request; all others need an admin
+	SelfServiceRoles []string
+	// BootstrapAdmin creates the first admin account at startup when set
+	BootstrapAdmin BootstrapAdminConfig
+}
+
+// BootstrapAdminConfig holds credentials for the first admin account
+type BootstrapAdminConfig struct {
+	Email    string
+	Password string
+	FullName string
+}
+
+// Enabled reports whether a bootstrap admin account is configured
+func (b BootstrapAdminConfig) Enabled() bool {
+	return b.Email != "" && b.Password != ""
+}
+
+// AllowsSelfService reports whether a public signup may request the role
+func (s SignupConfig) AllowsSelfService(role string) bool {
+	for _, allowed := range s.SelfServiceRoles {
+		if strings.EqualFold(allowed, role) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignupRole returns the role a public signup is created with: the default role, or the
+// requested role when it is a self-service one
+func (s SignupConfig) SignupRole(requested string) (string, error) {
+	if requested == "" {
+		return s.DefaultRole, nil
+	}
+	if !s.AllowsSelfService(requested) {
+		return "", ErrRoleNotSelfService
+	}
+	return requested, nil
+}
+
+// getEnvAsSlice gets a comma-separated environment variable as a slice with fallback to default value
+func getEnvAsSlice(key string, defaultValue []string) []string {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
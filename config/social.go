@@ -0,0 +1,37 @@
+package config
+
+import (
+	"fiber-api/pkg/oidc"
+	"strings"
+	"time"
+)
+
+// SocialLoginConfig holds the external OpenID Connect providers users can log in with
+type SocialLoginConfig struct {
+	// Providers are configured by SOCIAL_PROVIDERS and SOCIAL_<NAME>_* variables
+	Providers []oidc.Config
+	// StateTTL is how long the user has to complete a login at the provider
+	StateTTL time.Duration
+	// CookieSecure and CookieSameSite set the attributes of the browser binding cookie
+	CookieSecure   bool
+	CookieSameSite string
+}
+
+// loadSocialProviders reads SOCIAL_<NAME>_ISSUER, _CLIENT_ID, _CLIENT_SECRET, _REDIRECT_URL
+// and _SCOPES for every provider named in SOCIAL_PROVIDERS (e.g. google,okta)
+func loadSocialProviders() []oidc.Config {
+	var providers []oidc.Config
+	for _, name := range getEnvAsSlice("SOCIAL_PROVIDERS", nil) {
+		name = strings.ToLower(name)
+		prefix := "SOCIAL_" + strings.ToUpper(name) + "_"
+		providers = append(providers, oidc.Config{
+			Name:         name,
+			Issuer:       getEnv(prefix+"ISSUER", ""),
+			ClientID:     getEnv(prefix+"CLIENT_ID", ""),
+			ClientSecret: getEnv(prefix+"CLIENT_SECRET", ""),
+			RedirectURL:  getEnv(prefix+"REDIRECT_URL", "http://localhost:3000/login/"+name+"/callback"),
+			Scopes:       getEnvAsSlice(prefix+"SCOPES", []string{"email", "profile"}),
+		})
+	}
+	return providers
+}
@@ -0,0 +1,16 @@
+-- User sessions track every device login backed by a JWK session key.
+-- One row per token family; key_id follows the session key as it rotates on refresh.
+CREATE TABLE IF NOT EXISTS user_sessions (
+    session_id        UUID PRIMARY KEY,
+    user_profile_id   UUID        NOT NULL,
+    key_id            TEXT        NOT NULL UNIQUE,
+    device_type       TEXT        NOT NULL,
+    platform          TEXT        NOT NULL DEFAULT 'unknown',
+    browser           TEXT        NOT NULL DEFAULT 'unknown',
+    browser_version   TEXT        NOT NULL DEFAULT 'unknown',
+    ip_address        TEXT        NOT NULL DEFAULT '',
+    created_at        TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_refreshed_at TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_sessions_user_profile_id ON user_sessions (user_profile_id);
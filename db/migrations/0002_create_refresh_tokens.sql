@@ -0,0 +1,12 @@
+-- Refresh tokens are single-use. Each issued refresh token is recorded by its
+-- SHA-256 hash (its jti) under the session that forms its token family.
+CREATE TABLE IF NOT EXISTS refresh_tokens (
+    token_hash      TEXT PRIMARY KEY,
+    session_id      UUID        NOT NULL REFERENCES user_sessions (session_id) ON DELETE CASCADE,
+    user_profile_id UUID        NOT NULL,
+    key_id          TEXT        NOT NULL,
+    issued_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    used_at         TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_refresh_tokens_session_id ON refresh_tokens (session_id);
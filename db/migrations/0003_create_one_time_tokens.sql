@@ -0,0 +1,12 @@
+-- Single-use, expiring tokens sent to users (password reset, email verification).
+-- Only the SHA-256 hash of each token is stored.
+CREATE TABLE IF NOT EXISTS one_time_tokens (
+    token_hash      TEXT PRIMARY KEY,
+    user_profile_id UUID        NOT NULL,
+    purpose         TEXT        NOT NULL,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    used_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_one_time_tokens_user_purpose ON one_time_tokens (user_profile_id, purpose);
@@ -0,0 +1,6 @@
+-- Records when a user confirmed ownership of their email address.
+-- Users without a row are unverified.
+CREATE TABLE IF NOT EXISTS user_email_verifications (
+    user_profile_id UUID PRIMARY KEY,
+    verified_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,20 @@
+-- TOTP second factor per user. The shared secret is stored encrypted;
+-- enrollment is pending until enabled_at is set by a confirmed first code.
+CREATE TABLE IF NOT EXISTS user_mfa (
+    user_profile_id  UUID PRIMARY KEY,
+    encrypted_secret TEXT        NOT NULL,
+    enabled_at       TIMESTAMPTZ,
+    last_used_step   BIGINT      NOT NULL DEFAULT 0,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- One-time recovery codes for users who lost their authenticator.
+-- Only the SHA-256 hash of each code is stored.
+CREATE TABLE IF NOT EXISTS mfa_recovery_codes (
+    code_hash       TEXT PRIMARY KEY,
+    user_profile_id UUID        NOT NULL REFERENCES user_mfa (user_profile_id) ON DELETE CASCADE,
+    used_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_mfa_recovery_codes_user ON mfa_recovery_codes (user_profile_id);
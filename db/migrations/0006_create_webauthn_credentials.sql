@@ -0,0 +1,16 @@
+-- WebAuthn (passkey) credentials registered by users.
+-- credential_id is the base64url credential ID; public_key is the COSE key.
+CREATE TABLE IF NOT EXISTS webauthn_credentials (
+    credential_id    TEXT PRIMARY KEY,
+    user_profile_id  UUID        NOT NULL,
+    name             TEXT        NOT NULL DEFAULT '',
+    public_key       BYTEA       NOT NULL,
+    sign_count       BIGINT      NOT NULL DEFAULT 0,
+    aaguid           BYTEA,
+    attestation_type TEXT        NOT NULL,
+    transports       TEXT        NOT NULL DEFAULT '',
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_used_at     TIMESTAMPTZ
+);
+
+CREATE INDEX IF NOT EXISTS idx_webauthn_credentials_user ON webauthn_credentials (user_profile_id);
@@ -0,0 +1,9 @@
+-- Failed password logins per email address, for backoff and temporary lockout.
+-- Keyed by the lowercased email whether or not an account exists, so throttling
+-- behaves the same for unknown addresses.
+CREATE TABLE IF NOT EXISTS login_attempts (
+    email          TEXT PRIMARY KEY,
+    failed_count   INTEGER     NOT NULL DEFAULT 0,
+    last_failed_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    locked_until   TIMESTAMPTZ
+);
@@ -0,0 +1,8 @@
+-- OAuth 2.0 clients allowed to call the /oauth endpoints.
+-- Only the SHA-256 hash of the client secret is stored; the secret is shown once at registration.
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    client_id          UUID PRIMARY KEY,
+    client_secret_hash TEXT        NOT NULL,
+    name               TEXT        NOT NULL,
+    created_at         TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,30 @@
+-- Client registration details for the authorization server. Lists are stored
+-- space-separated, like OAuth scope strings. Public clients have no secret and
+-- client_credentials tokens are keyed under the owner's account.
+ALTER TABLE oauth_clients ALTER COLUMN client_secret_hash DROP NOT NULL;
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS redirect_uris TEXT    NOT NULL DEFAULT '';
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS grant_types   TEXT    NOT NULL DEFAULT '';
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS scopes        TEXT    NOT NULL DEFAULT '';
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS public        BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE oauth_clients ADD COLUMN IF NOT EXISTS owner_user_id UUID;
+
+-- Sessions opened through the authorization server belong to a client and carry its granted scope.
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS client_id UUID;
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS scope     TEXT NOT NULL DEFAULT '';
+
+-- Authorization requests awaiting the user's consent, then the code issued for them.
+-- Only the SHA-256 hash of the code is stored; session_id is the session the code was exchanged for.
+CREATE TABLE IF NOT EXISTS oauth_authorizations (
+    request_id      UUID PRIMARY KEY,
+    client_id       UUID        NOT NULL REFERENCES oauth_clients (client_id) ON DELETE CASCADE,
+    redirect_uri    TEXT        NOT NULL,
+    scope           TEXT        NOT NULL DEFAULT '',
+    state           TEXT        NOT NULL DEFAULT '',
+    code_challenge  TEXT        NOT NULL,
+    user_profile_id UUID,
+    code_hash       TEXT UNIQUE,
+    session_id      UUID,
+    expires_at      TIMESTAMPTZ NOT NULL,
+    used_at         TIMESTAMPTZ,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
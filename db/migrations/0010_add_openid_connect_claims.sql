@@ -0,0 +1,9 @@
+-- OpenID Connect claims. auth_time is when the user last actively authenticated and amr
+-- lists how (RFC 8176 method references, space-separated). Both survive token refreshes.
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS auth_time TIMESTAMPTZ NOT NULL DEFAULT NOW();
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS amr       TEXT        NOT NULL DEFAULT '';
+
+-- The client's nonce, echoed in the ID token, and the authentication of the consenting session
+ALTER TABLE oauth_authorizations ADD COLUMN IF NOT EXISTS nonce     TEXT NOT NULL DEFAULT '';
+ALTER TABLE oauth_authorizations ADD COLUMN IF NOT EXISTS auth_time TIMESTAMPTZ;
+ALTER TABLE oauth_authorizations ADD COLUMN IF NOT EXISTS amr       TEXT NOT NULL DEFAULT '';
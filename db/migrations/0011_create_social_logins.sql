@@ -0,0 +1,23 @@
+-- Logins in progress at an external OpenID Connect provider. Only the SHA-256 hash of
+-- the state is stored; the nonce and PKCE verifier are needed to finish the login.
+CREATE TABLE IF NOT EXISTS social_login_states (
+    state_hash    TEXT PRIMARY KEY,
+    provider      TEXT        NOT NULL,
+    nonce         TEXT        NOT NULL,
+    code_verifier TEXT        NOT NULL,
+    expires_at    TIMESTAMPTZ NOT NULL,
+    created_at    TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+-- Provider accounts linked to profiles, identified by the provider's subject
+CREATE TABLE IF NOT EXISTS user_identities (
+    provider        TEXT        NOT NULL,
+    subject         TEXT        NOT NULL,
+    user_profile_id UUID        NOT NULL,
+    email           TEXT        NOT NULL DEFAULT '',
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    last_login_at   TIMESTAMPTZ,
+    PRIMARY KEY (provider, subject)
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_identities_user ON user_identities (user_profile_id);
@@ -0,0 +1,18 @@
+-- Device authorization requests (RFC 8628). Only the SHA-256 hash of the device code is
+-- stored; the user code is short-lived and typed in by the user, so it is kept as is.
+-- A request is pending until a user approves it (user_profile_id) or denies it (denied_at).
+-- interval_seconds grows each time the device polls too fast.
+CREATE TABLE IF NOT EXISTS oauth_device_authorizations (
+    device_code_hash TEXT PRIMARY KEY,
+    user_code        TEXT        NOT NULL UNIQUE,
+    client_id        UUID        NOT NULL REFERENCES oauth_clients (client_id) ON DELETE CASCADE,
+    scope            TEXT        NOT NULL DEFAULT '',
+    user_profile_id  UUID,
+    auth_time        TIMESTAMPTZ,
+    amr              TEXT        NOT NULL DEFAULT '',
+    denied_at        TIMESTAMPTZ,
+    interval_seconds INTEGER     NOT NULL,
+    last_polled_at   TIMESTAMPTZ,
+    expires_at       TIMESTAMPTZ NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
@@ -0,0 +1,18 @@
+-- Cross-device logins: a web browser shows a QR code that the native app scans and
+-- approves. Only SHA-256 hashes of the browser's poll token and of the scanned code are
+-- stored. The browser's fingerprint binds the poll token to it; its platform, browser and
+-- IP address are shown in the app before approval.
+CREATE TABLE IF NOT EXISTS qr_logins (
+    login_id         UUID PRIMARY KEY,
+    poll_token_hash  TEXT        NOT NULL UNIQUE,
+    scan_code_hash   TEXT        NOT NULL UNIQUE,
+    fingerprint_hash TEXT        NOT NULL,
+    platform         TEXT        NOT NULL DEFAULT '',
+    browser          TEXT        NOT NULL DEFAULT '',
+    ip_address       TEXT        NOT NULL DEFAULT '',
+    user_profile_id  UUID,
+    scanned_at       TIMESTAMPTZ,
+    denied_at        TIMESTAMPTZ,
+    expires_at       TIMESTAMPTZ NOT NULL,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
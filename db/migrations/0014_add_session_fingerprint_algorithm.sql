@@ -0,0 +1,3 @@
+-- The version of the fingerprint algorithm the session's device was fingerprinted with.
+-- Refresh tokens do not carry the fpv claim, so refreshes read it from here.
+ALTER TABLE user_sessions ADD COLUMN IF NOT EXISTS fingerprint_algorithm INTEGER NOT NULL DEFAULT 1;
@@ -0,0 +1,3 @@
+-- The SHA-256 hash of the browser binding cookie set when the login started. Finishing
+-- a login requires the same cookie, so a state cannot be finished in another browser.
+ALTER TABLE social_login_states ADD COLUMN IF NOT EXISTS binding_hash TEXT NOT NULL DEFAULT '';
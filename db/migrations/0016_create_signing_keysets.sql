@@ -0,0 +1,11 @@
+-- Signing keysets of key owners that are not user accounts, such as OAuth clients'
+-- client_credentials keys and the issuer's ID token keys. They are kept apart from users' keysets, so logging a user out
+-- everywhere never revokes a machine client's tokens. The keyset is encrypted like a
+-- user's; an owner without keys keeps its row with an empty keyset_data.
+CREATE TABLE IF NOT EXISTS signing_keysets (
+    owner_id       UUID PRIMARY KEY,
+    keyset_data    TEXT        NOT NULL DEFAULT '',
+    encryption_key TEXT        NOT NULL DEFAULT '',
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
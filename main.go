@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fiber-api/api/services"
 	"fiber-api/config"
 	"log"
@@ -14,15 +15,36 @@ func main() {
 
 	// Create server service
 	serverService, err := services.NewAPIServerService(services.ServerConfig{
-		DatabaseURL: appConfig.Database.URL,
-		Port:        appConfig.Server.Port,
-		Config:      appConfig.JWK,
+		DatabaseURL:   appConfig.Database.URL,
+		Port:          appConfig.Server.Port,
+		Config:        appConfig.JWK,
+		RBAC:          appConfig.RBAC,
+		Signup:        appConfig.Signup,
+		Mail:          appConfig.Mail,
+		PasswordReset: appConfig.PasswordReset,
+		Verification:  appConfig.Verification,
+		MFA:           appConfig.MFA,
+		Lockout:       appConfig.Lockout,
+		RateLimit:     appConfig.RateLimit,
+		WebAuthn:      appConfig.WebAuthn,
+		OIDC:          appConfig.OIDC,
+		OAuth:         appConfig.OAuth,
+		Social:        appConfig.Social,
+		QRLogin:       appConfig.QRLogin,
+		UserAgent:     appConfig.UserAgent,
+		Fingerprint:   appConfig.Fingerprint,
+		NativeApps:    appConfig.NativeApps,
 	})
 	if err != nil {
 		log.Fatal("Failed to create server service:", err)
 	}
 	defer serverService.Close()
 
+	// Create the first admin account if configured
+	if err := serverService.AuthAPIService.BootstrapAdmin(context.Background(), appConfig.Signup.BootstrapAdmin); err != nil {
+		log.Fatal("Failed to bootstrap admin account:", err)
+	}
+
 	// Register all routes
 	serverService.RegisterAllRoutes()
 
@@ -0,0 +1,247 @@
+// Package fingerprint derives the device fingerprints bound to session tokens. Every
+// algorithm has a version number that tokens carry in the "fpv" claim, so a new algorithm
+// can become current while tokens fingerprinted by an older one stay valid until their
+// next refresh. In compatibility mode a browser that updated itself to a newer major
+// version keeps its tokens; the next refresh re-issues them with the new fingerprint.
+package fingerprint
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+	"fiber-api/pkg/useragent"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Token claims holding the fingerprint hash, the version of its algorithm and the
+// device parts it was made from
+const (
+	ClaimHash      = "device_fingerprint"
+	ClaimAlgorithm = "fpv"
+	ClaimPlatform  = "device_platform"
+	ClaimBrowser   = "device_browser"
+	ClaimVersion   = "device_version"
+)
+
+// Config holds the fingerprint checking settings
+type Config struct {
+	// AllowBrowserUpgrades accepts the same platform and browser at a newer major version
+	AllowBrowserUpgrades bool
+}
+
+// Algorithm identifies a fingerprint algorithm version
+type Algorithm int
+
+const (
+	// AlgorithmV1 hashes the OS family, browser family and browser major version
+	// parsed from the User-Agent string
+	AlgorithmV1 Algorithm = 1
+
+	// AlgorithmV2 hashes the same parts, taken from User-Agent Client Hints when the
+	// browser sends them. Without hints it equals AlgorithmV1.
+	AlgorithmV2 Algorithm = 2
+
+	// CurrentAlgorithm is the algorithm new fingerprints are made with
+	CurrentAlgorithm = AlgorithmV2
+)
+
+// unknown stands in for fingerprint parts the User-Agent does not reveal
+const unknown = "unknown"
+
+// Match is the outcome of comparing a request's device with a token's fingerprint
+type Match int
+
+const (
+	// Mismatch means the request comes from another device, or a downgraded browser
+	Mismatch Match = iota
+	// Exact means the request's fingerprint equals the token's
+	Exact
+	// Upgraded means the same platform and browser at a newer major version
+	Upgraded
+)
+
+// Fingerprint describes the device a token was issued to
+type Fingerprint struct {
+	Algorithm Algorithm `json:"fpv"`
+	Hash      string    `json:"hash"`
+	Platform  string    `json:"platform"`
+	Browser   string    `json:"browser"`
+	Version   string    `json:"version"`
+}
+
+// Generator fingerprints requests. It is safe for concurrent use.
+type Generator struct {
+	userAgents *useragent.Parser
+	algorithms map[Algorithm]func(headers useragent.Headers) *Fingerprint
+	config     Config
+}
+
+// New creates a generator that reads User-Agent strings with the shared parser
+func New(userAgents *useragent.Parser, cfg Config) *Generator {
+	g := &Generator{userAgents: userAgents, config: cfg}
+	g.algorithms = map[Algorithm]func(headers useragent.Headers) *Fingerprint{
+		AlgorithmV1: g.fingerprintV1,
+		AlgorithmV2: g.fingerprintV2,
+	}
+	return g
+}
+
+// Generate fingerprints a request's device with the current algorithm
+func (g *Generator) Generate(headers useragent.Headers) *Fingerprint {
+	return g.algorithms[CurrentAlgorithm](headers)
+}
+
+// GenerateWith fingerprints a request's device with the given algorithm
+func (g *Generator) GenerateWith(algorithm Algorithm, headers useragent.Headers) (*Fingerprint, error) {
+	generate, ok := g.algorithms[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("unknown fingerprint algorithm %d", algorithm)
+	}
+	return generate(headers), nil
+}
+
+// Matches reports whether a request's device has the fingerprint hash made with
+// algorithm. Hashes of unknown algorithms never match.
+func (g *Generator) Matches(headers useragent.Headers, hash string, algorithm Algorithm) bool {
+	if hash == "" {
+		return false
+	}
+	current, err := g.GenerateWith(algorithm, headers)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(current.Hash), []byte(hash)) == 1
+}
+
+// Compare fingerprints a request's device with the algorithm of a stored fingerprint
+// and compares the two. Upgraded is only reported in compatibility mode, and only when the
+// stored fingerprint has its parts.
+func (g *Generator) Compare(headers useragent.Headers, stored *Fingerprint) Match {
+	if stored.Hash == "" {
+		return Mismatch
+	}
+	current, err := g.GenerateWith(stored.Algorithm, headers)
+	if err != nil {
+		return Mismatch
+	}
+	if subtle.ConstantTimeCompare([]byte(current.Hash), []byte(stored.Hash)) == 1 {
+		return Exact
+	}
+	if g.config.AllowBrowserUpgrades && current.upgrades(stored) {
+		return Upgraded
+	}
+	return Mismatch
+}
+
+// upgrades reports whether f is the stored device's platform and browser at a newer
+// major version
+func (f *Fingerprint) upgrades(stored *Fingerprint) bool {
+	if stored.Platform == "" || stored.Platform == unknown || stored.Browser == "" || stored.Browser == unknown {
+		return false
+	}
+	if f.Platform != stored.Platform || f.Browser != stored.Browser {
+		return false
+	}
+	storedMajor, err := strconv.Atoi(stored.Version)
+	if err != nil {
+		return false
+	}
+	major, err := strconv.Atoi(f.Version)
+	return err == nil && major > storedMajor
+}
+
+// FromClaims reads the fingerprint of verified token claims. Without the algorithm claim
+// the fingerprint is taken as AlgorithmV1, and without the part claims the parts are left
+// empty. Refresh tokens carry only the hash, so their callers take the algorithm and the
+// parts from the session record instead.
+func FromClaims(claims map[string]interface{}) (*Fingerprint, bool) {
+	hash, ok := claims[ClaimHash].(string)
+	if !ok {
+		return nil, false
+	}
+	fingerprint := &Fingerprint{Algorithm: AlgorithmV1, Hash: hash}
+	fingerprint.Platform, _ = claims[ClaimPlatform].(string)
+	fingerprint.Browser, _ = claims[ClaimBrowser].(string)
+	fingerprint.Version, _ = claims[ClaimVersion].(string)
+
+	raw, exists := claims[ClaimAlgorithm]
+	if !exists {
+		return fingerprint, true
+	}
+	switch version := raw.(type) {
+	case float64:
+		if version != float64(int(version)) {
+			return nil, false
+		}
+		fingerprint.Algorithm = Algorithm(version)
+	case int:
+		fingerprint.Algorithm = Algorithm(version)
+	case json.Number:
+		n, err := version.Int64()
+		if err != nil {
+			return nil, false
+		}
+		fingerprint.Algorithm = Algorithm(n)
+	default:
+		return nil, false
+	}
+	return fingerprint, true
+}
+
+// fingerprintV1 hashes the OS family, browser family and browser major version
+// parsed from the User-Agent string
+func (g *Generator) fingerprintV1(headers useragent.Headers) *Fingerprint {
+	if headers.UserAgent == "" {
+		return &Fingerprint{
+			Algorithm: AlgorithmV1,
+			Hash:      hash(unknown),
+			Platform:  unknown,
+			Browser:   unknown,
+			Version:   unknown,
+		}
+	}
+
+	client := g.userAgents.Parse(headers.UserAgent)
+	return build(AlgorithmV1, client.Os.Family, client.UserAgent.Family, client.UserAgent.Major)
+}
+
+// fingerprintV2 hashes the OS family, browser family and browser major version of the
+// device detected from Client Hints and the User-Agent string
+func (g *Generator) fingerprintV2(headers useragent.Headers) *Fingerprint {
+	if headers.UserAgent == "" && !headers.HasHints() {
+		fingerprint := g.fingerprintV1(headers)
+		fingerprint.Algorithm = AlgorithmV2
+		return fingerprint
+	}
+
+	device := g.userAgents.Detect(headers)
+	return build(AlgorithmV2, device.Platform, device.Browser, device.Major)
+}
+
+// build normalizes fingerprint parts and hashes them
+func build(algorithm Algorithm, platform string, browser string, version string) *Fingerprint {
+	fingerprint := &Fingerprint{
+		Algorithm: algorithm,
+		Platform:  normalize(platform),
+		Browser:   normalize(browser),
+		Version:   normalize(version),
+	}
+	fingerprint.Hash = hash(fmt.Sprintf("%s|%s|%s", fingerprint.Platform, fingerprint.Browser, fingerprint.Version))
+	return fingerprint
+}
+
+// hash returns the hex SHA-256 of a fingerprint's composite string
+func hash(composite string) string {
+	sum := sha256.Sum256([]byte(composite))
+	return fmt.Sprintf("%x", sum)
+}
+
+// normalize lowercases a fingerprint part, so parser casing changes keep hashes stable
+func normalize(s string) string {
+	if s == "" {
+		return unknown
+	}
+	return strings.ToLower(strings.TrimSpace(s))
+}
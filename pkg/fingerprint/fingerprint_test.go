@@ -0,0 +1,103 @@
+package fingerprint
+
+import (
+	"fiber-api/pkg/useragent"
+	"testing"
+)
+
+// braveWindows is Brave 120 on Windows: its User-Agent string is Chrome's, and only the
+// Client Hints name the brand
+var braveWindows = useragent.Headers{
+	UserAgent: "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36",
+	UA:        `"Not_A Brand";v="8", "Brave";v="120", "Chromium";v="120"`,
+	Platform:  `"Windows"`,
+	Mobile:    "?0",
+}
+
+func newGenerator(t testing.TB, cfg Config) *Generator {
+	t.Helper()
+	userAgents, err := useragent.New(16)
+	if err != nil {
+		t.Fatalf("useragent.New: %v", err)
+	}
+	return New(userAgents, cfg)
+}
+
+func TestBrandDiffersFromUserAgentFamily(t *testing.T) {
+	g := newGenerator(t, Config{AllowBrowserUpgrades: true})
+
+	login := g.Generate(braveWindows)
+	if login.Algorithm != AlgorithmV2 || login.Platform != "windows" || login.Browser != "brave" || login.Version != "120" {
+		t.Fatalf("login fingerprint = %+v, want V2 windows|brave|120", login)
+	}
+	v1, err := g.GenerateWith(AlgorithmV1, braveWindows)
+	if err != nil {
+		t.Fatalf("GenerateWith(V1): %v", err)
+	}
+	if v1.Browser != "chrome" || v1.Hash == login.Hash {
+		t.Fatalf("V1 fingerprint = %+v, want the chrome family with another hash", v1)
+	}
+
+	// A refresh token carries only the hash; the session record supplies the algorithm
+	// and the parts
+	refreshClaims := map[string]interface{}{ClaimHash: login.Hash}
+	stored, ok := FromClaims(refreshClaims)
+	if !ok {
+		t.Fatal("FromClaims rejected a refresh token's claims")
+	}
+	stored.Algorithm = login.Algorithm
+	stored.Platform, stored.Browser, stored.Version = login.Platform, login.Browser, login.Version
+	if got := g.Compare(braveWindows, stored); got != Exact {
+		t.Errorf("Compare with the session's algorithm = %v, want Exact", got)
+	}
+
+	// Checking the V2 hash with the V1 default reads the Chrome family and must not match
+	stored.Algorithm = AlgorithmV1
+	if got := g.Compare(braveWindows, stored); got != Mismatch {
+		t.Errorf("Compare with V1 = %v, want Mismatch", got)
+	}
+}
+
+func TestAccessTokenClaimsRoundTrip(t *testing.T) {
+	g := newGenerator(t, Config{})
+	login := g.Generate(braveWindows)
+
+	// Claims decoded from JSON hold numbers as float64
+	claims := map[string]interface{}{
+		ClaimHash:      login.Hash,
+		ClaimAlgorithm: float64(login.Algorithm),
+		ClaimPlatform:  login.Platform,
+		ClaimBrowser:   login.Browser,
+		ClaimVersion:   login.Version,
+	}
+	stored, ok := FromClaims(claims)
+	if !ok {
+		t.Fatal("FromClaims rejected access token claims")
+	}
+	if *stored != *login {
+		t.Fatalf("FromClaims = %+v, want %+v", stored, login)
+	}
+	if got := g.Compare(braveWindows, stored); got != Exact {
+		t.Errorf("Compare = %v, want Exact", got)
+	}
+}
+
+func TestBrowserUpgrade(t *testing.T) {
+	updated := braveWindows
+	updated.UA = `"Not_A Brand";v="8", "Brave";v="121", "Chromium";v="121"`
+	updated.UserAgent = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/121.0.0.0 Safari/537.36"
+
+	strict := newGenerator(t, Config{})
+	compatible := newGenerator(t, Config{AllowBrowserUpgrades: true})
+	stored := strict.Generate(braveWindows)
+
+	if got := strict.Compare(updated, stored); got != Mismatch {
+		t.Errorf("strict Compare after an update = %v, want Mismatch", got)
+	}
+	if got := compatible.Compare(updated, stored); got != Upgraded {
+		t.Errorf("compatible Compare after an update = %v, want Upgraded", got)
+	}
+	if got := compatible.Compare(braveWindows, compatible.Generate(updated)); got != Mismatch {
+		t.Errorf("compatible Compare after a downgrade = %v, want Mismatch", got)
+	}
+}
@@ -0,0 +1,50 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// LogMailer writes messages to the application log or a file instead of sending them.
+// Intended for local development.
+type LogMailer struct {
+	from     string
+	filePath string
+	mu       sync.Mutex
+}
+
+// NewLogMailer creates a log mailer. Messages are appended to filePath when it is set.
+func NewLogMailer(from string, filePath string) *LogMailer {
+	return &LogMailer{
+		from:     from,
+		filePath: filePath,
+	}
+}
+
+// Send records the message
+func (m *LogMailer) Send(ctx context.Context, msg Message) error {
+	if m.filePath == "" {
+		log.Printf("📧 Email to %s: %s\n%s", msg.To, msg.Subject, msg.Body)
+		return nil
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	file, err := os.OpenFile(m.filePath, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open mail file %s: %w", m.filePath, err)
+	}
+	defer file.Close()
+
+	_, err = fmt.Fprintf(file, "Date: %s\nFrom: %s\nTo: %s\nSubject: %s\n\n%s\n\n----\n\n",
+		time.Now().Format(time.RFC1123Z), m.from, msg.To, msg.Subject, msg.Body)
+	if err != nil {
+		return fmt.Errorf("failed to write mail file %s: %w", m.filePath, err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+)
+
+// Message represents a plain-text email
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer delivers email messages
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Supported mailer drivers
+const (
+	DriverSMTP = "smtp"
+	DriverLog  = "log"
+)
+
+// Config holds the settings for every mailer driver
+type Config struct {
+	Driver   string
+	From     string
+	Host     string
+	Port     int
+	Username string
+	Password string
+	// FilePath makes the log driver append messages to a file instead of the application log
+	FilePath string
+}
+
+// New creates the mailer selected by cfg.Driver
+func New(cfg Config) (Mailer, error) {
+	switch cfg.Driver {
+	case DriverSMTP:
+		return NewSMTPMailer(cfg), nil
+	case DriverLog, "":
+		return NewLogMailer(cfg.From, cfg.FilePath), nil
+	default:
+		return nil, fmt.Errorf("unknown mailer driver: %s", cfg.Driver)
+	}
+}
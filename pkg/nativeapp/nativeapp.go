@@ -0,0 +1,259 @@
+// Package nativeapp verifies the signed headers native Android and iOS apps identify
+// themselves with. Each app is registered with an HMAC-SHA256 secret or an Ed25519
+// public key, and signs the app headers, a timestamp, a nonce, the request line and a
+// digest of the body with it.
+package nativeapp
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Request headers sent by native apps
+const (
+	HeaderAppID     = "X-App-ID"
+	HeaderVersion   = "X-App-Version"
+	HeaderPlatform  = "X-App-Platform"
+	HeaderTimestamp = "X-App-Timestamp"
+	HeaderNonce     = "X-App-Nonce"
+	HeaderSignature = "X-App-Signature"
+)
+
+// Platforms native apps run on
+const (
+	PlatformAndroid = "android"
+	PlatformIOS     = "ios"
+)
+
+// Fallback modes for requests without a verified app signature
+const (
+	// FallbackWeb treats every unverified client as a web browser
+	FallbackWeb = "web"
+	// FallbackHeuristic keeps guessing native apps from the User-Agent, for app
+	// releases that do not sign their requests yet
+	FallbackHeuristic = "heuristic"
+	// FallbackReject refuses unverified clients that claim or look like a native app
+	FallbackReject = "reject"
+)
+
+// defaultMaxSkew applies when Config.MaxSkew is not set
+const defaultMaxSkew = 5 * time.Minute
+
+// maxVersionLength bounds the app version header
+const maxVersionLength = 64
+
+// Bounds of the nonce header; the lower one keeps nonces from being guessable
+const (
+	minNonceLength = 16
+	maxNonceLength = 128
+)
+
+// ErrVerification is wrapped by every error caused by invalid app headers
+var ErrVerification = errors.New("native app verification failed")
+
+// App is a native app registered on the server
+type App struct {
+	// ID is the app identifier sent in X-App-ID, e.g. com.example.app
+	ID string
+	// Platform is android or ios
+	Platform string
+	// Secret is the HMAC-SHA256 signing secret; PublicKey is a base64 Ed25519 public key.
+	// Exactly one of them is set.
+	Secret    string
+	PublicKey string
+}
+
+// Config holds the registered apps and the verification settings
+type Config struct {
+	Apps []App
+	// MaxSkew is how far the signed timestamp may be from the server clock. A nonce is
+	// remembered for as long as its request would pass this check.
+	MaxSkew time.Duration
+	// Fallback is web, heuristic or reject
+	Fallback string
+}
+
+// Request holds the app headers and the request line and body they are signed with
+type Request struct {
+	AppID     string
+	Version   string
+	Platform  string
+	Timestamp string
+	Nonce     string
+	Signature string
+	Method    string
+	Path      string
+	Body      []byte
+}
+
+// Present reports whether the request claims to come from a native app
+func (r Request) Present() bool {
+	return r.AppID != "" || r.Signature != ""
+}
+
+// SigningString returns the bytes an app signs: the app ID, version, platform, Unix
+// timestamp, nonce, HTTP method, path and the hex SHA-256 digest of the body, joined
+// by newlines
+func (r Request) SigningString() string {
+	digest := sha256.Sum256(r.Body)
+	return strings.Join([]string{r.AppID, r.Version, r.Platform, r.Timestamp, r.Nonce, strings.ToUpper(r.Method), r.Path, hex.EncodeToString(digest[:])}, "\n")
+}
+
+// Identity is a verified native app
+type Identity struct {
+	AppID    string
+	Version  string
+	Platform string
+}
+
+// registeredApp is an App with its decoded key
+type registeredApp struct {
+	App
+	publicKey ed25519.PublicKey
+}
+
+// Verifier checks the signed headers of native apps. It is safe for concurrent use.
+type Verifier struct {
+	apps     map[string]*registeredApp
+	maxSkew  time.Duration
+	fallback string
+
+	// nonces maps the used nonces of each app to when they may be forgotten
+	mu        sync.Mutex
+	nonces    map[string]time.Time
+	nextPrune time.Time
+}
+
+// New validates the registered apps and creates a verifier
+func New(cfg Config) (*Verifier, error) {
+	v := &Verifier{
+		apps:     make(map[string]*registeredApp, len(cfg.Apps)),
+		maxSkew:  cfg.MaxSkew,
+		fallback: cfg.Fallback,
+		nonces:   make(map[string]time.Time),
+	}
+	if v.maxSkew <= 0 {
+		v.maxSkew = defaultMaxSkew
+	}
+	if v.fallback == "" {
+		v.fallback = FallbackWeb
+	}
+	if v.fallback != FallbackWeb && v.fallback != FallbackHeuristic && v.fallback != FallbackReject {
+		return nil, fmt.Errorf("unknown native app fallback %q", cfg.Fallback)
+	}
+
+	for _, app := range cfg.Apps {
+		if app.ID == "" {
+			return nil, fmt.Errorf("native app ID is required")
+		}
+		if app.Platform != PlatformAndroid && app.Platform != PlatformIOS {
+			return nil, fmt.Errorf("native app %s has unknown platform %q", app.ID, app.Platform)
+		}
+		if _, exists := v.apps[app.ID]; exists {
+			return nil, fmt.Errorf("native app %s is registered twice", app.ID)
+		}
+
+		registered := &registeredApp{App: app}
+		switch {
+		case app.Secret != "" && app.PublicKey != "":
+			return nil, fmt.Errorf("native app %s must have a secret or a public key, not both", app.ID)
+		case app.PublicKey != "":
+			key, err := base64.StdEncoding.DecodeString(app.PublicKey)
+			if err != nil || len(key) != ed25519.PublicKeySize {
+				return nil, fmt.Errorf("native app %s public key must be a base64 Ed25519 key", app.ID)
+			}
+			registered.publicKey = key
+		case len(app.Secret) < 32:
+			return nil, fmt.Errorf("native app %s secret must be at least 32 characters", app.ID)
+		}
+		v.apps[app.ID] = registered
+	}
+	return v, nil
+}
+
+// Fallback returns the mode for requests without a verified app signature
+func (v *Verifier) Fallback() string {
+	return v.fallback
+}
+
+// Verify checks the app headers of a request against the registered app and the
+// current time, and rejects a nonce the app already used. Errors wrap ErrVerification.
+func (v *Verifier) Verify(req Request, now time.Time) (*Identity, error) {
+	app, ok := v.apps[req.AppID]
+	if !ok {
+		return nil, fmt.Errorf("%w: unknown app %q", ErrVerification, req.AppID)
+	}
+	if req.Platform != app.Platform {
+		return nil, fmt.Errorf("%w: app %s does not run on %q", ErrVerification, app.ID, req.Platform)
+	}
+	if req.Version == "" || len(req.Version) > maxVersionLength {
+		return nil, fmt.Errorf("%w: invalid app version", ErrVerification)
+	}
+
+	timestamp, err := strconv.ParseInt(req.Timestamp, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("%w: invalid timestamp", ErrVerification)
+	}
+	if skew := now.Sub(time.Unix(timestamp, 0)); skew > v.maxSkew || skew < -v.maxSkew {
+		return nil, fmt.Errorf("%w: timestamp outside the allowed clock skew", ErrVerification)
+	}
+	if len(req.Nonce) < minNonceLength || len(req.Nonce) > maxNonceLength {
+		return nil, fmt.Errorf("%w: invalid nonce", ErrVerification)
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(req.Signature, "="))
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature is not base64url", ErrVerification)
+	}
+	message := []byte(req.SigningString())
+	if app.publicKey != nil {
+		if !ed25519.Verify(app.publicKey, message, signature) {
+			return nil, fmt.Errorf("%w: invalid signature", ErrVerification)
+		}
+	} else {
+		mac := hmac.New(sha256.New, []byte(app.Secret))
+		mac.Write(message)
+		if !hmac.Equal(mac.Sum(nil), signature) {
+			return nil, fmt.Errorf("%w: invalid signature", ErrVerification)
+		}
+	}
+
+	// Only signed nonces are recorded, so nobody else can use up an app's nonces
+	if err := v.useNonce(app.ID, req.Nonce, time.Unix(timestamp, 0), now); err != nil {
+		return nil, err
+	}
+	return &Identity{AppID: app.ID, Version: req.Version, Platform: app.Platform}, nil
+}
+
+// useNonce records a nonce of an app and fails when the app already used it. A nonce is
+// kept until its timestamp is outside the allowed clock skew, from when the timestamp
+// check alone rejects its request.
+func (v *Verifier) useNonce(appID string, nonce string, signedAt time.Time, now time.Time) error {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	if now.After(v.nextPrune) {
+		for key, forgetAt := range v.nonces {
+			if now.After(forgetAt) {
+				delete(v.nonces, key)
+			}
+		}
+		v.nextPrune = now.Add(v.maxSkew)
+	}
+
+	key := appID + "\n" + nonce
+	if forgetAt, used := v.nonces[key]; used && !now.After(forgetAt) {
+		return fmt.Errorf("%w: nonce already used", ErrVerification)
+	}
+	v.nonces[key] = signedAt.Add(v.maxSkew)
+	return nil
+}
@@ -0,0 +1,174 @@
+package nativeapp_test
+
+import (
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fiber-api/pkg/nativeapp"
+	"strconv"
+	"testing"
+	"time"
+)
+
+const secret = "0123456789abcdef0123456789abcdef"
+
+// signer signs requests as a registered app does
+type signer struct {
+	app        nativeapp.App
+	privateKey ed25519.PrivateKey
+}
+
+func hmacApp() signer {
+	return signer{app: nativeapp.App{ID: "com.example.android", Platform: nativeapp.PlatformAndroid, Secret: secret}}
+}
+
+func ed25519App(t *testing.T) signer {
+	t.Helper()
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate app key: %v", err)
+	}
+	app := nativeapp.App{ID: "com.example.ios", Platform: nativeapp.PlatformIOS, PublicKey: base64.StdEncoding.EncodeToString(publicKey)}
+	return signer{app: app, privateKey: privateKey}
+}
+
+// request builds a signed request with a new nonce at the given time
+func (s signer) request(t *testing.T, at time.Time, body string) nativeapp.Request {
+	t.Helper()
+	nonce := make([]byte, 16)
+	if _, err := rand.Read(nonce); err != nil {
+		t.Fatalf("failed to generate nonce: %v", err)
+	}
+	req := nativeapp.Request{
+		AppID:     s.app.ID,
+		Version:   "1.2.3",
+		Platform:  s.app.Platform,
+		Timestamp: strconv.FormatInt(at.Unix(), 10),
+		Nonce:     base64.RawURLEncoding.EncodeToString(nonce),
+		Method:    "POST",
+		Path:      "/api/login",
+		Body:      []byte(body),
+	}
+	s.sign(&req)
+	return req
+}
+
+func (s signer) sign(req *nativeapp.Request) {
+	var signature []byte
+	if s.privateKey != nil {
+		signature = ed25519.Sign(s.privateKey, []byte(req.SigningString()))
+	} else {
+		mac := hmac.New(sha256.New, []byte(s.app.Secret))
+		mac.Write([]byte(req.SigningString()))
+		signature = mac.Sum(nil)
+	}
+	req.Signature = base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func newVerifier(t *testing.T, apps ...signer) *nativeapp.Verifier {
+	t.Helper()
+	cfg := nativeapp.Config{MaxSkew: 5 * time.Minute}
+	for _, app := range apps {
+		cfg.Apps = append(cfg.Apps, app.app)
+	}
+	v, err := nativeapp.New(cfg)
+	if err != nil {
+		t.Fatalf("nativeapp.New: %v", err)
+	}
+	return v
+}
+
+func TestVerify(t *testing.T) {
+	for name, app := range map[string]signer{"hmac": hmacApp(), "ed25519": ed25519App(t)} {
+		t.Run(name, func(t *testing.T) {
+			v := newVerifier(t, app)
+			now := time.Now()
+			identity, err := v.Verify(app.request(t, now, `{"user_email":"user@example.com"}`), now)
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if identity.AppID != app.app.ID || identity.Platform != app.app.Platform || identity.Version != "1.2.3" {
+				t.Errorf("identity = %+v, want app %s on %s at 1.2.3", identity, app.app.ID, app.app.Platform)
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsClockSkew(t *testing.T) {
+	app := hmacApp()
+	v := newVerifier(t, app)
+	// Timestamps have whole seconds
+	now := time.Now().Truncate(time.Second)
+
+	for _, skew := range []time.Duration{-5 * time.Minute, 5 * time.Minute} {
+		if _, err := v.Verify(app.request(t, now.Add(skew), ""), now); err != nil {
+			t.Errorf("timestamp %s from now: %v", skew, err)
+		}
+	}
+	for _, skew := range []time.Duration{-6 * time.Minute, 6 * time.Minute} {
+		if _, err := v.Verify(app.request(t, now.Add(skew), ""), now); !errors.Is(err, nativeapp.ErrVerification) {
+			t.Errorf("timestamp %s from now: error = %v, want ErrVerification", skew, err)
+		}
+	}
+}
+
+func TestVerifyRejectsTampering(t *testing.T) {
+	for name, app := range map[string]signer{"hmac": hmacApp(), "ed25519": ed25519App(t)} {
+		t.Run(name, func(t *testing.T) {
+			v := newVerifier(t, app)
+			now := time.Now()
+			tests := map[string]func(req *nativeapp.Request){
+				"body":      func(req *nativeapp.Request) { req.Body = []byte(`{"user_email":"admin@example.com"}`) },
+				"path":      func(req *nativeapp.Request) { req.Path = "/api/signup" },
+				"method":    func(req *nativeapp.Request) { req.Method = "PUT" },
+				"version":   func(req *nativeapp.Request) { req.Version = "9.9.9" },
+				"nonce":     func(req *nativeapp.Request) { req.Nonce += "x" },
+				"timestamp": func(req *nativeapp.Request) { req.Timestamp = strconv.FormatInt(now.Unix()+1, 10) },
+				"signature": func(req *nativeapp.Request) { req.Signature = req.Signature[1:] + "A" },
+			}
+			for field, tamper := range tests {
+				req := app.request(t, now, `{"user_email":"user@example.com"}`)
+				tamper(&req)
+				if _, err := v.Verify(req, now); !errors.Is(err, nativeapp.ErrVerification) {
+					t.Errorf("tampered %s: error = %v, want ErrVerification", field, err)
+				}
+			}
+		})
+	}
+}
+
+func TestVerifyRejectsReplay(t *testing.T) {
+	app := hmacApp()
+	v := newVerifier(t, app)
+	now := time.Now()
+
+	req := app.request(t, now, `{"user_email":"user@example.com"}`)
+	if _, err := v.Verify(req, now); err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	// Replayed up to the end of the clock skew window
+	for _, later := range []time.Duration{0, time.Second, 5 * time.Minute} {
+		if _, err := v.Verify(req, now.Add(later)); !errors.Is(err, nativeapp.ErrVerification) {
+			t.Errorf("replay after %s: error = %v, want ErrVerification", later, err)
+		}
+	}
+
+	// The same nonce signed again, e.g. for another path, is a replay too
+	again := req
+	again.Path = "/api/user"
+	app.sign(&again)
+	if _, err := v.Verify(again, now); !errors.Is(err, nativeapp.ErrVerification) {
+		t.Errorf("reused nonce: error = %v, want ErrVerification", err)
+	}
+
+	// A request signed without a nonce
+	missing := app.request(t, now, "")
+	missing.Nonce = ""
+	app.sign(&missing)
+	if _, err := v.Verify(missing, now); !errors.Is(err, nativeapp.ErrVerification) {
+		t.Errorf("missing nonce: error = %v, want ErrVerification", err)
+	}
+}
@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// AuthCodeURL returns the provider URL that starts an authorization code flow with the
+// given state, nonce and S256 PKCE challenge
+func (p *Provider) AuthCodeURL(ctx context.Context, state string, nonce string, codeChallenge string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	target, err := url.Parse(doc.AuthorizationEndpoint)
+	if err != nil {
+		return "", fmt.Errorf("invalid authorization endpoint of %s: %w", p.cfg.Name, err)
+	}
+	query := target.Query()
+	query.Set("response_type", "code")
+	query.Set("client_id", p.cfg.ClientID)
+	query.Set("redirect_uri", p.cfg.RedirectURL)
+	query.Set("scope", strings.Join(append([]string{"openid"}, p.cfg.Scopes...), " "))
+	query.Set("state", state)
+	query.Set("nonce", nonce)
+	query.Set("code_challenge", codeChallenge)
+	query.Set("code_challenge_method", "S256")
+	target.RawQuery = query.Encode()
+	return target.String(), nil
+}
+
+// tokenResponse is the part of a token endpoint response the relying party uses
+type tokenResponse struct {
+	IDToken          string `json:"id_token"`
+	Error            string `json:"error"`
+	ErrorDescription string `json:"error_description"`
+}
+
+// Exchange redeems an authorization code at the provider's token endpoint and returns
+// the raw ID token. The client authenticates with HTTP Basic (client_secret_basic).
+func (p *Provider) Exchange(ctx context.Context, code string, codeVerifier string) (string, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	form := url.Values{
+		"grant_type":    {"authorization_code"},
+		"code":          {code},
+		"redirect_uri":  {p.cfg.RedirectURL},
+		"code_verifier": {codeVerifier},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, doc.TokenEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(p.cfg.ClientID), url.QueryEscape(p.cfg.ClientSecret))
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed to call token endpoint of %s: %w", p.cfg.Name, err)
+	}
+	defer resp.Body.Close()
+
+	var tokens tokenResponse
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 1<<20)).Decode(&tokens); err != nil {
+		return "", fmt.Errorf("invalid token response from %s: %w", p.cfg.Name, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		// A bad or replayed code is the caller's fault, not an outage
+		if tokens.Error == "invalid_grant" {
+			return "", fmt.Errorf("%w: %s rejected the code: %s", ErrVerification, p.cfg.Name, tokens.ErrorDescription)
+		}
+		return "", fmt.Errorf("token endpoint of %s returned %d: %s", p.cfg.Name, resp.StatusCode, tokens.Error)
+	}
+	if tokens.IDToken == "" {
+		return "", fmt.Errorf("%w: %s returned no id_token", ErrVerification, p.cfg.Name)
+	}
+	return tokens.IDToken, nil
+}
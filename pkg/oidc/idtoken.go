@@ -0,0 +1,136 @@
+package oidc
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/lestrrat-go/jwx/v3/jws"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// keyRefreshInterval limits how often an unknown kid triggers a JWKS refetch
+const keyRefreshInterval = time.Minute
+
+// clockSkew is the tolerance applied to exp, iat and nbf
+const clockSkew = time.Minute
+
+// Claims holds the verified identity claims of an ID token
+type Claims struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+	// AuthMethods are the provider's amr values, when it sends them
+	AuthMethods []string
+}
+
+// VerifyIDToken checks an ID token's signature against the provider JWKS, its issuer,
+// audience, expiry and nonce, and returns its identity claims
+func (p *Provider) VerifyIDToken(ctx context.Context, rawIDToken string, nonce string) (*Claims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	message, err := jws.Parse([]byte(rawIDToken))
+	if err != nil || len(message.Signatures()) != 1 {
+		return nil, fmt.Errorf("%w: malformed id token", ErrVerification)
+	}
+	kid, _ := message.Signatures()[0].ProtectedHeaders().KeyID()
+
+	keys, err := p.signingKeys(ctx, doc.JWKSURI, kid)
+	if err != nil {
+		return nil, err
+	}
+
+	token, err := jwt.Parse([]byte(rawIDToken),
+		jwt.WithKeySet(keys, jws.WithInferAlgorithmFromKey(true)),
+		jwt.WithIssuer(p.cfg.Issuer),
+		jwt.WithAudience(p.cfg.ClientID),
+		jwt.WithAcceptableSkew(clockSkew),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrVerification, err)
+	}
+
+	// With several audiences the token must name this client as its authorized party
+	if audience, _ := token.Audience(); len(audience) > 1 {
+		var azp string
+		if err := token.Get("azp", &azp); err != nil || azp != p.cfg.ClientID {
+			return nil, fmt.Errorf("%w: id token was issued to another party", ErrVerification)
+		}
+	}
+
+	var tokenNonce string
+	if err := token.Get("nonce", &tokenNonce); err != nil || tokenNonce != nonce {
+		return nil, fmt.Errorf("%w: nonce mismatch", ErrVerification)
+	}
+
+	claims := &Claims{}
+	if claims.Subject, _ = token.Subject(); claims.Subject == "" {
+		return nil, fmt.Errorf("%w: id token has no subject", ErrVerification)
+	}
+	_ = token.Get("email", &claims.Email)
+	_ = token.Get("name", &claims.Name)
+	claims.EmailVerified = emailVerified(token)
+	var amr []interface{}
+	if token.Get("amr", &amr) == nil {
+		for _, method := range amr {
+			if value, ok := method.(string); ok {
+				claims.AuthMethods = append(claims.AuthMethods, value)
+			}
+		}
+	}
+	return claims, nil
+}
+
+// emailVerified reads email_verified, which some providers send as a string
+func emailVerified(token jwt.Token) bool {
+	var value interface{}
+	if err := token.Get("email_verified", &value); err != nil {
+		return false
+	}
+	switch verified := value.(type) {
+	case bool:
+		return verified
+	case string:
+		return verified == "true"
+	}
+	return false
+}
+
+// signingKeys returns the provider JWKS, refetching it when it does not hold kid yet:
+// providers rotate keys and publish the new one before signing with it
+func (p *Provider) signingKeys(ctx context.Context, jwksURI string, kid string) (jwk.Set, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.keys != nil {
+		if _, ok := p.keys.LookupKeyID(kid); ok || kid == "" || time.Since(p.keysFetch) < keyRefreshInterval {
+			return p.keys, nil
+		}
+	}
+
+	set, err := p.fetchKeys(ctx, jwksURI)
+	if err != nil {
+		// Keep verifying with the cached keys while the provider is unreachable
+		if p.keys != nil {
+			return p.keys, nil
+		}
+		return nil, fmt.Errorf("failed to fetch signing keys of %s: %w", p.cfg.Name, err)
+	}
+	p.keys = set
+	p.keysFetch = time.Now()
+	return set, nil
+}
+
+// fetchKeys downloads and parses the provider JWKS
+func (p *Provider) fetchKeys(ctx context.Context, jwksURI string) (jwk.Set, error) {
+	body, err := p.get(ctx, jwksURI)
+	if err != nil {
+		return nil, err
+	}
+	return jwk.Parse(body)
+}
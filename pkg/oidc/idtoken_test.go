@@ -0,0 +1,54 @@
+package oidc
+
+import (
+	"context"
+	"fiber-api/pkg/oidc/oidctest"
+	"testing"
+	"time"
+)
+
+func TestSigningKeyRotation(t *testing.T) {
+	mock := oidctest.NewProvider(t, "client-123")
+	provider, err := New(Config{Name: "mock", Issuer: mock.Issuer(), ClientID: "client-123", RedirectURL: "http://localhost/callback"})
+	if err != nil {
+		t.Fatalf("New: %v", err)
+	}
+	ctx := context.Background()
+	claims := map[string]interface{}{"nonce": "nonce"}
+
+	if _, err := provider.VerifyIDToken(ctx, mock.IDToken(claims), "nonce"); err != nil {
+		t.Fatalf("VerifyIDToken before rotation: %v", err)
+	}
+
+	// A token signed with a new key is not verified until the key set may be refetched
+	mock.RotateKey(true)
+	rotated := mock.IDToken(claims)
+	if _, err := provider.VerifyIDToken(ctx, rotated, "nonce"); err == nil {
+		t.Fatal("VerifyIDToken accepted an unknown kid without refetching the key set")
+	}
+	if got := mock.Requests("/jwks"); got != 1 {
+		t.Fatalf("key set fetched %d times within keyRefreshInterval, want 1", got)
+	}
+
+	provider.mu.Lock()
+	provider.keysFetch = time.Now().Add(-keyRefreshInterval)
+	provider.mu.Unlock()
+	if _, err := provider.VerifyIDToken(ctx, rotated, "nonce"); err != nil {
+		t.Fatalf("VerifyIDToken after rotation: %v", err)
+	}
+	if got := mock.Requests("/jwks"); got != 2 {
+		t.Errorf("key set fetched %d times, want 2", got)
+	}
+
+	// Tokens signed with the previous key stay valid while the provider still publishes it
+	mock.RotateKey(false)
+	provider.mu.Lock()
+	provider.keysFetch = time.Now().Add(-keyRefreshInterval)
+	provider.mu.Unlock()
+	if _, err := provider.VerifyIDToken(ctx, mock.IDToken(claims), "nonce"); err != nil {
+		t.Fatalf("VerifyIDToken after second rotation: %v", err)
+	}
+	if _, err := provider.VerifyIDToken(ctx, rotated, "nonce"); err == nil {
+		t.Error("VerifyIDToken accepted a token signed with a retired key")
+	}
+}
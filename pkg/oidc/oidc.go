@@ -0,0 +1,135 @@
+package oidc
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v3/jwk"
+)
+
+// ErrVerification is wrapped by every error caused by an invalid provider response
+var ErrVerification = errors.New("oidc verification failed")
+
+// defaultTimeout bounds every request to a provider
+const defaultTimeout = 10 * time.Second
+
+// Config holds the settings of one external OpenID Connect provider
+type Config struct {
+	// Name identifies the provider in URLs and linked identities, e.g. google
+	Name string
+	// Issuer is the provider's issuer URL; its discovery document lives under it
+	Issuer string
+	// ClientID and ClientSecret are the credentials registered with the provider
+	ClientID     string
+	ClientSecret string
+	// RedirectURL is the page the provider sends the user back to with the code
+	RedirectURL string
+	// Scopes are requested on top of openid
+	Scopes []string
+}
+
+// Provider is an OpenID Connect relying party for one provider. Its discovery document
+// and signing keys are fetched on first use and cached.
+type Provider struct {
+	cfg    Config
+	client *http.Client
+
+	mu        sync.Mutex
+	metadata  *metadata
+	keys      jwk.Set
+	keysFetch time.Time
+}
+
+// New creates a provider from cfg
+func New(cfg Config) (*Provider, error) {
+	if cfg.Name == "" {
+		return nil, fmt.Errorf("oidc provider name is required")
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("oidc provider %s requires an issuer, client ID and redirect URL", cfg.Name)
+	}
+	cfg.Issuer = strings.TrimSuffix(cfg.Issuer, "/")
+	return &Provider{cfg: cfg, client: &http.Client{Timeout: defaultTimeout}}, nil
+}
+
+// Name returns the provider name
+func (p *Provider) Name() string {
+	return p.cfg.Name
+}
+
+// NewRandom returns a random URL-safe value for state, nonce and PKCE verifiers
+func NewRandom() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate random value: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// CodeChallenge returns the S256 PKCE challenge of a code verifier
+func CodeChallenge(verifier string) string {
+	sum := sha256.Sum256([]byte(verifier))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// metadata is the part of the discovery document the relying party uses
+type metadata struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JWKSURI               string `json:"jwks_uri"`
+}
+
+// discover returns the provider metadata, fetching it on first use
+func (p *Provider) discover(ctx context.Context) (*metadata, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.metadata != nil {
+		return p.metadata, nil
+	}
+
+	body, err := p.get(ctx, p.cfg.Issuer+"/.well-known/openid-configuration")
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch discovery document of %s: %w", p.cfg.Name, err)
+	}
+	var doc metadata
+	if err := json.Unmarshal(body, &doc); err != nil {
+		return nil, fmt.Errorf("invalid discovery document of %s: %w", p.cfg.Name, err)
+	}
+	if doc.Issuer != p.cfg.Issuer {
+		return nil, fmt.Errorf("%w: discovery issuer %q does not match %q", ErrVerification, doc.Issuer, p.cfg.Issuer)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JWKSURI == "" {
+		return nil, fmt.Errorf("discovery document of %s is missing endpoints", p.cfg.Name)
+	}
+	p.metadata = &doc
+	return p.metadata, nil
+}
+
+// get fetches a JSON document from the provider, up to 1 MiB
+func (p *Provider) get(ctx context.Context, url string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+}
@@ -0,0 +1,165 @@
+package oidc_test
+
+import (
+	"context"
+	"errors"
+	"fiber-api/pkg/oidc"
+	"fiber-api/pkg/oidc/oidctest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const clientID = "client-123"
+
+func newProvider(t *testing.T, mock *oidctest.Provider) *oidc.Provider {
+	t.Helper()
+	provider, err := oidc.New(oidc.Config{
+		Name:         "mock",
+		Issuer:       mock.Issuer(),
+		ClientID:     clientID,
+		ClientSecret: "secret",
+		RedirectURL:  "http://localhost:3000/login/mock/callback",
+		Scopes:       []string{"email"},
+	})
+	if err != nil {
+		t.Fatalf("oidc.New: %v", err)
+	}
+	return provider
+}
+
+func TestDiscovery(t *testing.T) {
+	mock := oidctest.NewProvider(t, clientID)
+	provider := newProvider(t, mock)
+	ctx := context.Background()
+
+	authorizationURL, err := provider.AuthCodeURL(ctx, "state", "nonce", oidc.CodeChallenge("verifier"))
+	if err != nil {
+		t.Fatalf("AuthCodeURL: %v", err)
+	}
+	target, err := url.Parse(authorizationURL)
+	if err != nil {
+		t.Fatalf("invalid authorization URL %q: %v", authorizationURL, err)
+	}
+	if got := target.Scheme + "://" + target.Host + target.Path; got != mock.Issuer()+"/authorize" {
+		t.Errorf("authorization endpoint = %s, want the discovered one", got)
+	}
+	query := target.Query()
+	for name, want := range map[string]string{
+		"client_id":             clientID,
+		"scope":                 "openid email",
+		"state":                 "state",
+		"nonce":                 "nonce",
+		"code_challenge":        oidc.CodeChallenge("verifier"),
+		"code_challenge_method": "S256",
+	} {
+		if got := query.Get(name); got != want {
+			t.Errorf("%s = %q, want %q", name, got, want)
+		}
+	}
+
+	// The document is fetched once and cached
+	if _, err := provider.AuthCodeURL(ctx, "state", "nonce", "challenge"); err != nil {
+		t.Fatalf("second AuthCodeURL: %v", err)
+	}
+	if got := mock.Requests("/.well-known/openid-configuration"); got != 1 {
+		t.Errorf("discovery fetched %d times, want 1", got)
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	mock := oidctest.NewProvider(t, clientID)
+	provider, err := oidc.New(oidc.Config{
+		Name:        "mock",
+		Issuer:      strings.Replace(mock.Issuer(), "127.0.0.1", "localhost", 1),
+		ClientID:    clientID,
+		RedirectURL: "http://localhost:3000/login/mock/callback",
+	})
+	if err != nil {
+		t.Fatalf("oidc.New: %v", err)
+	}
+	_, err = provider.AuthCodeURL(context.Background(), "state", "nonce", "challenge")
+	if !errors.Is(err, oidc.ErrVerification) {
+		t.Fatalf("AuthCodeURL error = %v, want ErrVerification", err)
+	}
+}
+
+func TestExchangeAndVerify(t *testing.T) {
+	mock := oidctest.NewProvider(t, clientID)
+	provider := newProvider(t, mock)
+	ctx := context.Background()
+
+	code := mock.IssueCode(mock.IDToken(map[string]interface{}{
+		"nonce":          "nonce",
+		"email":          "user@example.com",
+		"email_verified": "true",
+		"name":           "Jane Doe",
+		"amr":            []string{"pwd", "mfa"},
+	}))
+	rawIDToken, err := provider.Exchange(ctx, code, "verifier")
+	if err != nil {
+		t.Fatalf("Exchange: %v", err)
+	}
+	claims, err := provider.VerifyIDToken(ctx, rawIDToken, "nonce")
+	if err != nil {
+		t.Fatalf("VerifyIDToken: %v", err)
+	}
+	if claims.Subject != "subject" || claims.Email != "user@example.com" || !claims.EmailVerified || claims.Name != "Jane Doe" {
+		t.Errorf("claims = %+v", claims)
+	}
+	if strings.Join(claims.AuthMethods, " ") != "pwd mfa" {
+		t.Errorf("amr = %v, want [pwd mfa]", claims.AuthMethods)
+	}
+
+	// Codes are single-use at the provider
+	if _, err := provider.Exchange(ctx, code, "verifier"); !errors.Is(err, oidc.ErrVerification) {
+		t.Errorf("replayed Exchange error = %v, want ErrVerification", err)
+	}
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	mock := oidctest.NewProvider(t, clientID)
+	provider := newProvider(t, mock)
+
+	tests := []struct {
+		name   string
+		claims map[string]interface{}
+		nonce  string
+	}{
+		{name: "nonce mismatch", claims: map[string]interface{}{"nonce": "other"}, nonce: "nonce"},
+		{name: "missing nonce", claims: map[string]interface{}{}, nonce: "nonce"},
+		{name: "other audience", claims: map[string]interface{}{"nonce": "nonce", "aud": []string{"other-client"}}, nonce: "nonce"},
+		{name: "several audiences without azp", claims: map[string]interface{}{"nonce": "nonce", "aud": []string{clientID, "other-client"}}, nonce: "nonce"},
+		{name: "several audiences, azp of another party", claims: map[string]interface{}{"nonce": "nonce", "aud": []string{clientID, "other-client"}, "azp": "other-client"}, nonce: "nonce"},
+		{name: "other issuer", claims: map[string]interface{}{"nonce": "nonce", "iss": "https://evil.example.com"}, nonce: "nonce"},
+		{name: "expired", claims: map[string]interface{}{"nonce": "nonce", "exp": time.Now().Add(-time.Hour).Unix()}, nonce: "nonce"},
+		{name: "no subject", claims: map[string]interface{}{"nonce": "nonce", "sub": ""}, nonce: "nonce"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := provider.VerifyIDToken(context.Background(), mock.IDToken(tt.claims), tt.nonce)
+			if !errors.Is(err, oidc.ErrVerification) {
+				t.Fatalf("VerifyIDToken error = %v, want ErrVerification", err)
+			}
+		})
+	}
+
+	// Several audiences are accepted when this client is the authorized party
+	token := mock.IDToken(map[string]interface{}{"nonce": "nonce", "aud": []string{clientID, "other-client"}, "azp": clientID})
+	if _, err := provider.VerifyIDToken(context.Background(), token, "nonce"); err != nil {
+		t.Errorf("VerifyIDToken with azp of this client: %v", err)
+	}
+}
+
+func TestVerifyRejectsForeignSignature(t *testing.T) {
+	mock := oidctest.NewProvider(t, clientID)
+	other := oidctest.NewProvider(t, clientID)
+	provider := newProvider(t, mock)
+
+	// Signed by another provider's key under the same kid and claims
+	token := other.IDToken(map[string]interface{}{"nonce": "nonce", "iss": mock.Issuer()})
+	if _, err := provider.VerifyIDToken(context.Background(), token, "nonce"); !errors.Is(err, oidc.ErrVerification) {
+		t.Fatalf("VerifyIDToken error = %v, want ErrVerification", err)
+	}
+}
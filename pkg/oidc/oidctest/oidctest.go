@@ -0,0 +1,193 @@
+// Package oidctest runs an in-process OpenID Connect provider for tests. It serves a
+// discovery document, a JWKS and a token endpoint that redeems the codes it was given.
+package oidctest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/lestrrat-go/jwx/v3/jwa"
+	"github.com/lestrrat-go/jwx/v3/jwk"
+	"github.com/lestrrat-go/jwx/v3/jws"
+	"github.com/lestrrat-go/jwx/v3/jwt"
+)
+
+// Provider is a mock OpenID Connect provider
+type Provider struct {
+	*httptest.Server
+	// ClientID is the audience of the ID tokens it issues by default
+	ClientID string
+
+	t          testing.TB
+	mu         sync.Mutex
+	keys       []jwk.Key
+	signingKey jwk.Key
+	codes      map[string]string
+	requests   map[string]int
+}
+
+// NewProvider starts a provider with one signing key. It is closed when the test ends.
+func NewProvider(t testing.TB, clientID string) *Provider {
+	t.Helper()
+	p := &Provider{ClientID: clientID, t: t, codes: map[string]string{}, requests: map[string]int{}}
+	p.RotateKey(true)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", p.discovery)
+	mux.HandleFunc("/jwks", p.jwks)
+	mux.HandleFunc("/token", p.token)
+	p.Server = httptest.NewServer(mux)
+	t.Cleanup(p.Close)
+	return p
+}
+
+// Issuer returns the issuer URL of the provider
+func (p *Provider) Issuer() string {
+	return p.URL
+}
+
+// Requests returns how often a path was requested
+func (p *Provider) Requests(path string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.requests[path]
+}
+
+// RotateKey creates a new signing key. With keepOld the previous keys stay published.
+func (p *Provider) RotateKey(keepOld bool) {
+	p.t.Helper()
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		p.t.Fatalf("failed to generate signing key: %v", err)
+	}
+	key, err := jwk.Import(privateKey)
+	if err != nil {
+		p.t.Fatalf("failed to import signing key: %v", err)
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if err := key.Set(jwk.KeyIDKey, fmt.Sprintf("key-%d", len(p.keys)+1)); err != nil {
+		p.t.Fatalf("failed to set key id: %v", err)
+	}
+	if !keepOld {
+		p.keys = nil
+	}
+	p.keys = append(p.keys, key)
+	p.signingKey = key
+}
+
+// IDToken signs an ID token with the current key. iss, aud, sub, iat and exp default to
+// the provider, its client, "subject", now and an hour from now; claims override them.
+func (p *Provider) IDToken(claims map[string]interface{}) string {
+	p.t.Helper()
+	now := time.Now()
+	token := jwt.New()
+	defaults := map[string]interface{}{
+		jwt.IssuerKey:     p.Issuer(),
+		jwt.AudienceKey:   []string{p.ClientID},
+		jwt.SubjectKey:    "subject",
+		jwt.IssuedAtKey:   now.Unix(),
+		jwt.ExpirationKey: now.Add(time.Hour).Unix(),
+	}
+	for _, values := range []map[string]interface{}{defaults, claims} {
+		for name, value := range values {
+			if err := token.Set(name, value); err != nil {
+				p.t.Fatalf("failed to set claim %s: %v", name, err)
+			}
+		}
+	}
+
+	p.mu.Lock()
+	key := p.signingKey
+	p.mu.Unlock()
+	kid, _ := key.KeyID()
+	headers := jws.NewHeaders()
+	if err := headers.Set(jws.KeyIDKey, kid); err != nil {
+		p.t.Fatalf("failed to set key id header: %v", err)
+	}
+	signed, err := jwt.Sign(token, jwt.WithKey(jwa.RS256(), key, jws.WithProtectedHeaders(headers)))
+	if err != nil {
+		p.t.Fatalf("failed to sign id token: %v", err)
+	}
+	return string(signed)
+}
+
+// IssueCode returns a single-use authorization code that redeems to idToken
+func (p *Provider) IssueCode(idToken string) string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	code := fmt.Sprintf("code-%d", len(p.codes)+1)
+	p.codes[code] = idToken
+	return code
+}
+
+func (p *Provider) count(r *http.Request) {
+	p.mu.Lock()
+	p.requests[r.URL.Path]++
+	p.mu.Unlock()
+}
+
+func (p *Provider) discovery(w http.ResponseWriter, r *http.Request) {
+	p.count(r)
+	writeJSON(w, http.StatusOK, map[string]string{
+		"issuer":                 p.Issuer(),
+		"authorization_endpoint": p.Issuer() + "/authorize",
+		"token_endpoint":         p.Issuer() + "/token",
+		"jwks_uri":               p.Issuer() + "/jwks",
+	})
+}
+
+func (p *Provider) jwks(w http.ResponseWriter, r *http.Request) {
+	p.count(r)
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	set := jwk.NewSet()
+	for _, key := range p.keys {
+		publicKey, err := key.PublicKey()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		if err := set.AddKey(publicKey); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	writeJSON(w, http.StatusOK, set)
+}
+
+func (p *Provider) token(w http.ResponseWriter, r *http.Request) {
+	p.count(r)
+	clientID, _, ok := r.BasicAuth()
+	if !ok || clientID != p.ClientID {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if err := r.ParseForm(); err != nil || r.PostForm.Get("code_verifier") == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	p.mu.Lock()
+	idToken, ok := p.codes[r.PostForm.Get("code")]
+	delete(p.codes, r.PostForm.Get("code"))
+	p.mu.Unlock()
+	if !ok {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "unknown code"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"access_token": "access", "token_type": "Bearer", "id_token": idToken})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(body)
+}
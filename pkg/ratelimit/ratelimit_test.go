@@ -0,0 +1,94 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestParseLimit(t *testing.T) {
+	limit, err := ParseLimit(" 5/1m ")
+	if err != nil || limit != (Limit{Requests: 5, Period: time.Minute}) {
+		t.Errorf("ParseLimit(5/1m) = %+v, %v; want 5 per minute", limit, err)
+	}
+	for _, value := range []string{"", "5", "five/1m", "-1/1m", "5/", "5/0s", "5/-1m", "5/minute"} {
+		if _, err := ParseLimit(value); err == nil {
+			t.Errorf("ParseLimit(%q) was accepted", value)
+		}
+	}
+}
+
+func TestBucketRefill(t *testing.T) {
+	limit := Limit{Requests: 4, Period: 4 * time.Second}
+	now := time.Now()
+	b := bucket{tokens: float64(limit.Requests), last: now}
+
+	for i := 0; i < limit.Requests; i++ {
+		if result := b.take(limit, now); !result.Allowed {
+			t.Fatalf("request %d of the burst was refused", i+1)
+		}
+	}
+	result := b.take(limit, now)
+	if result.Allowed || result.RetryAfter != time.Second || result.Reset != 4*time.Second {
+		t.Fatalf("empty bucket = %+v, want refused, retry after 1s and full after 4s", result)
+	}
+
+	// One token per second comes back
+	if result := b.take(limit, now.Add(500*time.Millisecond)); result.Allowed {
+		t.Errorf("request after half a token was allowed")
+	}
+	if result := b.take(limit, now.Add(1500*time.Millisecond)); !result.Allowed || result.Remaining != 0 {
+		t.Errorf("request after a token refilled = %+v, want allowed with none remaining", result)
+	}
+
+	// A long pause refills up to the capacity, not beyond
+	later := now.Add(time.Hour)
+	if !b.full(limit, later) {
+		t.Errorf("bucket is not full after an hour")
+	}
+	allowed := 0
+	for i := 0; i < 2*limit.Requests; i++ {
+		if b.take(limit, later).Allowed {
+			allowed++
+		}
+	}
+	if allowed != limit.Requests {
+		t.Errorf("burst after a long pause = %d requests, want %d", allowed, limit.Requests)
+	}
+}
+
+func TestMemoryStoreBurst(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 3, Period: time.Hour}
+	ctx := context.Background()
+
+	for i := 0; i < limit.Requests; i++ {
+		result, err := store.Take(ctx, "login:ip:192.0.2.1", limit)
+		if err != nil || !result.Allowed || result.Remaining != limit.Requests-i-1 {
+			t.Fatalf("request %d = %+v, %v; want allowed with %d remaining", i+1, result, err, limit.Requests-i-1)
+		}
+	}
+	result, err := store.Take(ctx, "login:ip:192.0.2.1", limit)
+	if err != nil || result.Allowed || result.RetryAfter <= 0 {
+		t.Errorf("request over the burst = %+v, %v; want refused with a retry delay", result, err)
+	}
+}
+
+func TestMemoryStoreKeysAreIsolated(t *testing.T) {
+	store := NewMemoryStore()
+	limit := Limit{Requests: 1, Period: time.Hour}
+	ctx := context.Background()
+
+	if result, _ := store.Take(ctx, "login:ip:192.0.2.1", limit); !result.Allowed {
+		t.Fatalf("first request of 192.0.2.1 was refused")
+	}
+	if result, _ := store.Take(ctx, "login:ip:192.0.2.1", limit); result.Allowed {
+		t.Fatalf("second request of 192.0.2.1 was allowed")
+	}
+	// Other keys, including the same address on another route or under another kind of key, have their own buckets
+	for _, key := range []string{"login:ip:192.0.2.2", "login:email:192.0.2.1", "signup:ip:192.0.2.1"} {
+		if result, _ := store.Take(ctx, key, limit); !result.Allowed {
+			t.Errorf("first request of %s was refused after 192.0.2.1 ran out", key)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package totp
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/sha512"
+	"hash"
+	"testing"
+	"time"
+)
+
+// rfcSecret is the SHA-1 seed of RFC 6238 Appendix B, base32 encoded
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// TestRFC6238Vectors checks the test vectors of RFC 6238 Appendix B
+func TestRFC6238Vectors(t *testing.T) {
+	seeds := []struct {
+		name    string
+		newHash func() hash.Hash
+		key     string
+	}{
+		{name: "SHA1", newHash: sha1.New, key: "12345678901234567890"},
+		{name: "SHA256", newHash: sha256.New, key: "12345678901234567890123456789012"},
+		{name: "SHA512", newHash: sha512.New, key: "1234567890123456789012345678901234567890123456789012345678901234"},
+	}
+	vectors := []struct {
+		unix int64
+		want [3]string
+	}{
+		{59, [3]string{"94287082", "46119246", "90693936"}},
+		{1111111109, [3]string{"07081804", "68084774", "25091201"}},
+		{1111111111, [3]string{"14050471", "67062674", "99943326"}},
+		{1234567890, [3]string{"89005924", "91819424", "93441116"}},
+		{2000000000, [3]string{"69279037", "90698825", "38618901"}},
+		{20000000000, [3]string{"65353130", "77737706", "47863826"}},
+	}
+	for i, seed := range seeds {
+		for _, v := range vectors {
+			step := Step(time.Unix(v.unix, 0))
+			if got := hotp([]byte(seed.key), uint64(step), seed.newHash, 8); got != v.want[i] {
+				t.Errorf("%s at %d = %s, want %s", seed.name, v.unix, got, v.want[i])
+			}
+		}
+	}
+
+	// The six digit codes authenticator apps show are the last digits of the SHA-1 values
+	for _, v := range vectors {
+		got, err := GenerateCode(rfcSecret, Step(time.Unix(v.unix, 0)))
+		if err != nil {
+			t.Fatalf("GenerateCode: %v", err)
+		}
+		if want := v.want[0][2:]; got != want {
+			t.Errorf("GenerateCode at %d = %s, want %s", v.unix, got, want)
+		}
+	}
+}
+
+func TestValidateSkew(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+
+	for offset := int64(-2); offset <= 2; offset++ {
+		code, err := GenerateCode(rfcSecret, current+offset)
+		if err != nil {
+			t.Fatalf("GenerateCode: %v", err)
+		}
+		step, ok := Validate(rfcSecret, code, now, 1)
+		if inWindow := offset >= -1 && offset <= 1; ok != inWindow {
+			t.Errorf("code of step %+d with skew 1: accepted = %v, want %v", offset, ok, inWindow)
+		}
+		if ok && step != current+offset {
+			t.Errorf("code of step %+d: matched step %d, want %d", offset, step, current+offset)
+		}
+	}
+
+	code, _ := GenerateCode(rfcSecret, current)
+	for _, malformed := range []string{"", code[:5], code + "0", "abcdef"} {
+		if _, ok := Validate(rfcSecret, malformed, now, 1); ok {
+			t.Errorf("malformed code %q was accepted", malformed)
+		}
+	}
+	if _, ok := Validate(rfcSecret, " "+code+" ", now, 0); !ok {
+		t.Errorf("code with surrounding spaces was rejected")
+	}
+}
+
+func TestValidateAfterRejectsReusedStep(t *testing.T) {
+	now := time.Unix(1234567890, 0)
+	current := Step(now)
+	code, _ := GenerateCode(rfcSecret, current)
+
+	step, ok := ValidateAfter(rfcSecret, code, now, 1, 0)
+	if !ok || step != current {
+		t.Fatalf("first use = %d, %v; want step %d", step, ok, current)
+	}
+	// The same code again, also later within the window
+	for _, at := range []time.Time{now, now.Add(Period * time.Second)} {
+		if _, ok := ValidateAfter(rfcSecret, code, at, 1, step); ok {
+			t.Errorf("reused code at %s was accepted", at.UTC())
+		}
+	}
+	// A code of an earlier step still in the window
+	earlier, _ := GenerateCode(rfcSecret, current-1)
+	if _, ok := ValidateAfter(rfcSecret, earlier, now, 1, step); ok {
+		t.Errorf("code of an earlier step was accepted")
+	}
+	// The next step's code is new
+	next, _ := GenerateCode(rfcSecret, current+1)
+	if got, ok := ValidateAfter(rfcSecret, next, now, 1, step); !ok || got != current+1 {
+		t.Errorf("next step = %d, %v; want step %d", got, ok, current+1)
+	}
+}
@@ -0,0 +1,236 @@
+package useragent
+
+import (
+	"strings"
+)
+
+// User-Agent Client Hint request headers. Chromium browsers send the first three on
+// every secure request; the full version list only after the server asks for it.
+const (
+	HeaderUA              = "Sec-CH-UA"
+	HeaderPlatform        = "Sec-CH-UA-Platform"
+	HeaderMobile          = "Sec-CH-UA-Mobile"
+	HeaderFullVersionList = "Sec-CH-UA-Full-Version-List"
+)
+
+// AcceptCH is the Accept-CH response header value asking browsers for the hints used
+// in device detection
+var AcceptCH = strings.Join([]string{HeaderUA, HeaderPlatform, HeaderMobile, HeaderFullVersionList}, ", ")
+
+// Headers holds the request headers a device is detected from
+type Headers struct {
+	UserAgent       string
+	UA              string
+	Platform        string
+	Mobile          string
+	FullVersionList string
+}
+
+// HasHints reports whether the request carried User-Agent Client Hints
+func (h Headers) HasHints() bool {
+	return h.UA != "" || h.Platform != ""
+}
+
+// Device is the browser and OS of a request, taken from its Client Hints where present
+// and from the User-Agent string otherwise. Names follow uap-go, e.g. "Mac OS X" and
+// "Chrome Mobile", so both sources describe the same browser alike.
+type Device struct {
+	Platform string
+	Browser  string
+	Major    string
+	Version  string
+	Mobile   bool
+	// FromHints is set when the browser was identified by its Client Hints
+	FromHints bool
+}
+
+// hintPlatforms maps Sec-CH-UA-Platform values to uap-go OS families
+var hintPlatforms = map[string]string{
+	"macOS":       "Mac OS X",
+	"Chrome OS":   "Chrome OS",
+	"Chromium OS": "Chrome OS",
+}
+
+// hintBrowsers maps Sec-CH-UA brands to uap-go browser families, desktop then mobile
+var hintBrowsers = map[string][2]string{
+	"Google Chrome":    {"Chrome", "Chrome Mobile"},
+	"Microsoft Edge":   {"Edge", "Edge Mobile"},
+	"Opera":            {"Opera", "Opera Mobile"},
+	"Samsung Internet": {"Samsung Internet", "Samsung Internet"},
+	"Android WebView":  {"Chrome Mobile WebView", "Chrome Mobile WebView"},
+}
+
+// Detect identifies the device of a request. Client Hints take precedence over the
+// User-Agent string, which Chromium browsers have frozen.
+func (p *Parser) Detect(headers Headers) *Device {
+	device := &Device{}
+	if headers.UserAgent != "" {
+		client := p.Parse(headers.UserAgent)
+		device.Platform = client.Os.Family
+		device.Browser = client.UserAgent.Family
+		device.Major = client.UserAgent.Major
+		device.Version = joinVersion(client.UserAgent.Major, client.UserAgent.Minor, client.UserAgent.Patch)
+		device.Mobile = strings.Contains(headers.UserAgent, "Mobile")
+	}
+	if !headers.HasHints() {
+		return device
+	}
+
+	device.FromHints = true
+	if mobile, ok := parseBoolean(headers.Mobile); ok {
+		device.Mobile = mobile
+	}
+	if platform := unquote(strings.TrimSpace(headers.Platform)); platform != "" && platform != "Unknown" {
+		device.Platform = platform
+		if family, ok := hintPlatforms[platform]; ok {
+			device.Platform = family
+		}
+	}
+
+	brand, major := pickBrand(parseBrands(headers.UA))
+	if brand == "" {
+		return device
+	}
+	device.Browser = brand
+	if families, ok := hintBrowsers[brand]; ok {
+		device.Browser = families[0]
+		if device.Mobile {
+			device.Browser = families[1]
+		}
+	}
+	device.Major = major
+	device.Version = major
+	for _, full := range parseBrands(headers.FullVersionList) {
+		if full.name == brand && majorOf(full.version) == major {
+			device.Version = full.version
+		}
+	}
+	return device
+}
+
+// brandVersion is one entry of a Sec-CH-UA brand list
+type brandVersion struct {
+	name    string
+	version string
+}
+
+// pickBrand returns the most specific brand of a list: GREASE brands are skipped and
+// Chromium only counts when no browser built on it is named
+func pickBrand(brands []brandVersion) (string, string) {
+	var chromium *brandVersion
+	for i, brand := range brands {
+		switch {
+		case strings.Contains(brand.name, "Not") && strings.Contains(brand.name, "Brand"):
+			continue
+		case brand.name == "Chromium":
+			chromium = &brands[i]
+		default:
+			return brand.name, majorOf(brand.version)
+		}
+	}
+	if chromium != nil {
+		return chromium.name, majorOf(chromium.version)
+	}
+	return "", ""
+}
+
+// parseBrands reads a brand list structured header (RFC 8941), such as
+// `"Chromium";v="120", "Not_A Brand";v="8"`. Malformed entries end the list.
+func parseBrands(header string) []brandVersion {
+	var brands []brandVersion
+	rest := header
+	for {
+		rest = strings.TrimLeft(rest, " \t")
+		name, after, ok := readQuoted(rest)
+		if !ok {
+			return brands
+		}
+		brand := brandVersion{name: name}
+		rest = after
+
+		// Parameters; only v is used
+		for strings.HasPrefix(rest, ";") {
+			key, after, found := strings.Cut(rest[1:], "=")
+			if !found {
+				return brands
+			}
+			value, after, ok := readQuoted(after)
+			if !ok {
+				return brands
+			}
+			if strings.TrimSpace(key) == "v" {
+				brand.version = value
+			}
+			rest = after
+		}
+		brands = append(brands, brand)
+
+		rest = strings.TrimLeft(rest, " \t")
+		if !strings.HasPrefix(rest, ",") {
+			return brands
+		}
+		rest = rest[1:]
+	}
+}
+
+// readQuoted reads a structured header string at the start of s and returns its value
+// and the remainder of s
+func readQuoted(s string) (string, string, bool) {
+	if !strings.HasPrefix(s, `"`) {
+		return "", s, false
+	}
+	var value strings.Builder
+	for i := 1; i < len(s); i++ {
+		switch s[i] {
+		case '\\':
+			if i+1 == len(s) {
+				return "", s, false
+			}
+			i++
+			value.WriteByte(s[i])
+		case '"':
+			return value.String(), s[i+1:], true
+		default:
+			value.WriteByte(s[i])
+		}
+	}
+	return "", s, false
+}
+
+// unquote returns the value of a structured header string, or s unchanged when it is
+// not quoted
+func unquote(s string) string {
+	if value, _, ok := readQuoted(s); ok {
+		return value
+	}
+	return s
+}
+
+// parseBoolean reads a structured header boolean, ?1 or ?0
+func parseBoolean(s string) (bool, bool) {
+	switch strings.TrimSpace(s) {
+	case "?1":
+		return true, true
+	case "?0":
+		return false, true
+	}
+	return false, false
+}
+
+// majorOf returns the major part of a dotted version
+func majorOf(version string) string {
+	major, _, _ := strings.Cut(version, ".")
+	return major
+}
+
+// joinVersion joins the known parts of a version
+func joinVersion(parts ...string) string {
+	var known []string
+	for _, part := range parts {
+		if part == "" {
+			break
+		}
+		known = append(known, part)
+	}
+	return strings.Join(known, ".")
+}
@@ -0,0 +1,49 @@
+// Package useragent parses User-Agent strings with one shared uap-go parser and an LRU
+// cache of the results. Building a uap-go parser compiles its whole regex database, so
+// it must happen once at startup, not per request.
+package useragent
+
+import (
+	"fmt"
+
+	lru "github.com/hashicorp/golang-lru"
+	"github.com/ua-parser/uap-go/uaparser"
+)
+
+// maxCachedLength keeps oversized User-Agent strings out of the cache, so a client
+// cannot fill it with large keys
+const maxCachedLength = 1024
+
+// Parser parses User-Agent strings, caching the most recently seen ones. It is safe
+// for concurrent use.
+type Parser struct {
+	parser *uaparser.Parser
+	cache  *lru.Cache
+}
+
+// New builds the parser from the regex database bundled with uap-go and a cache of up
+// to cacheSize User-Agent strings
+func New(cacheSize int) (*Parser, error) {
+	cache, err := lru.New(cacheSize)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user agent cache size %d: %w", cacheSize, err)
+	}
+	return &Parser{
+		parser: uaparser.NewFromSaved(),
+		cache:  cache,
+	}, nil
+}
+
+// Parse returns the browser, OS and device of a User-Agent string. The result may be
+// shared with other callers and must not be modified.
+func (p *Parser) Parse(userAgent string) *uaparser.Client {
+	if client, ok := p.cache.Get(userAgent); ok {
+		return client.(*uaparser.Client)
+	}
+
+	client := p.parser.Parse(userAgent)
+	if len(userAgent) <= maxCachedLength {
+		p.cache.Add(userAgent, client)
+	}
+	return client
+}
@@ -0,0 +1,63 @@
+package useragent
+
+import (
+	"strconv"
+	"testing"
+)
+
+const chromeWindows = "Mozilla/5.0 (Windows NT 10.0; Win64; x64) AppleWebKit/537.36 (KHTML, like Gecko) Chrome/120.0.0.0 Safari/537.36"
+
+func newParser(tb testing.TB, cacheSize int) *Parser {
+	tb.Helper()
+	p, err := New(cacheSize)
+	if err != nil {
+		tb.Fatalf("New: %v", err)
+	}
+	return p
+}
+
+func TestParseCaches(t *testing.T) {
+	p := newParser(t, 4)
+
+	first := p.Parse(chromeWindows)
+	if first.UserAgent.Family != "Chrome" || first.UserAgent.Major != "120" || first.Os.Family != "Windows" {
+		t.Fatalf("Parse = %s %s on %s, want Chrome 120 on Windows", first.UserAgent.Family, first.UserAgent.Major, first.Os.Family)
+	}
+	if second := p.Parse(chromeWindows); second != first {
+		t.Error("second Parse did not return the cached result")
+	}
+}
+
+func TestParseSkipsCacheForLongStrings(t *testing.T) {
+	p := newParser(t, 4)
+	long := chromeWindows + string(make([]byte, maxCachedLength))
+
+	p.Parse(long)
+	if p.cache.Contains(long) {
+		t.Error("a User-Agent longer than maxCachedLength was cached")
+	}
+}
+
+// BenchmarkParse compares a parse that misses the cache with one that hits it
+func BenchmarkParse(b *testing.B) {
+	b.Run("cold", func(b *testing.B) {
+		p := newParser(b, 1)
+		userAgents := make([]string, b.N)
+		for i := range userAgents {
+			userAgents[i] = chromeWindows + " Build/" + strconv.Itoa(i)
+		}
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			p.Parse(userAgents[i])
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		p := newParser(b, 1)
+		p.Parse(chromeWindows)
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			p.Parse(chromeWindows)
+		}
+	})
+}
@@ -0,0 +1,121 @@
+package webauthn
+
+import (
+	"bytes"
+	"crypto/x509"
+	"encoding/asn1"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Attestation types recorded with a credential
+const (
+	AttestationNone  = "none"
+	AttestationSelf  = "self"
+	AttestationBasic = "basic"
+)
+
+// oidFIDOAAGUID is the certificate extension carrying the authenticator AAGUID
+var oidFIDOAAGUID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}
+
+// attestationObject is the CBOR structure returned by a registration
+type attestationObject struct {
+	Format   string          `cbor:"fmt"`
+	AttStmt  cbor.RawMessage `cbor:"attStmt"`
+	AuthData []byte          `cbor:"authData"`
+}
+
+// packedStatement is the attStmt of the packed attestation format
+type packedStatement struct {
+	Alg int64    `cbor:"alg"`
+	Sig []byte   `cbor:"sig"`
+	X5C [][]byte `cbor:"x5c,omitempty"`
+}
+
+// verifyAttestation checks the attestation statement and returns the attestation type.
+// Certificates of basic attestation are checked for well-formedness but not chained to
+// a trust anchor, as no authenticator metadata is configured.
+func verifyAttestation(obj *attestationObject, authData *authenticatorData, clientDataHash []byte) (string, error) {
+	switch obj.Format {
+	case "none":
+		var stmt map[string]interface{}
+		if err := cbor.Unmarshal(obj.AttStmt, &stmt); err != nil || len(stmt) != 0 {
+			return "", verificationError("none attestation must have an empty statement")
+		}
+		return AttestationNone, nil
+
+	case "packed":
+		var stmt packedStatement
+		if err := cbor.Unmarshal(obj.AttStmt, &stmt); err != nil {
+			return "", verificationError("malformed packed attestation statement")
+		}
+		signed := append(append([]byte{}, obj.AuthData...), clientDataHash...)
+
+		if len(stmt.X5C) == 0 {
+			// Self attestation: signed by the credential key itself
+			key, err := ParsePublicKey(authData.PublicKey)
+			if err != nil {
+				return "", err
+			}
+			if stmt.Alg != key.Algorithm {
+				return "", verificationError("self attestation algorithm does not match the credential key")
+			}
+			if err := key.Verify(signed, stmt.Sig); err != nil {
+				return "", err
+			}
+			return AttestationSelf, nil
+		}
+
+		cert, err := x509.ParseCertificate(stmt.X5C[0])
+		if err != nil {
+			return "", verificationError("malformed attestation certificate")
+		}
+		if err := verifyPackedCertificate(cert, authData.AAGUID); err != nil {
+			return "", err
+		}
+		if err := verifySignature(stmt.Alg, cert.PublicKey, signed, stmt.Sig); err != nil {
+			return "", err
+		}
+		return AttestationBasic, nil
+
+	default:
+		return "", verificationError("unsupported attestation format %q", obj.Format)
+	}
+}
+
+// verifyPackedCertificate applies the packed attestation certificate requirements
+func verifyPackedCertificate(cert *x509.Certificate, aaguid []byte) error {
+	if cert.Version != 3 {
+		return verificationError("attestation certificate must be version 3")
+	}
+	subject := cert.Subject
+	if len(subject.Country) == 0 || len(subject.Organization) == 0 || subject.CommonName == "" {
+		return verificationError("attestation certificate subject is incomplete")
+	}
+	hasUnit := false
+	for _, unit := range subject.OrganizationalUnit {
+		if unit == "Authenticator Attestation" {
+			hasUnit = true
+		}
+	}
+	if !hasUnit {
+		return verificationError("attestation certificate has the wrong organizational unit")
+	}
+	if cert.IsCA {
+		return verificationError("attestation certificate must not be a CA")
+	}
+
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidFIDOAAGUID) {
+			continue
+		}
+		if ext.Critical {
+			return verificationError("AAGUID extension must not be critical")
+		}
+		var value []byte
+		if _, err := asn1.Unmarshal(ext.Value, &value); err != nil || !bytes.Equal(value, aaguid) {
+			return verificationError("attestation certificate AAGUID does not match")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package webauthn
+
+import (
+	"encoding/binary"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Authenticator data flags
+const (
+	flagUserPresent            = 0x01
+	flagUserVerified           = 0x04
+	flagAttestedCredentialData = 0x40
+	flagExtensionData          = 0x80
+)
+
+// authenticatorData is the parsed authenticator data of a ceremony response
+type authenticatorData struct {
+	RPIDHash  []byte
+	Flags     byte
+	SignCount uint32
+
+	// Set during registration only
+	AAGUID       []byte
+	CredentialID []byte
+	PublicKey    []byte
+}
+
+func (a *authenticatorData) userPresent() bool {
+	return a.Flags&flagUserPresent != 0
+}
+
+func (a *authenticatorData) userVerified() bool {
+	return a.Flags&flagUserVerified != 0
+}
+
+// parseAuthenticatorData decodes the fixed header and, when flagged, the attested credential data
+func parseAuthenticatorData(data []byte) (*authenticatorData, error) {
+	if len(data) < 37 {
+		return nil, verificationError("authenticator data is too short")
+	}
+	authData := &authenticatorData{
+		RPIDHash:  data[:32],
+		Flags:     data[32],
+		SignCount: binary.BigEndian.Uint32(data[33:37]),
+	}
+
+	rest := data[37:]
+	if authData.Flags&flagAttestedCredentialData != 0 {
+		if len(rest) < 18 {
+			return nil, verificationError("attested credential data is too short")
+		}
+		authData.AAGUID = rest[:16]
+		idLen := int(binary.BigEndian.Uint16(rest[16:18]))
+		rest = rest[18:]
+		if idLen > 1023 || len(rest) < idLen {
+			return nil, verificationError("invalid credential ID length")
+		}
+		authData.CredentialID = rest[:idLen]
+		rest = rest[idLen:]
+
+		// The COSE key is followed by optional extensions, so decode only the first item
+		var key cbor.RawMessage
+		remaining, err := cbor.UnmarshalFirst(rest, &key)
+		if err != nil {
+			return nil, verificationError("malformed credential public key: %v", err)
+		}
+		authData.PublicKey = []byte(key)
+		rest = remaining
+	}
+
+	if authData.Flags&flagExtensionData != 0 {
+		var extensions cbor.RawMessage
+		remaining, err := cbor.UnmarshalFirst(rest, &extensions)
+		if err != nil {
+			return nil, verificationError("malformed authenticator extensions: %v", err)
+		}
+		rest = remaining
+	}
+
+	if len(rest) != 0 {
+		return nil, verificationError("unexpected trailing bytes in authenticator data")
+	}
+	return authData, nil
+}
@@ -0,0 +1,218 @@
+package webauthn_test
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"encoding/json"
+	"fiber-api/pkg/webauthn"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Attestation statements the software authenticator can produce
+const (
+	attestNone        = "none"
+	attestPackedSelf  = "packed-self"
+	attestPackedBasic = "packed-basic"
+)
+
+// ctap2 encodes CBOR as authenticators do, with map keys in canonical order
+var ctap2, _ = cbor.CTAP2EncOptions().EncMode()
+
+// authenticator is a software ES256 authenticator. rpID and origin are what it reports in
+// authenticator data and client data, so tests can change them to act as another site.
+type authenticator struct {
+	t            *testing.T
+	rpID         string
+	origin       string
+	userVerified bool
+	// noCounter authenticators report a zero signature counter
+	noCounter bool
+	aaguid    []byte
+	// certAAGUID, when set, is the AAGUID named by the attestation certificate
+	certAAGUID   []byte
+	credentialID []byte
+	key          *ecdsa.PrivateKey
+	signCount    uint32
+}
+
+func newAuthenticator(t *testing.T, rpID, origin string) *authenticator {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate credential key: %v", err)
+	}
+	credentialID := make([]byte, 16)
+	aaguid := make([]byte, 16)
+	if _, err := rand.Read(credentialID); err != nil {
+		t.Fatalf("failed to generate credential ID: %v", err)
+	}
+	if _, err := rand.Read(aaguid); err != nil {
+		t.Fatalf("failed to generate AAGUID: %v", err)
+	}
+	return &authenticator{t: t, rpID: rpID, origin: origin, aaguid: aaguid, credentialID: credentialID, key: key}
+}
+
+// publicKey returns the credential public key as a COSE_Key
+func (a *authenticator) publicKey() []byte {
+	a.t.Helper()
+	x := a.key.PublicKey.X.FillBytes(make([]byte, 32))
+	y := a.key.PublicKey.Y.FillBytes(make([]byte, 32))
+	encoded, err := ctap2.Marshal(map[int64]interface{}{1: 2, 3: webauthn.AlgES256, -1: 1, -2: x, -3: y})
+	if err != nil {
+		a.t.Fatalf("failed to encode credential key: %v", err)
+	}
+	return encoded
+}
+
+// authData builds authenticator data, with the attested credential data when attested is set
+func (a *authenticator) authData(attested bool) []byte {
+	rpIDHash := sha256.Sum256([]byte(a.rpID))
+	flags := byte(0x01)
+	if a.userVerified {
+		flags |= 0x04
+	}
+	if attested {
+		flags |= 0x40
+	}
+	data := append([]byte{}, rpIDHash[:]...)
+	data = append(data, flags)
+	data = binary.BigEndian.AppendUint32(data, a.signCount)
+	if attested {
+		data = append(data, a.aaguid...)
+		data = binary.BigEndian.AppendUint16(data, uint16(len(a.credentialID)))
+		data = append(data, a.credentialID...)
+		data = append(data, a.publicKey()...)
+	}
+	return data
+}
+
+// clientData returns the clientDataJSON of a ceremony and its hash
+func (a *authenticator) clientData(ceremony, challenge string) ([]byte, []byte) {
+	a.t.Helper()
+	raw, err := json.Marshal(map[string]interface{}{"type": ceremony, "challenge": challenge, "origin": a.origin, "crossOrigin": false})
+	if err != nil {
+		a.t.Fatalf("failed to encode client data: %v", err)
+	}
+	hash := sha256.Sum256(raw)
+	return raw, hash[:]
+}
+
+func (a *authenticator) sign(key *ecdsa.PrivateKey, data []byte) []byte {
+	a.t.Helper()
+	digest := sha256.Sum256(data)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	if err != nil {
+		a.t.Fatalf("failed to sign: %v", err)
+	}
+	return signature
+}
+
+// register answers a registration ceremony with the given attestation statement
+func (a *authenticator) register(challenge, attestation string) webauthn.RegistrationCredential {
+	a.t.Helper()
+	clientDataJSON, clientDataHash := a.clientData("webauthn.create", challenge)
+	authData := a.authData(true)
+	signed := append(append([]byte{}, authData...), clientDataHash...)
+
+	format := "packed"
+	var stmt map[string]interface{}
+	switch attestation {
+	case attestNone:
+		format, stmt = "none", map[string]interface{}{}
+	case attestPackedSelf:
+		stmt = map[string]interface{}{"alg": webauthn.AlgES256, "sig": a.sign(a.key, signed)}
+	case attestPackedBasic:
+		cert, certKey := a.attestationCertificate()
+		stmt = map[string]interface{}{"alg": webauthn.AlgES256, "sig": a.sign(certKey, signed), "x5c": [][]byte{cert}}
+	default:
+		a.t.Fatalf("unknown attestation %q", attestation)
+	}
+
+	object, err := ctap2.Marshal(map[string]interface{}{"fmt": format, "attStmt": stmt, "authData": authData})
+	if err != nil {
+		a.t.Fatalf("failed to encode attestation object: %v", err)
+	}
+	id := webauthn.Encoding.EncodeToString(a.credentialID)
+	return webauthn.RegistrationCredential{
+		ID:    id,
+		RawID: id,
+		Type:  "public-key",
+		Response: webauthn.AttestationResponse{
+			ClientDataJSON:    webauthn.Encoding.EncodeToString(clientDataJSON),
+			AttestationObject: webauthn.Encoding.EncodeToString(object),
+			Transports:        []string{"internal"},
+		},
+	}
+}
+
+// attestationCertificate issues a packed attestation certificate for the authenticator's AAGUID
+func (a *authenticator) attestationCertificate() ([]byte, *ecdsa.PrivateKey) {
+	a.t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		a.t.Fatalf("failed to generate attestation key: %v", err)
+	}
+	certAAGUID := a.aaguid
+	if a.certAAGUID != nil {
+		certAAGUID = a.certAAGUID
+	}
+	aaguid, err := asn1.Marshal(certAAGUID)
+	if err != nil {
+		a.t.Fatalf("failed to encode AAGUID: %v", err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject: pkix.Name{
+			Country:            []string{"US"},
+			Organization:       []string{"Test Authenticators"},
+			OrganizationalUnit: []string{"Authenticator Attestation"},
+			CommonName:         "Test Authenticator",
+		},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		BasicConstraintsValid: true,
+		ExtraExtensions: []pkix.Extension{
+			{Id: asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 45724, 1, 1, 4}, Value: aaguid},
+		},
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		a.t.Fatalf("failed to create attestation certificate: %v", err)
+	}
+	return cert, key
+}
+
+// assert answers an authentication ceremony, advancing the signature counter
+func (a *authenticator) assert(challenge string) webauthn.AuthenticationCredential {
+	a.t.Helper()
+	if a.noCounter {
+		a.signCount = 0
+	} else {
+		a.signCount++
+	}
+	clientDataJSON, clientDataHash := a.clientData("webauthn.get", challenge)
+	authData := a.authData(false)
+	signature := a.sign(a.key, append(append([]byte{}, authData...), clientDataHash...))
+
+	id := webauthn.Encoding.EncodeToString(a.credentialID)
+	return webauthn.AuthenticationCredential{
+		ID:    id,
+		RawID: id,
+		Type:  "public-key",
+		Response: webauthn.AssertionResponse{
+			ClientDataJSON:    webauthn.Encoding.EncodeToString(clientDataJSON),
+			AuthenticatorData: webauthn.Encoding.EncodeToString(authData),
+			Signature:         webauthn.Encoding.EncodeToString(signature),
+		},
+	}
+}
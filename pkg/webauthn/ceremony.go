@@ -0,0 +1,162 @@
+package webauthn
+
+import (
+	"crypto/subtle"
+	"fmt"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// ErrSignCount is returned when a credential's signature counter did not increase,
+// which indicates a cloned authenticator
+var ErrSignCount = fmt.Errorf("%w: signature counter did not increase", ErrVerification)
+
+// AttestationResponse is the response field of a registration credential
+type AttestationResponse struct {
+	ClientDataJSON    string   `json:"clientDataJSON"`
+	AttestationObject string   `json:"attestationObject"`
+	Transports        []string `json:"transports,omitempty"`
+}
+
+// RegistrationCredential is the JSON form of the credential returned by navigator.credentials.create
+type RegistrationCredential struct {
+	ID       string              `json:"id"`
+	RawID    string              `json:"rawId"`
+	Type     string              `json:"type"`
+	Response AttestationResponse `json:"response"`
+}
+
+// AssertionResponse is the response field of an authentication credential
+type AssertionResponse struct {
+	ClientDataJSON    string `json:"clientDataJSON"`
+	AuthenticatorData string `json:"authenticatorData"`
+	Signature         string `json:"signature"`
+	UserHandle        string `json:"userHandle,omitempty"`
+}
+
+// AuthenticationCredential is the JSON form of the credential returned by navigator.credentials.get
+type AuthenticationCredential struct {
+	ID       string            `json:"id"`
+	RawID    string            `json:"rawId"`
+	Type     string            `json:"type"`
+	Response AssertionResponse `json:"response"`
+}
+
+// Credential is a verified credential to store after registration
+type Credential struct {
+	ID              []byte
+	PublicKey       []byte
+	SignCount       uint32
+	AAGUID          []byte
+	AttestationType string
+	Transports      []string
+}
+
+// Assertion is the outcome of a verified authentication
+type Assertion struct {
+	SignCount    uint32
+	UserVerified bool
+}
+
+// VerifyRegistration verifies a registration response against the challenge issued for it
+// and returns the credential to store
+func (rp *RelyingParty) VerifyRegistration(cred RegistrationCredential, challenge string) (*Credential, error) {
+	if cred.Type != credentialType {
+		return nil, verificationError("unexpected credential type %q", cred.Type)
+	}
+
+	clientDataHash, err := rp.verifyClientData(cred.Response.ClientDataJSON, ceremonyCreate, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	rawObject, err := Encoding.DecodeString(cred.Response.AttestationObject)
+	if err != nil {
+		return nil, verificationError("attestationObject is not base64url")
+	}
+	var obj attestationObject
+	if err := cbor.Unmarshal(rawObject, &obj); err != nil {
+		return nil, verificationError("malformed attestationObject")
+	}
+
+	authData, err := parseAuthenticatorData(obj.AuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+	if authData.CredentialID == nil {
+		return nil, verificationError("registration has no attested credential data")
+	}
+	if rawID, err := Encoding.DecodeString(cred.RawID); err != nil || subtle.ConstantTimeCompare(rawID, authData.CredentialID) != 1 {
+		return nil, verificationError("rawId does not match the attested credential")
+	}
+
+	// Reject keys of unsupported algorithms at registration rather than at first login
+	if _, err := ParsePublicKey(authData.PublicKey); err != nil {
+		return nil, err
+	}
+
+	attestationType, err := verifyAttestation(&obj, authData, clientDataHash)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Credential{
+		ID:              authData.CredentialID,
+		PublicKey:       authData.PublicKey,
+		SignCount:       authData.SignCount,
+		AAGUID:          authData.AAGUID,
+		AttestationType: attestationType,
+		Transports:      cred.Response.Transports,
+	}, nil
+}
+
+// VerifyAuthentication verifies an authentication response against the challenge issued for it,
+// using the stored public key and signature counter of the credential
+func (rp *RelyingParty) VerifyAuthentication(cred AuthenticationCredential, challenge string, publicKey []byte, storedSignCount uint32) (*Assertion, error) {
+	if cred.Type != credentialType {
+		return nil, verificationError("unexpected credential type %q", cred.Type)
+	}
+
+	clientDataHash, err := rp.verifyClientData(cred.Response.ClientDataJSON, ceremonyGet, challenge)
+	if err != nil {
+		return nil, err
+	}
+
+	rawAuthData, err := Encoding.DecodeString(cred.Response.AuthenticatorData)
+	if err != nil {
+		return nil, verificationError("authenticatorData is not base64url")
+	}
+	authData, err := parseAuthenticatorData(rawAuthData)
+	if err != nil {
+		return nil, err
+	}
+	if err := rp.verifyAuthenticatorData(authData); err != nil {
+		return nil, err
+	}
+
+	signature, err := Encoding.DecodeString(cred.Response.Signature)
+	if err != nil {
+		return nil, verificationError("signature is not base64url")
+	}
+	key, err := ParsePublicKey(publicKey)
+	if err != nil {
+		return nil, err
+	}
+	signed := append(append([]byte{}, rawAuthData...), clientDataHash...)
+	if err := key.Verify(signed, signature); err != nil {
+		return nil, err
+	}
+
+	// Authenticators without a counter always report zero
+	if (authData.SignCount != 0 || storedSignCount != 0) && authData.SignCount <= storedSignCount {
+		return nil, ErrSignCount
+	}
+
+	return &Assertion{
+		SignCount:    authData.SignCount,
+		UserVerified: authData.userVerified(),
+	}, nil
+}
@@ -0,0 +1,204 @@
+package webauthn_test
+
+import (
+	"bytes"
+	"errors"
+	"fiber-api/pkg/webauthn"
+	"testing"
+)
+
+const (
+	rpID   = "example.com"
+	origin = "https://example.com"
+)
+
+func newRelyingParty(t *testing.T, userVerification string) *webauthn.RelyingParty {
+	t.Helper()
+	rp, err := webauthn.New(webauthn.Config{RPID: rpID, RPName: "Example", Origins: []string{origin}, UserVerification: userVerification})
+	if err != nil {
+		t.Fatalf("webauthn.New: %v", err)
+	}
+	return rp
+}
+
+func newChallenge(t *testing.T) string {
+	t.Helper()
+	challenge, err := webauthn.NewChallenge()
+	if err != nil {
+		t.Fatalf("NewChallenge: %v", err)
+	}
+	return challenge
+}
+
+// registered registers the authenticator with none attestation and returns the stored credential
+func registered(t *testing.T, rp *webauthn.RelyingParty, a *authenticator) *webauthn.Credential {
+	t.Helper()
+	challenge := newChallenge(t)
+	credential, err := rp.VerifyRegistration(a.register(challenge, attestNone), challenge)
+	if err != nil {
+		t.Fatalf("VerifyRegistration: %v", err)
+	}
+	return credential
+}
+
+func TestRegistrationAttestation(t *testing.T) {
+	rp := newRelyingParty(t, "")
+
+	tests := []struct {
+		attestation string
+		want        string
+	}{
+		{attestation: attestNone, want: webauthn.AttestationNone},
+		{attestation: attestPackedSelf, want: webauthn.AttestationSelf},
+		{attestation: attestPackedBasic, want: webauthn.AttestationBasic},
+	}
+	for _, tt := range tests {
+		t.Run(tt.attestation, func(t *testing.T) {
+			a := newAuthenticator(t, rpID, origin)
+			challenge := newChallenge(t)
+			credential, err := rp.VerifyRegistration(a.register(challenge, tt.attestation), challenge)
+			if err != nil {
+				t.Fatalf("VerifyRegistration: %v", err)
+			}
+			if credential.AttestationType != tt.want {
+				t.Errorf("attestation type = %q, want %q", credential.AttestationType, tt.want)
+			}
+			if !bytes.Equal(credential.ID, a.credentialID) || !bytes.Equal(credential.AAGUID, a.aaguid) || !bytes.Equal(credential.PublicKey, a.publicKey()) {
+				t.Errorf("credential = %+v, want the authenticator's ID, AAGUID and key", credential)
+			}
+			if _, err := webauthn.ParsePublicKey(credential.PublicKey); err != nil {
+				t.Errorf("stored public key does not parse: %v", err)
+			}
+		})
+	}
+}
+
+func TestRegistrationRejectsPackedAttestation(t *testing.T) {
+	rp := newRelyingParty(t, "")
+
+	// The certificate names another authenticator model
+	a := newAuthenticator(t, rpID, origin)
+	a.certAAGUID = make([]byte, 16)
+	challenge := newChallenge(t)
+	if _, err := rp.VerifyRegistration(a.register(challenge, attestPackedBasic), challenge); !errors.Is(err, webauthn.ErrVerification) {
+		t.Errorf("certificate of another AAGUID: error = %v, want ErrVerification", err)
+	}
+
+	// A self attestation signed over another challenge
+	signedElsewhere := a.register(newChallenge(t), attestPackedSelf)
+	cred := a.register(challenge, attestPackedSelf)
+	cred.Response.AttestationObject = signedElsewhere.Response.AttestationObject
+	if _, err := rp.VerifyRegistration(cred, challenge); !errors.Is(err, webauthn.ErrVerification) {
+		t.Errorf("self attestation over another challenge: error = %v, want ErrVerification", err)
+	}
+}
+
+func TestAuthentication(t *testing.T) {
+	rp := newRelyingParty(t, "")
+	a := newAuthenticator(t, rpID, origin)
+	credential := registered(t, rp, a)
+
+	challenge := newChallenge(t)
+	cred := a.assert(challenge)
+	if got, err := webauthn.ChallengeOf(cred.Response.ClientDataJSON); err != nil || got != challenge {
+		t.Fatalf("ChallengeOf = %q, %v; want %q", got, err, challenge)
+	}
+	assertion, err := rp.VerifyAuthentication(cred, challenge, credential.PublicKey, credential.SignCount)
+	if err != nil {
+		t.Fatalf("VerifyAuthentication: %v", err)
+	}
+	if assertion.SignCount != 1 || assertion.UserVerified {
+		t.Errorf("assertion = %+v, want sign count 1 without user verification", assertion)
+	}
+
+	// The response answers one challenge only
+	if _, err := rp.VerifyAuthentication(a.assert(challenge), newChallenge(t), credential.PublicKey, assertion.SignCount); !errors.Is(err, webauthn.ErrVerification) {
+		t.Errorf("other challenge: error = %v, want ErrVerification", err)
+	}
+
+	// The signature is checked against the stored key
+	other := newAuthenticator(t, rpID, origin)
+	if _, err := rp.VerifyAuthentication(a.assert(challenge), challenge, other.publicKey(), assertion.SignCount); !errors.Is(err, webauthn.ErrVerification) {
+		t.Errorf("other key: error = %v, want ErrVerification", err)
+	}
+}
+
+func TestAuthenticationUserVerification(t *testing.T) {
+	rp := newRelyingParty(t, webauthn.UserVerificationRequired)
+	a := newAuthenticator(t, rpID, origin)
+	a.userVerified = true
+	credential := registered(t, rp, a)
+
+	challenge := newChallenge(t)
+	assertion, err := rp.VerifyAuthentication(a.assert(challenge), challenge, credential.PublicKey, credential.SignCount)
+	if err != nil || !assertion.UserVerified {
+		t.Fatalf("VerifyAuthentication = %+v, %v; want a verified user", assertion, err)
+	}
+
+	a.userVerified = false
+	if _, err := rp.VerifyAuthentication(a.assert(challenge), challenge, credential.PublicKey, assertion.SignCount); !errors.Is(err, webauthn.ErrVerification) {
+		t.Errorf("unverified user: error = %v, want ErrVerification", err)
+	}
+}
+
+func TestAuthenticationSignCount(t *testing.T) {
+	rp := newRelyingParty(t, "")
+	a := newAuthenticator(t, rpID, origin)
+	credential := registered(t, rp, a)
+	challenge := newChallenge(t)
+
+	a.signCount = 41
+	assertion, err := rp.VerifyAuthentication(a.assert(challenge), challenge, credential.PublicKey, credential.SignCount)
+	if err != nil || assertion.SignCount != 42 {
+		t.Fatalf("VerifyAuthentication = %+v, %v; want sign count 42", assertion, err)
+	}
+
+	// A clone reports a counter that is not above the stored one
+	for _, count := range []uint32{41, 40, 0} {
+		a.signCount = count
+		if _, err := rp.VerifyAuthentication(a.assert(challenge), challenge, credential.PublicKey, assertion.SignCount); !errors.Is(err, webauthn.ErrSignCount) {
+			t.Errorf("sign count %d after 42: error = %v, want ErrSignCount", count+1, err)
+		}
+	}
+
+	// Authenticators without a counter always report zero
+	a.noCounter = true
+	if _, err := rp.VerifyAuthentication(a.assert(challenge), challenge, credential.PublicKey, 0); err != nil {
+		t.Errorf("counterless authenticator: %v", err)
+	}
+}
+
+func TestOriginMismatch(t *testing.T) {
+	rp := newRelyingParty(t, "")
+	a := newAuthenticator(t, rpID, origin)
+	credential := registered(t, rp, a)
+
+	for _, other := range []string{"https://evil.example.com", "http://example.com", "https://example.com:8443"} {
+		a.origin = other
+		challenge := newChallenge(t)
+		if _, err := rp.VerifyRegistration(a.register(challenge, attestNone), challenge); !errors.Is(err, webauthn.ErrVerification) {
+			t.Errorf("registration from %s: error = %v, want ErrVerification", other, err)
+		}
+		if _, err := rp.VerifyAuthentication(a.assert(challenge), challenge, credential.PublicKey, 0); !errors.Is(err, webauthn.ErrVerification) {
+			t.Errorf("authentication from %s: error = %v, want ErrVerification", other, err)
+		}
+	}
+}
+
+func TestRPIDMismatch(t *testing.T) {
+	rp := newRelyingParty(t, "")
+	a := newAuthenticator(t, rpID, origin)
+	credential := registered(t, rp, a)
+
+	// Credentials scoped to another RP ID, even when the page origin is allowed
+	for _, other := range []string{"evil.com", "login.example.com", "Example.com"} {
+		a.rpID = other
+		challenge := newChallenge(t)
+		if _, err := rp.VerifyRegistration(a.register(challenge, attestNone), challenge); !errors.Is(err, webauthn.ErrVerification) {
+			t.Errorf("registration for %s: error = %v, want ErrVerification", other, err)
+		}
+		if _, err := rp.VerifyAuthentication(a.assert(challenge), challenge, credential.PublicKey, 0); !errors.Is(err, webauthn.ErrVerification) {
+			t.Errorf("authentication for %s: error = %v, want ErrVerification", other, err)
+		}
+	}
+}
@@ -0,0 +1,87 @@
+package webauthn
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/json"
+)
+
+// Client data types
+const (
+	ceremonyCreate = "webauthn.create"
+	ceremonyGet    = "webauthn.get"
+)
+
+// clientData is the parsed clientDataJSON of a ceremony response
+type clientData struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	Origin    string `json:"origin"`
+}
+
+// ChallengeOf returns the base64url challenge a response was signed for, so the caller
+// can look up the ceremony it belongs to before verifying it
+func ChallengeOf(clientDataJSON string) (string, error) {
+	raw, err := Encoding.DecodeString(clientDataJSON)
+	if err != nil {
+		return "", verificationError("clientDataJSON is not base64url")
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return "", verificationError("malformed clientDataJSON")
+	}
+	if data.Challenge == "" {
+		return "", verificationError("clientDataJSON has no challenge")
+	}
+	return data.Challenge, nil
+}
+
+// verifyClientData checks the ceremony type, challenge and origin of clientDataJSON
+// and returns its SHA-256 hash, which the authenticator signed
+func (rp *RelyingParty) verifyClientData(clientDataJSON string, ceremony string, challenge string) ([]byte, error) {
+	raw, err := Encoding.DecodeString(clientDataJSON)
+	if err != nil {
+		return nil, verificationError("clientDataJSON is not base64url")
+	}
+	var data clientData
+	if err := json.Unmarshal(raw, &data); err != nil {
+		return nil, verificationError("malformed clientDataJSON")
+	}
+
+	if data.Type != ceremony {
+		return nil, verificationError("unexpected client data type %q", data.Type)
+	}
+	if subtle.ConstantTimeCompare([]byte(data.Challenge), []byte(challenge)) != 1 {
+		return nil, verificationError("challenge mismatch")
+	}
+	if !rp.allowsOrigin(data.Origin) {
+		return nil, verificationError("origin %q is not allowed", data.Origin)
+	}
+
+	hash := sha256.Sum256(raw)
+	return hash[:], nil
+}
+
+func (rp *RelyingParty) allowsOrigin(origin string) bool {
+	for _, allowed := range rp.cfg.Origins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// verifyAuthenticatorData checks the RP ID hash and the user presence and verification flags
+func (rp *RelyingParty) verifyAuthenticatorData(authData *authenticatorData) error {
+	rpIDHash := sha256.Sum256([]byte(rp.cfg.RPID))
+	if subtle.ConstantTimeCompare(authData.RPIDHash, rpIDHash[:]) != 1 {
+		return verificationError("RP ID hash mismatch")
+	}
+	if !authData.userPresent() {
+		return verificationError("user was not present")
+	}
+	if rp.cfg.UserVerification == UserVerificationRequired && !authData.userVerified() {
+		return verificationError("user was not verified")
+	}
+	return nil
+}
@@ -0,0 +1,169 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"math/big"
+
+	"github.com/fxamacker/cbor/v2"
+)
+
+// Supported COSE algorithms
+const (
+	AlgES256 int64 = -7
+	AlgEdDSA int64 = -8
+	AlgRS256 int64 = -257
+)
+
+// COSE key parameters (RFC 9053)
+const (
+	coseKeyType   = 1
+	coseAlgorithm = 3
+	coseCurve     = -1
+	coseX         = -2
+	coseY         = -3
+	coseRSAN      = -1
+	coseRSAE      = -2
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+	coseKeyTypeRSA = 3
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// PublicKey is a credential public key decoded from its COSE form
+type PublicKey struct {
+	Algorithm int64
+	Key       crypto.PublicKey
+}
+
+// ParsePublicKey decodes a COSE_Key as stored with a credential
+func ParsePublicKey(data []byte) (*PublicKey, error) {
+	var params map[int64]cbor.RawMessage
+	if err := cbor.Unmarshal(data, &params); err != nil {
+		return nil, verificationError("malformed credential public key: %v", err)
+	}
+
+	var kty, alg int64
+	if err := decodeParam(params, coseKeyType, &kty); err != nil {
+		return nil, err
+	}
+	if err := decodeParam(params, coseAlgorithm, &alg); err != nil {
+		return nil, err
+	}
+
+	switch {
+	case kty == coseKeyTypeEC2 && alg == AlgES256:
+		var crv int64
+		var x, y []byte
+		if err := decodeParam(params, coseCurve, &crv); err != nil {
+			return nil, err
+		}
+		if crv != coseCurveP256 {
+			return nil, verificationError("unsupported EC2 curve %d", crv)
+		}
+		if err := decodeParam(params, coseX, &x); err != nil {
+			return nil, err
+		}
+		if err := decodeParam(params, coseY, &y); err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !key.Curve.IsOnCurve(key.X, key.Y) {
+			return nil, verificationError("EC2 public key is not on the curve")
+		}
+		return &PublicKey{Algorithm: alg, Key: key}, nil
+
+	case kty == coseKeyTypeOKP && alg == AlgEdDSA:
+		var crv int64
+		var x []byte
+		if err := decodeParam(params, coseCurve, &crv); err != nil {
+			return nil, err
+		}
+		if crv != coseCurveEd25519 {
+			return nil, verificationError("unsupported OKP curve %d", crv)
+		}
+		if err := decodeParam(params, coseX, &x); err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, verificationError("invalid Ed25519 public key length")
+		}
+		return &PublicKey{Algorithm: alg, Key: ed25519.PublicKey(x)}, nil
+
+	case kty == coseKeyTypeRSA && alg == AlgRS256:
+		var n, e []byte
+		if err := decodeParam(params, coseRSAN, &n); err != nil {
+			return nil, err
+		}
+		if err := decodeParam(params, coseRSAE, &e); err != nil {
+			return nil, err
+		}
+		exponent := new(big.Int).SetBytes(e)
+		if !exponent.IsInt64() || exponent.Int64() > 1<<31-1 {
+			return nil, verificationError("invalid RSA exponent")
+		}
+		return &PublicKey{Algorithm: alg, Key: &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(exponent.Int64())}}, nil
+
+	default:
+		return nil, verificationError("unsupported key type %d with algorithm %d", kty, alg)
+	}
+}
+
+// Verify checks a signature made with the key over data
+func (k *PublicKey) Verify(data, signature []byte) error {
+	return verifySignature(k.Algorithm, k.Key, data, signature)
+}
+
+// verifySignature checks a signature of a COSE algorithm with a public key of the matching type
+func verifySignature(alg int64, key crypto.PublicKey, data, signature []byte) error {
+	switch alg {
+	case AlgES256:
+		pub, ok := key.(*ecdsa.PublicKey)
+		if !ok {
+			return verificationError("key does not match algorithm %d", alg)
+		}
+		digest := sha256.Sum256(data)
+		if !ecdsa.VerifyASN1(pub, digest[:], signature) {
+			return verificationError("invalid signature")
+		}
+	case AlgEdDSA:
+		pub, ok := key.(ed25519.PublicKey)
+		if !ok {
+			return verificationError("key does not match algorithm %d", alg)
+		}
+		if !ed25519.Verify(pub, data, signature) {
+			return verificationError("invalid signature")
+		}
+	case AlgRS256:
+		pub, ok := key.(*rsa.PublicKey)
+		if !ok {
+			return verificationError("key does not match algorithm %d", alg)
+		}
+		digest := sha256.Sum256(data)
+		if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature); err != nil {
+			return verificationError("invalid signature")
+		}
+	default:
+		return verificationError("unsupported algorithm %d", alg)
+	}
+	return nil
+}
+
+// decodeParam decodes a required COSE key parameter into v
+func decodeParam(params map[int64]cbor.RawMessage, label int64, v interface{}) error {
+	raw, ok := params[label]
+	if !ok {
+		return verificationError("credential public key is missing parameter %d", label)
+	}
+	if err := cbor.Unmarshal(raw, v); err != nil {
+		return verificationError("malformed credential public key parameter %d", label)
+	}
+	return nil
+}
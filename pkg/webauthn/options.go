@@ -0,0 +1,112 @@
+package webauthn
+
+// The option types mirror the WebAuthn Level 3 JSON forms, so browsers can pass them to
+// PublicKeyCredential.parseCreationOptionsFromJSON / parseRequestOptionsFromJSON.
+// Binary values are base64url strings.
+
+// RelyingPartyEntity identifies the relying party
+type RelyingPartyEntity struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+}
+
+// UserEntity identifies the account a credential is created for
+type UserEntity struct {
+	ID          string `json:"id"`
+	Name        string `json:"name"`
+	DisplayName string `json:"displayName"`
+}
+
+// CredentialParameter names an accepted credential type and COSE algorithm
+type CredentialParameter struct {
+	Type string `json:"type"`
+	Alg  int64  `json:"alg"`
+}
+
+// CredentialDescriptor references an existing credential
+type CredentialDescriptor struct {
+	Type       string   `json:"type"`
+	ID         string   `json:"id"`
+	Transports []string `json:"transports,omitempty"`
+}
+
+// AuthenticatorSelection states the authenticator requirements of a registration
+type AuthenticatorSelection struct {
+	ResidentKey      string `json:"residentKey"`
+	UserVerification string `json:"userVerification"`
+}
+
+// CreationOptions are the options of a registration ceremony
+type CreationOptions struct {
+	RP                     RelyingPartyEntity     `json:"rp"`
+	User                   UserEntity             `json:"user"`
+	Challenge              string                 `json:"challenge"`
+	PubKeyCredParams       []CredentialParameter  `json:"pubKeyCredParams"`
+	Timeout                int64                  `json:"timeout,omitempty"`
+	ExcludeCredentials     []CredentialDescriptor `json:"excludeCredentials,omitempty"`
+	AuthenticatorSelection AuthenticatorSelection `json:"authenticatorSelection"`
+	Attestation            string                 `json:"attestation"`
+}
+
+// RequestOptions are the options of an authentication ceremony
+type RequestOptions struct {
+	Challenge        string                 `json:"challenge"`
+	Timeout          int64                  `json:"timeout,omitempty"`
+	RPID             string                 `json:"rpId"`
+	AllowCredentials []CredentialDescriptor `json:"allowCredentials,omitempty"`
+	UserVerification string                 `json:"userVerification"`
+}
+
+// credentialType is the only credential type WebAuthn defines
+const credentialType = "public-key"
+
+// NewCreationOptions returns registration options for a user. userID is the opaque user handle
+// stored on the authenticator; exclude lists credentials the user already registered.
+func (rp *RelyingParty) NewCreationOptions(challenge string, userID []byte, name, displayName string, exclude []CredentialDescriptor) CreationOptions {
+	return CreationOptions{
+		RP: RelyingPartyEntity{
+			ID:   rp.cfg.RPID,
+			Name: rp.cfg.RPName,
+		},
+		User: UserEntity{
+			ID:          Encoding.EncodeToString(userID),
+			Name:        name,
+			DisplayName: displayName,
+		},
+		Challenge: challenge,
+		PubKeyCredParams: []CredentialParameter{
+			{Type: credentialType, Alg: AlgES256},
+			{Type: credentialType, Alg: AlgEdDSA},
+			{Type: credentialType, Alg: AlgRS256},
+		},
+		Timeout:            rp.cfg.Timeout.Milliseconds(),
+		ExcludeCredentials: exclude,
+		AuthenticatorSelection: AuthenticatorSelection{
+			ResidentKey:      "preferred",
+			UserVerification: rp.cfg.UserVerification,
+		},
+		// Only none and packed statements are verified, so ask clients not to send others
+		Attestation: "none",
+	}
+}
+
+// NewRequestOptions returns authentication options. An empty allow list starts a
+// discoverable credential (usernameless) ceremony.
+func (rp *RelyingParty) NewRequestOptions(challenge string, allow []CredentialDescriptor) RequestOptions {
+	return RequestOptions{
+		Challenge:        challenge,
+		Timeout:          rp.cfg.Timeout.Milliseconds(),
+		RPID:             rp.cfg.RPID,
+		AllowCredentials: allow,
+		UserVerification: rp.cfg.UserVerification,
+	}
+}
+
+// NewCredentialDescriptor references a stored credential by its base64url ID
+func NewCredentialDescriptor(id string, transports []string) CredentialDescriptor {
+	return CredentialDescriptor{
+		Type:       credentialType,
+		ID:         id,
+		Transports: transports,
+	}
+}
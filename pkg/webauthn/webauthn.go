@@ -0,0 +1,84 @@
+package webauthn
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// User verification requirements
+const (
+	UserVerificationRequired    = "required"
+	UserVerificationPreferred   = "preferred"
+	UserVerificationDiscouraged = "discouraged"
+)
+
+// ErrVerification is wrapped by every error caused by an invalid ceremony response
+var ErrVerification = errors.New("webauthn verification failed")
+
+// defaultTimeout applies when Config.Timeout is not set
+const defaultTimeout = 5 * time.Minute
+
+// Encoding is the unpadded base64url encoding used for every binary value in WebAuthn JSON
+var Encoding = base64.RawURLEncoding
+
+// Config holds the relying party settings
+type Config struct {
+	// RPID is the relying party ID, the registrable domain credentials are scoped to
+	RPID string
+	// RPName is the name authenticators show to the user
+	RPName string
+	// Origins lists the accepted client origins, e.g. https://example.com or android:apk-key-hash:...
+	Origins []string
+	// UserVerification is required, preferred or discouraged
+	UserVerification string
+	// Timeout is how long a ceremony challenge stays valid
+	Timeout time.Duration
+}
+
+// RelyingParty issues ceremony options and verifies authenticator responses
+type RelyingParty struct {
+	cfg Config
+}
+
+// New creates a relying party from cfg
+func New(cfg Config) (*RelyingParty, error) {
+	if cfg.RPID == "" {
+		return nil, fmt.Errorf("webauthn relying party ID is required")
+	}
+	if len(cfg.Origins) == 0 {
+		return nil, fmt.Errorf("webauthn requires at least one allowed origin")
+	}
+	if cfg.Timeout <= 0 {
+		cfg.Timeout = defaultTimeout
+	}
+	switch cfg.UserVerification {
+	case "":
+		cfg.UserVerification = UserVerificationPreferred
+	case UserVerificationRequired, UserVerificationPreferred, UserVerificationDiscouraged:
+	default:
+		return nil, fmt.Errorf("unknown webauthn user verification requirement: %s", cfg.UserVerification)
+	}
+	return &RelyingParty{cfg: cfg}, nil
+}
+
+// Config returns the relying party settings
+func (rp *RelyingParty) Config() Config {
+	return rp.cfg
+}
+
+// NewChallenge returns a random 256-bit ceremony challenge encoded as base64url
+func NewChallenge() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate webauthn challenge: %w", err)
+	}
+	return Encoding.EncodeToString(buf), nil
+}
+
+// verificationError wraps ErrVerification with a reason
+func verificationError(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrVerification, fmt.Sprintf(format, args...))
+}